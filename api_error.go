@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"golang-developer-test-task/structs"
+	"net/http"
+
+	"github.com/mailru/easyjson"
+	"go.uber.org/zap"
+)
+
+// ErrorKind classifies errors returned to API clients
+type ErrorKind int
+
+const (
+	// KindInternal is for unexpected server errors
+	KindInternal ErrorKind = iota
+	// KindBadRequest is for malformed requests: unreadable body, broken JSON, wrong parameter types
+	KindBadRequest
+	// KindUnauthorized is for requests without valid credentials
+	KindUnauthorized
+	// KindForbidden is for requests with credentials which do not grant access
+	KindForbidden
+	// KindValidation is for well-formed requests with semantically invalid content
+	KindValidation
+	// KindNotFound is for missing resources
+	KindNotFound
+	// KindMethodNotAllowed is for requests with method which route does not accept
+	KindMethodNotAllowed
+	// KindPayloadTooLarge is for request or upstream bodies exceeding limits
+	KindPayloadTooLarge
+	// KindUnsupportedMediaType is for request bodies of wrong Content-Type
+	KindUnsupportedMediaType
+	// KindRateLimited is for clients which exceeded their rate limit
+	KindRateLimited
+	// KindUpstreamFetch is for failures of fetching data from third-party URL
+	KindUpstreamFetch
+	// KindStorage is for failures of the storage
+	KindStorage
+)
+
+var errorKinds = map[ErrorKind]struct {
+	code   string
+	status int
+}{
+	KindInternal:             {"internal_error", http.StatusInternalServerError},
+	KindBadRequest:           {"bad_request", http.StatusBadRequest},
+	KindUnauthorized:         {"unauthorized", http.StatusUnauthorized},
+	KindForbidden:            {"forbidden", http.StatusForbidden},
+	KindValidation:           {"validation_failed", http.StatusUnprocessableEntity},
+	KindNotFound:             {"not_found", http.StatusNotFound},
+	KindMethodNotAllowed:     {"method_not_allowed", http.StatusMethodNotAllowed},
+	KindPayloadTooLarge:      {"payload_too_large", http.StatusRequestEntityTooLarge},
+	KindUnsupportedMediaType: {"unsupported_media_type", http.StatusUnsupportedMediaType},
+	KindRateLimited:          {"rate_limited", http.StatusTooManyRequests},
+	KindUpstreamFetch:        {"upstream_fetch_failed", http.StatusBadGateway},
+	KindStorage:              {"storage_unavailable", http.StatusServiceUnavailable},
+}
+
+// Code returns machine-readable code of ErrorKind
+func (k ErrorKind) Code() string {
+	return errorKinds[k].code
+}
+
+// Status returns HTTP status of ErrorKind
+func (k ErrorKind) Status() int {
+	return errorKinds[k].status
+}
+
+// APIError is error which message is safe to show to API clients
+type APIError struct {
+	Kind    ErrorKind
+	Message string
+	Err     error
+}
+
+func newAPIError(kind ErrorKind, message string, err error) *APIError {
+	return &APIError{Kind: kind, Message: message, Err: err}
+}
+
+// Error implements error interface
+func (e *APIError) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+// Unwrap returns the cause of APIError
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// writeError logs err and renders it as JSON error envelope,
+// errors which are not APIError are rendered as internal errors
+func (d *DBProcessor) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		apiErr = newAPIError(KindInternal, "internal server error", err)
+	}
+	requestID := RequestIDFromContext(r.Context())
+
+	fields := []zap.Field{
+		zap.String("code", apiErr.Kind.Code()),
+		zap.String("request_id", requestID),
+		zap.String("path", r.URL.Path),
+		zap.Error(err),
+	}
+	if apiErr.Kind.Status() >= http.StatusInternalServerError {
+		d.logger.Error(apiErr.Message, fields...)
+	} else {
+		d.logger.Info(apiErr.Message, fields...)
+	}
+
+	bs, _ := easyjson.Marshal(structs.ErrorObject{
+		Code:      apiErr.Kind.Code(),
+		Message:   apiErr.Message,
+		RequestID: requestID,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(apiErr.Kind.Status())
+	_, _ = w.Write(bs)
+}
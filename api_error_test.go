@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"golang-developer-test-task/infrastructure/redclient"
+	"golang-developer-test-task/structs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v8"
+	"github.com/mailru/easyjson"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+func TestErrorKindStatus(t *testing.T) {
+	tests := map[ErrorKind]int{
+		KindInternal:             http.StatusInternalServerError,
+		KindBadRequest:           http.StatusBadRequest,
+		KindValidation:           http.StatusUnprocessableEntity,
+		KindUnauthorized:         http.StatusUnauthorized,
+		KindRateLimited:          http.StatusTooManyRequests,
+		KindForbidden:            http.StatusForbidden,
+		KindNotFound:             http.StatusNotFound,
+		KindMethodNotAllowed:     http.StatusMethodNotAllowed,
+		KindPayloadTooLarge:      http.StatusRequestEntityTooLarge,
+		KindUnsupportedMediaType: http.StatusUnsupportedMediaType,
+		KindUpstreamFetch:        http.StatusBadGateway,
+		KindStorage:              http.StatusServiceUnavailable,
+	}
+	codes := make(map[string]bool, len(tests))
+	for kind, status := range tests {
+		if kind.Status() != status {
+			t.Errorf("kind %d: got status %d but wanted %d", kind, kind.Status(), status)
+		}
+		if kind.Code() == "" || codes[kind.Code()] {
+			t.Errorf("kind %d: code %q is empty or duplicated", kind, kind.Code())
+		}
+		codes[kind.Code()] = true
+	}
+}
+
+func TestAPIErrorUnwrap(t *testing.T) {
+	cause := errors.New("test error")
+	err := newAPIError(KindStorage, "cannot search in storage", cause)
+	if !errors.Is(err, cause) {
+		t.Errorf("APIError does not wrap its cause")
+	}
+	if err.Error() != "cannot search in storage: test error" {
+		t.Errorf("got message %q", err.Error())
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	db, _ := redismock.NewClientMock()
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
+	logger, _ := zap.NewProduction()
+	defer func() {
+		_ = logger.Sync()
+	}()
+	cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+	processor := NewDBProcessor(client, logger, &singleflight.Group{}, cache)
+
+	tests := []struct {
+		err     error
+		status  int
+		code    string
+		message string
+	}{
+		{newAPIError(KindNotFound, "parking not found", nil), http.StatusNotFound, "not_found", "parking not found"},
+		{errors.New("secret details"), http.StatusInternalServerError, "internal_error", "internal server error"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/parkings/1", nil)
+		req.Header.Set(RequestIDHeader, "test-request")
+		res := httptest.NewRecorder()
+		h := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			processor.writeError(w, r, tt.err)
+		}))
+		h.ServeHTTP(res, req)
+
+		checkErrorResponse(t, res, tt.status, tt.code)
+		var body structs.ErrorObject
+		_ = easyjson.Unmarshal(res.Body.Bytes(), &body)
+		if body.Message != tt.message {
+			t.Errorf("got message %q but wanted %q", body.Message, tt.message)
+		}
+		if body.RequestID != "test-request" {
+			t.Errorf("got request id %q but wanted %q", body.RequestID, "test-request")
+		}
+	}
+}
+
+func TestHandlersErrorEnvelope(t *testing.T) {
+	db, _ := redismock.NewClientMock()
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
+	logger, _ := zap.NewProduction()
+	defer func() {
+		_ = logger.Sync()
+	}()
+	cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+	processor := NewDBProcessor(client, logger, &singleflight.Group{}, cache)
+
+	tests := []struct {
+		name        string
+		handler     Handler
+		target      string
+		contentType string
+		body        string
+		status      int
+		code        string
+	}{
+		{"search bad json", processor.HandleSearch, "/api/search", "application/json", "{", http.StatusBadRequest, "bad_request"},
+		{"search no keys", processor.HandleSearch, "/api/search", "application/json", "{}", http.StatusUnprocessableEntity, "validation_failed"},
+		{"search media type", processor.HandleSearch, "/api/search", "text/plain", "{}", http.StatusUnsupportedMediaType, "unsupported_media_type"},
+		{"search storage", processor.HandleSearch, "/api/search", "", `{"id":1}`, http.StatusServiceUnavailable, "storage_unavailable"},
+		{"batch bad json", processor.HandleSearchBatch, "/api/search/batch", "", "[", http.StatusBadRequest, "bad_request"},
+		{"batch storage", processor.HandleSearchBatch, "/api/search/batch", "", `{"id":[1]}`, http.StatusServiceUnavailable, "storage_unavailable"},
+		{"parking not found", processor.HandleParking, "/api/parkings/by-name/1", "", "", http.StatusNotFound, "not_found"},
+		{"parking bad id", processor.HandleParking, "/api/parkings/by-id/a", "", "", http.StatusBadRequest, "bad_request"},
+		{"load json media type", processor.HandleLoadJSON, "/api/load_json", "text/csv", "[]", http.StatusUnsupportedMediaType, "unsupported_media_type"},
+		{"load json invalid dataset", processor.HandleLoadJSON, "/api/load_json", "application/json", "{}", http.StatusUnprocessableEntity, "validation_failed"},
+		{"load file not multipart", processor.HandleLoadFile, "/api/load_file", "application/json", "[]", http.StatusUnsupportedMediaType, "unsupported_media_type"},
+		{"load file broken multipart", processor.HandleLoadFile, "/api/load_file", "multipart/form-data; boundary=x", "--y", http.StatusBadRequest, "bad_request"},
+		{"load from url bad json", processor.HandleLoadFromURL, "/api/load_from_url", "", "{", http.StatusBadRequest, "bad_request"},
+		{"load from url invalid url", processor.HandleLoadFromURL, "/api/load_from_url", "", `{"url":"://a"}`, http.StatusUnprocessableEntity, "validation_failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.target, bytes.NewBufferString(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			res := httptest.NewRecorder()
+			requestIDMiddleware(http.HandlerFunc(tt.handler)).ServeHTTP(res, req)
+
+			checkErrorResponse(t, res, tt.status, tt.code)
+		})
+	}
+}
+
+func checkErrorResponse(t *testing.T, res *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	if res.Code != status {
+		t.Errorf("got status %d but wanted %d", res.Code, status)
+	}
+	if contentType := res.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("got Content-Type %q but wanted %q", contentType, "application/json")
+	}
+	var body structs.ErrorObject
+	if err := easyjson.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body %q is not error object: %v", res.Body.String(), err)
+	}
+	if body.Code != code {
+		t.Errorf("got code %q but wanted %q", body.Code, code)
+	}
+	if body.Message == "" {
+		t.Errorf("message is empty")
+	}
+	if body.RequestID == "" || body.RequestID != res.Header().Get(RequestIDHeader) {
+		t.Errorf("got request id %q, header %q", body.RequestID, res.Header().Get(RequestIDHeader))
+	}
+}
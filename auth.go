@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"golang-developer-test-task/infrastructure/config"
+	"golang-developer-test-task/infrastructure/storage"
+	"golang-developer-test-task/structs"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mailru/easyjson"
+)
+
+const (
+	// RoleSearch allows only reading the dataset
+	RoleSearch = "search"
+	// RoleAdmin allows everything RoleSearch does, loading the dataset and managing API keys
+	RoleAdmin = "admin"
+
+	// APIKeyHeader is alternative to "Authorization: Bearer <key>" header
+	APIKeyHeader = "X-API-Key"
+
+	// bootstrapKeyID identifies bootstrap admin key, it is never stored
+	bootstrapKeyID = "bootstrap"
+	// minAdminKeyLength keeps bootstrap admin key from being guessed
+	minAdminKeyLength = 32
+	apiKeyIDLength    = 8
+	apiKeySecretLen   = 32
+
+	// adminKeyPlaceholder starts admin key shipped in .env, it is rejected so that it is not deployed
+	adminKeyPlaceholder = "change-me"
+)
+
+type apiKeyIDKey struct{}
+
+// APIKeyIDFromContext returns ID of API key authenticated by Authenticator.Require
+func APIKeyIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(apiKeyIDKey{}).(string)
+	return id
+}
+
+// AuthConfig is struct for storing API key authentication settings
+type AuthConfig struct {
+	Enabled bool
+	// AdminKey is admin key which works without being stored, it is used to create the first keys
+	AdminKey string
+}
+
+// settings binds AuthConfig fields to config file keys, environment variables and flags
+func (c *AuthConfig) settings() []config.Setting {
+	return []config.Setting{
+		{Key: "auth.enabled", Env: []string{"AUTH_ENABLED"}, Usage: "require API keys",
+			Default: "true", Value: config.Bool(&c.Enabled)},
+		{Key: "auth.admin_key", Env: []string{"ADMIN_API_KEY"},
+			Usage:  fmt.Sprintf("bootstrap admin API key of at least %d characters", minAdminKeyLength),
+			Secret: true, Value: config.String(&c.AdminKey)},
+	}
+}
+
+// Load is useful for loading AuthConfig data from environment,
+// authentication is enabled unless AUTH_ENABLED is false
+func (c *AuthConfig) Load() error {
+	if err := config.LoadEnv(c.settings()); err != nil {
+		return err
+	}
+	return c.Validate()
+}
+
+// Validate checks that AuthConfig values are usable
+func (c *AuthConfig) Validate() error {
+	if c.Enabled && len(c.AdminKey) < minAdminKeyLength {
+		return fmt.Errorf("admin api key must be at least %d characters long when authentication is enabled",
+			minAdminKeyLength)
+	}
+	if c.Enabled && strings.HasPrefix(c.AdminKey, adminKeyPlaceholder) {
+		return errors.New("admin api key is the placeholder of .env, set a random one, e.g. openssl rand -hex 32, " +
+			"or AUTH_ENABLED=false for local development")
+	}
+	return nil
+}
+
+// Authenticator checks API keys of requests and manages them
+type Authenticator struct {
+	keys       storage.APIKeyStore
+	config     AuthConfig
+	writeError func(http.ResponseWriter, *http.Request, error)
+}
+
+// NewAuthenticator is constructor for Authenticator
+func NewAuthenticator(keys storage.APIKeyStore, config AuthConfig,
+	writeError func(http.ResponseWriter, *http.Request, error)) *Authenticator {
+	return &Authenticator{
+		keys:       keys,
+		config:     config,
+		writeError: writeError,
+	}
+}
+
+// Require passes to next handler only requests with API key granting role,
+// every request is passed if authentication is disabled
+func (a *Authenticator) Require(role string, next http.Handler) http.Handler {
+	if !a.config.Enabled {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keyID, keyRole, err := a.authenticate(r.Context(), apiKeyFromRequest(r))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+			a.writeError(w, r, err)
+			return
+		}
+		if keyRole != role && keyRole != RoleAdmin {
+			a.writeError(w, r, newAPIError(KindForbidden,
+				fmt.Sprintf("api key does not grant %q role", role), nil))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyIDKey{}, keyID)))
+	})
+}
+
+// authenticate returns ID and role of API key in "<id>.<secret>" format
+func (a *Authenticator) authenticate(ctx context.Context, token string) (id, role string, err error) {
+	if token == "" {
+		return "", "", newAPIError(KindUnauthorized, "api key is required", nil)
+	}
+	if a.config.AdminKey != "" {
+		// hashes have equal length, so comparison time does not depend on the key length
+		want := sha256.Sum256([]byte(a.config.AdminKey))
+		got := sha256.Sum256([]byte(token))
+		if subtle.ConstantTimeCompare(got[:], want[:]) == 1 {
+			return bootstrapKeyID, RoleAdmin, nil
+		}
+	}
+
+	invalid := newAPIError(KindUnauthorized, "api key is invalid", nil)
+	id, secret, ok := strings.Cut(token, ".")
+	if !ok || !validAPIKeyID(id) {
+		return "", "", invalid
+	}
+	key, err := a.keys.GetAPIKey(ctx, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return "", "", invalid
+	}
+	if err != nil {
+		return "", "", newAPIError(KindStorage, "cannot check api key", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(hashAPIKeySecret(secret)), []byte(key.Hash)) != 1 {
+		return "", "", invalid
+	}
+	return id, key.Role, nil
+}
+
+// apiKeyFromRequest takes API key from Authorization or X-API-Key header
+func apiKeyFromRequest(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); len(auth) > len("Bearer ") &&
+		strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+		return strings.TrimSpace(auth[len("Bearer "):])
+	}
+	return r.Header.Get(APIKeyHeader)
+}
+
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// validAPIKeyID allows only IDs generated by newAPIKey
+func validAPIKeyID(id string) bool {
+	if len(id) != 2*apiKeyIDLength {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+// newAPIKey generates ID and secret of API key
+func newAPIKey() (id, secret string, err error) {
+	b := make([]byte, apiKeyIDLength+apiKeySecretLen)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(b[:apiKeyIDLength]), base64.RawURLEncoding.EncodeToString(b[apiKeyIDLength:]), nil
+}
+
+// HandleCreateKey is handler for /api/admin/keys
+func (a *Authenticator) HandleCreateKey(w http.ResponseWriter, r *http.Request) {
+	var keyObj structs.APIKeyObject
+	if err := readJSON(r, &keyObj); err != nil {
+		a.writeError(w, r, err)
+		return
+	}
+	if keyObj.Role != RoleSearch && keyObj.Role != RoleAdmin {
+		a.writeError(w, r, newAPIError(KindValidation,
+			fmt.Sprintf("role must be %q or %q, got %q", RoleSearch, RoleAdmin, keyObj.Role), nil))
+		return
+	}
+	id, secret, err := newAPIKey()
+	if err != nil {
+		a.writeError(w, r, err)
+		return
+	}
+	err = a.keys.SaveAPIKey(r.Context(), storage.APIKey{
+		ID:        id,
+		Hash:      hashAPIKeySecret(secret),
+		Role:      keyObj.Role,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		a.writeError(w, r, newAPIError(KindStorage, "cannot save api key", err))
+		return
+	}
+
+	bs, _ := easyjson.Marshal(structs.APIKeyObject{ID: id, Key: id + "." + secret, Role: keyObj.Role})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(bs)
+}
+
+// HandleRevokeKey is handler for /api/admin/keys/{id}
+func (a *Authenticator) HandleRevokeKey(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/admin/keys/")
+	if !validAPIKeyID(id) {
+		a.writeError(w, r, newAPIError(KindNotFound, "api key not found", nil))
+		return
+	}
+	err := a.keys.DeleteAPIKey(r.Context(), id)
+	if errors.Is(err, storage.ErrNotFound) {
+		a.writeError(w, r, newAPIError(KindNotFound, "api key not found", nil))
+		return
+	}
+	if err != nil {
+		a.writeError(w, r, newAPIError(KindStorage, "cannot revoke api key", err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"golang-developer-test-task/infrastructure/redclient"
+	"golang-developer-test-task/infrastructure/storage"
+	"golang-developer-test-task/structs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redismock/v8"
+	"github.com/mailru/easyjson"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+func TestAuthConfigLoad(t *testing.T) {
+	adminKey := strings.Repeat("a", minAdminKeyLength)
+	tests := []struct {
+		enabled  string
+		adminKey string
+		want     AuthConfig
+		wantErr  bool
+	}{
+		{"", adminKey, AuthConfig{Enabled: true, AdminKey: adminKey}, false},
+		{"true", adminKey, AuthConfig{Enabled: true, AdminKey: adminKey}, false},
+		{"false", "", AuthConfig{Enabled: false}, false},
+		{"", "", AuthConfig{}, true},
+		{"true", "short", AuthConfig{}, true},
+		{"true", adminKeyPlaceholder + adminKey, AuthConfig{}, true},
+		{"false", adminKeyPlaceholder + adminKey, AuthConfig{AdminKey: adminKeyPlaceholder + adminKey}, false},
+		{"maybe", adminKey, AuthConfig{}, true},
+	}
+	for _, tt := range tests {
+		t.Setenv("AUTH_ENABLED", tt.enabled)
+		t.Setenv("ADMIN_API_KEY", tt.adminKey)
+
+		var config AuthConfig
+		err := config.Load()
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%q, %q: expected error", tt.enabled, tt.adminKey)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q, %q: %v", tt.enabled, tt.adminKey, err)
+		}
+		if config != tt.want {
+			t.Errorf("got config %v but wanted %v", config, tt.want)
+		}
+	}
+}
+
+func TestAPIKeyFromRequest(t *testing.T) {
+	tests := []struct {
+		headers map[string]string
+		want    string
+	}{
+		{map[string]string{"Authorization": "Bearer abc.def"}, "abc.def"},
+		{map[string]string{"Authorization": "bearer  abc.def "}, "abc.def"},
+		{map[string]string{APIKeyHeader: "abc.def"}, "abc.def"},
+		{map[string]string{"Authorization": "Basic YWJjOmRlZg==", APIKeyHeader: "abc.def"}, "abc.def"},
+		{map[string]string{"Authorization": "Basic YWJjOmRlZg=="}, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/search", nil)
+		for key, value := range tt.headers {
+			req.Header.Set(key, value)
+		}
+		if got := apiKeyFromRequest(req); got != tt.want {
+			t.Errorf("%v: got key %q but wanted %q", tt.headers, got, tt.want)
+		}
+	}
+}
+
+func newTestAuthenticator(t *testing.T, client *redclient.RedisClient, config AuthConfig) *Authenticator {
+	t.Helper()
+	logger, _ := zap.NewProduction()
+	t.Cleanup(func() {
+		_ = logger.Sync()
+	})
+	cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+	processor := NewDBProcessor(client, logger, &singleflight.Group{}, cache)
+	return NewAuthenticator(client, config, processor.writeError)
+}
+
+func TestRequireDisabled(t *testing.T) {
+	db, _ := redismock.NewClientMock()
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
+	auth := newTestAuthenticator(t, client, AuthConfig{})
+
+	handler := auth.Require(RoleAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req := httptest.NewRequest("POST", "/api/load_json", nil)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusOK)
+	}
+}
+
+func TestRequire(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redclient.NewRedisClient(context.Background(), redclient.RedisConfig{Addr: mr.Addr()})
+	adminKey := strings.Repeat("a", minAdminKeyLength)
+	auth := newTestAuthenticator(t, client, AuthConfig{Enabled: true, AdminKey: adminKey})
+
+	id, secret, err := newAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.SaveAPIKey(context.Background(), storage.APIKey{
+		ID:   id,
+		Hash: hashAPIKeySecret(secret),
+		Role: RoleSearch,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		role   string
+		key    string
+		status int
+	}{
+		{"bootstrap admin key", RoleAdmin, adminKey, http.StatusOK},
+		{"bootstrap admin key for search", RoleSearch, adminKey, http.StatusOK},
+		{"search key", RoleSearch, id + "." + secret, http.StatusOK},
+		{"search key for admin", RoleAdmin, id + "." + secret, http.StatusForbidden},
+		{"no key", RoleSearch, "", http.StatusUnauthorized},
+		{"wrong secret", RoleSearch, id + ".wrong", http.StatusUnauthorized},
+		{"unknown id", RoleSearch, "0000000000000000." + secret, http.StatusUnauthorized},
+		{"malformed id", RoleSearch, "dataset." + secret, http.StatusUnauthorized},
+		{"no separator", RoleSearch, id + secret, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		handler := auth.Require(tt.role, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		req := httptest.NewRequest("GET", "/api/search", nil)
+		req.Header.Set(APIKeyHeader, tt.key)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		if res.Code != tt.status {
+			t.Errorf("%s: got status %d but wanted %d", tt.name, res.Code, tt.status)
+		}
+		wantChallenge := tt.status == http.StatusUnauthorized
+		if got := res.Header().Get("WWW-Authenticate") != ""; got != wantChallenge {
+			t.Errorf("%s: got WWW-Authenticate %q", tt.name, res.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
+
+func TestCreateAndRevokeKey(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redclient.NewRedisClient(context.Background(), redclient.RedisConfig{Addr: mr.Addr()})
+	auth := newTestAuthenticator(t, client,
+		AuthConfig{Enabled: true, AdminKey: strings.Repeat("a", minAdminKeyLength)})
+
+	req := httptest.NewRequest("POST", "/api/admin/keys", strings.NewReader(`{"role":"admin"}`))
+	res := httptest.NewRecorder()
+	auth.HandleCreateKey(res, req)
+
+	if res.Code != http.StatusCreated {
+		t.Fatalf("got status %d but wanted %d", res.Code, http.StatusCreated)
+	}
+	var keyObj structs.APIKeyObject
+	if err := easyjson.Unmarshal(res.Body.Bytes(), &keyObj); err != nil {
+		t.Fatal(err)
+	}
+	if keyObj.Role != RoleAdmin || !strings.HasPrefix(keyObj.Key, keyObj.ID+".") {
+		t.Fatalf("wrong key: %v", keyObj)
+	}
+	stored, err := client.GetAPIKey(context.Background(), keyObj.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(keyObj.Key, stored.Hash) || stored.Hash == strings.TrimPrefix(keyObj.Key, keyObj.ID+".") {
+		t.Errorf("secret is stored in plain text")
+	}
+
+	id, role, err := auth.authenticate(context.Background(), keyObj.Key)
+	if err != nil || id != keyObj.ID || role != RoleAdmin {
+		t.Fatalf("got role %q and error %v but wanted %q", role, err, RoleAdmin)
+	}
+
+	req = httptest.NewRequest("DELETE", "/api/admin/keys/"+keyObj.ID, nil)
+	res = httptest.NewRecorder()
+	auth.HandleRevokeKey(res, req)
+
+	if res.Code != http.StatusNoContent {
+		t.Fatalf("got status %d but wanted %d", res.Code, http.StatusNoContent)
+	}
+	if _, _, err = auth.authenticate(context.Background(), keyObj.Key); err == nil {
+		t.Errorf("revoked key is accepted")
+	}
+
+	for _, target := range []string{"/api/admin/keys/" + keyObj.ID, "/api/admin/keys/abc", "/api/admin/keys/"} {
+		req = httptest.NewRequest("DELETE", target, nil)
+		res = httptest.NewRecorder()
+		auth.HandleRevokeKey(res, req)
+
+		if res.Code != http.StatusNotFound {
+			t.Errorf("%s: got status %d but wanted %d", target, res.Code, http.StatusNotFound)
+		}
+	}
+}
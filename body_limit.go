@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"golang-developer-test-task/infrastructure/config"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultUploadLimit   = 32 << 20
+	defaultUpstreamLimit = 32 << 20
+	defaultQueryLimit    = 1 << 20
+)
+
+// BodyLimits are maximum sizes of bodies in bytes
+type BodyLimits struct {
+	// Upload limits request bodies carrying the dataset: load_file and load_json
+	Upload int64
+	// Upstream limits content fetched by load_from_url
+	Upstream int64
+	// Query limits request bodies of other routes: search queries, URLs and API keys
+	Query int64
+}
+
+// DefaultBodyLimits returns limits used when nothing is configured
+func DefaultBodyLimits() BodyLimits {
+	return BodyLimits{
+		Upload:   defaultUploadLimit,
+		Upstream: defaultUpstreamLimit,
+		Query:    defaultQueryLimit,
+	}
+}
+
+// settings binds BodyLimits fields to config file keys, environment variables and flags
+func (l *BodyLimits) settings() []config.Setting {
+	return []config.Setting{
+		{Key: "body_limit.upload", Env: []string{"BODY_LIMIT_UPLOAD"}, Usage: "maximum size of uploaded dataset in bytes",
+			Default: strconv.Itoa(defaultUploadLimit), Value: config.Int64(&l.Upload)},
+		{Key: "body_limit.upstream", Env: []string{"BODY_LIMIT_UPSTREAM"},
+			Usage:   "maximum size of dataset fetched by load_from_url in bytes",
+			Default: strconv.Itoa(defaultUpstreamLimit), Value: config.Int64(&l.Upstream)},
+		{Key: "body_limit.query", Env: []string{"BODY_LIMIT_QUERY"}, Usage: "maximum size of other request bodies in bytes",
+			Default: strconv.Itoa(defaultQueryLimit), Value: config.Int64(&l.Query)},
+	}
+}
+
+// Load is useful for loading BodyLimits data from environment
+func (l *BodyLimits) Load() error {
+	if err := config.LoadEnv(l.settings()); err != nil {
+		return err
+	}
+	return l.Validate()
+}
+
+// Validate checks that BodyLimits values are usable
+func (l *BodyLimits) Validate() error {
+	if l.Upload <= 0 || l.Upstream <= 0 || l.Query <= 0 {
+		return fmt.Errorf("body limits must be positive, got %+v", *l)
+	}
+	return nil
+}
+
+// limitedBody remembers that request body exceeded its limit,
+// as some readers, e.g. multipart one, do not keep original error
+type limitedBody struct {
+	io.ReadCloser
+	limit    int64
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+// limitBody rejects request bodies larger than limit, declared length is checked before reading
+func (d *DBProcessor) limitBody(limit int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limit {
+			d.writeError(w, r, newAPIError(KindPayloadTooLarge,
+				fmt.Sprintf("request body exceeds %d bytes", limit), nil))
+			return
+		}
+		r.Body = &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, limit), limit: limit}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bodyLimitError returns 413 error if err was caused by request body exceeding its limit, nil otherwise
+func bodyLimitError(r *http.Request, err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return newAPIError(KindPayloadTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxErr.Limit), err)
+	}
+	if body, ok := r.Body.(*limitedBody); ok && body.exceeded {
+		return newAPIError(KindPayloadTooLarge, fmt.Sprintf("request body exceeds %d bytes", body.limit), err)
+	}
+	return nil
+}
+
+// maxBytesReader is io.Reader which returns *http.MaxBytesError after limit bytes,
+// unlike io.LimitReader it does not cut data silently
+type maxBytesReader struct {
+	r     io.Reader
+	limit int64
+	left  int64
+}
+
+func newMaxBytesReader(r io.Reader, limit int64) io.Reader {
+	return &maxBytesReader{r: r, limit: limit, left: limit}
+}
+
+func (m *maxBytesReader) Read(p []byte) (int, error) {
+	if m.left < 0 {
+		return 0, &http.MaxBytesError{Limit: m.limit}
+	}
+	// one byte more than left is read to find out whether the limit is exceeded
+	if int64(len(p)) > m.left+1 {
+		p = p[:m.left+1]
+	}
+	n, err := m.r.Read(p)
+	m.left -= int64(n)
+	if m.left < 0 {
+		return n + int(m.left), &http.MaxBytesError{Limit: m.limit}
+	}
+	return n, err
+}
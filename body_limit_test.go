@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"golang-developer-test-task/infrastructure/redclient"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v8"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+func TestBodyLimitsLoad(t *testing.T) {
+	t.Setenv("BODY_LIMIT_UPLOAD", "")
+	t.Setenv("BODY_LIMIT_UPSTREAM", "2048")
+	t.Setenv("BODY_LIMIT_QUERY", "")
+	var limits BodyLimits
+	if err := limits.Load(); err != nil {
+		t.Fatal(err)
+	}
+	want := BodyLimits{Upload: defaultUploadLimit, Upstream: 2048, Query: defaultQueryLimit}
+	if limits != want {
+		t.Errorf("got limits %v but wanted %v", limits, want)
+	}
+
+	for _, v := range []string{"0", "-1", "1MB"} {
+		t.Setenv("BODY_LIMIT_QUERY", v)
+		if err := limits.Load(); err == nil {
+			t.Errorf("%q: expected error", v)
+		}
+	}
+}
+
+func TestMaxBytesReader(t *testing.T) {
+	for size, exceeded := range map[int]bool{0: false, 9: false, 10: false, 11: true, 100: true} {
+		data := strings.Repeat("a", size)
+		bs, err := io.ReadAll(newMaxBytesReader(strings.NewReader(data), 10))
+
+		var maxErr *http.MaxBytesError
+		if got := errors.As(err, &maxErr); got != exceeded {
+			t.Errorf("%d: got exceeded %v but wanted %v, error %v", size, got, exceeded, err)
+		}
+		if !exceeded && string(bs) != data {
+			t.Errorf("%d: got data %q but wanted %q", size, bs, data)
+		}
+		if exceeded && len(bs) > 10 {
+			t.Errorf("%d: got %d bytes over the limit", size, len(bs))
+		}
+	}
+}
+
+func newLimitedTestProcessor(t *testing.T, limits BodyLimits) *DBProcessor {
+	t.Helper()
+	db, _ := redismock.NewClientMock()
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
+	logger, _ := zap.NewProduction()
+	t.Cleanup(func() {
+		_ = logger.Sync()
+	})
+	cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+	return NewDBProcessor(client, logger, &singleflight.Group{}, cache, WithBodyLimits(limits),
+		WithURLFetcher(NewURLFetcher(FetchConfig{AllowPrivate: true})))
+}
+
+func TestHandleLoadFromURLChunkedTooLarge(t *testing.T) {
+	chunk := "[" + strings.Repeat(" ", 511)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// flushing before the end makes the response chunked, without Content-Length
+		for i := 0; i < 4; i++ {
+			_, _ = w.Write([]byte(chunk))
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+
+	processor := newLimitedTestProcessor(t, BodyLimits{Upload: 1024, Upstream: 1024, Query: 1024})
+	req := httptest.NewRequest("POST", "/api/load_from_url", strings.NewReader(`{"url":"`+server.URL+`"}`))
+	res := httptest.NewRecorder()
+	requestIDMiddleware(http.HandlerFunc(processor.HandleLoadFromURL)).ServeHTTP(res, req)
+
+	checkErrorResponse(t, res, http.StatusRequestEntityTooLarge, "payload_too_large")
+}
+
+func TestRouteBodyLimits(t *testing.T) {
+	limits := BodyLimits{Upload: 1024, Upstream: 1024, Query: 64}
+	processor := newLimitedTestProcessor(t, limits)
+	client := processor.store.(*redclient.RedisClient)
+	auth := NewAuthenticator(client, AuthConfig{}, processor.writeError)
+	limiter := NewRateLimiter(client, RateLimitConfig{}, processor.logger, processor.writeError)
+	health := NewHealth(client, HealthConfig{ReadyTimeout: time.Second})
+	router := NewAPIRouter(processor, auth, limiter, health, http.NotFoundHandler())
+
+	dataset := "[" + strings.Repeat(" ", 2048) + "]"
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("uploadFile", "data.json")
+	_, _ = part.Write([]byte(dataset))
+	_ = writer.Close()
+
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        string
+		chunked     bool
+		status      int
+	}{
+		{"load json", "/api/load_json", "application/json", "[]", false, http.StatusOK},
+		{"load json declared length", "/api/load_json", "application/json", dataset, false,
+			http.StatusRequestEntityTooLarge},
+		{"load json chunked", "/api/load_json", "application/json", dataset, true,
+			http.StatusRequestEntityTooLarge},
+		{"load from json chunked", "/api/load_from_json", "application/json", dataset, true,
+			http.StatusRequestEntityTooLarge},
+		{"load file chunked", "/api/load_file", writer.FormDataContentType(), body.String(), true,
+			http.StatusRequestEntityTooLarge},
+		{"search", "/api/search", "application/json", `{"offset":1}`, true, http.StatusUnprocessableEntity},
+		{"search chunked", "/api/search", "application/json", `{"mode":"` + strings.Repeat("a", 64) + `"}`, true,
+			http.StatusRequestEntityTooLarge},
+		{"search batch chunked", "/api/search/batch", "application/json",
+			`{"id":[` + strings.Repeat("1,", 64) + `1]}`, true, http.StatusRequestEntityTooLarge},
+		{"load from url chunked", "/api/load_from_url", "application/json",
+			`{"url":"https://example.com/` + strings.Repeat("a", 64) + `"}`, true, http.StatusRequestEntityTooLarge},
+		{"admin keys chunked", "/api/admin/keys", "application/json",
+			`{"role":"` + strings.Repeat("a", 64) + `"}`, true, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		var reader io.Reader = strings.NewReader(tt.body)
+		if tt.chunked {
+			// MultiReader hides the length, so the request is sent without Content-Length
+			reader = io.MultiReader(reader)
+		}
+		req := httptest.NewRequest("POST", tt.target, reader)
+		req.Header.Set("Content-Type", tt.contentType)
+		if tt.chunked && req.ContentLength != -1 {
+			t.Fatalf("%s: request has Content-Length %d", tt.name, req.ContentLength)
+		}
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+
+		if res.Code != tt.status {
+			t.Errorf("%s: got status %d but wanted %d", tt.name, res.Code, tt.status)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"golang-developer-test-task/infrastructure/config"
+	"golang-developer-test-task/structs"
+	"strconv"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// CachePolicyLRU evicts the least recently used item when capacity is reached,
+	// items expire TTL after insertion
+	CachePolicyLRU = "lru"
+	// CachePolicySliding evicts like CachePolicyLRU but every hit extends item's TTL
+	CachePolicySliding = "sliding"
+
+	defaultCacheCapacity = 10000
+	defaultCacheTTL      = 5 * time.Minute
+	defaultVersionTTL    = time.Second
+)
+
+// CacheConfig is struct for storing search cache settings
+type CacheConfig struct {
+	Capacity uint64
+	TTL      time.Duration
+	Policy   string
+	// VersionTTL is how long dataset version is kept in process, responses may be that stale
+	// after import done by another instance
+	VersionTTL time.Duration
+}
+
+// settings binds CacheConfig fields to config file keys, environment variables and flags
+func (c *CacheConfig) settings() []config.Setting {
+	return []config.Setting{
+		{Key: "cache.capacity", Env: []string{"CACHE_CAPACITY"}, Usage: "maximum number of cached search results, 0 is unlimited",
+			Default: strconv.Itoa(defaultCacheCapacity), Value: config.Uint64(&c.Capacity)},
+		{Key: "cache.ttl", Env: []string{"CACHE_TTL"}, Usage: "lifetime of cached search results",
+			Default: defaultCacheTTL.String(), Value: config.Duration(&c.TTL)},
+		{Key: "cache.policy", Env: []string{"CACHE_POLICY"}, Usage: "cache eviction policy, lru or sliding",
+			Default: CachePolicyLRU, Value: config.String(&c.Policy)},
+		{Key: "cache.version_ttl", Env: []string{"CACHE_VERSION_TTL"},
+			Usage:   "how long dataset version is kept between reads from storage, 0 reads it on every search",
+			Default: defaultVersionTTL.String(), Value: config.Duration(&c.VersionTTL)},
+	}
+}
+
+// Load is useful for loading CacheConfig data from environment
+func (c *CacheConfig) Load() error {
+	if err := config.LoadEnv(c.settings()); err != nil {
+		return err
+	}
+	return c.Validate()
+}
+
+// Validate checks that CacheConfig values are usable
+func (c *CacheConfig) Validate() error {
+	if c.TTL < 0 {
+		return fmt.Errorf("cache ttl must not be negative, got %s", c.TTL)
+	}
+	if c.VersionTTL < 0 {
+		return fmt.Errorf("cache version ttl must not be negative, got %s", c.VersionTTL)
+	}
+	switch c.Policy {
+	case CachePolicyLRU, CachePolicySliding:
+		return nil
+	default:
+		return fmt.Errorf("unknown cache policy %q, expected %q or %q",
+			c.Policy, CachePolicyLRU, CachePolicySliding)
+	}
+}
+
+// NewSearchCache is constructor for search results cache with given config
+func NewSearchCache(config CacheConfig) *ttlcache.Cache[string, structs.PaginationObject] {
+	opts := []ttlcache.Option[string, structs.PaginationObject]{
+		ttlcache.WithTTL[string, structs.PaginationObject](config.TTL),
+		ttlcache.WithCapacity[string, structs.PaginationObject](config.Capacity),
+	}
+	if config.Policy != CachePolicySliding {
+		opts = append(opts, ttlcache.WithDisableTouchOnHit[string, structs.PaginationObject]())
+	}
+	return ttlcache.New[string, structs.PaginationObject](opts...)
+}
+
+// NewCacheCollectors returns prometheus collectors exporting cache metrics
+func NewCacheCollectors(cache *ttlcache.Cache[string, structs.PaginationObject],
+	config CacheConfig) []prometheus.Collector {
+	evictions := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "search_cache_eviction_counter",
+			Help: "Search cache evictions per reason",
+		},
+		[]string{"reason"})
+	cache.OnEviction(func(_ context.Context, reason ttlcache.EvictionReason,
+		_ *ttlcache.Item[string, structs.PaginationObject]) {
+		evictions.WithLabelValues(evictionReason(reason)).Inc()
+	})
+
+	return []prometheus.Collector{
+		evictions,
+		prometheus.NewCounterFunc(
+			prometheus.CounterOpts{
+				Name: "search_cache_hit_counter",
+				Help: "Search cache hits",
+			},
+			func() float64 { return float64(cache.Metrics().Hits) }),
+		prometheus.NewCounterFunc(
+			prometheus.CounterOpts{
+				Name: "search_cache_miss_counter",
+				Help: "Search cache misses",
+			},
+			func() float64 { return float64(cache.Metrics().Misses) }),
+		prometheus.NewGaugeFunc(
+			prometheus.GaugeOpts{
+				Name: "search_cache_size",
+				Help: "Number of items in search cache",
+			},
+			func() float64 { return float64(cache.Len()) }),
+		prometheus.NewGaugeFunc(
+			prometheus.GaugeOpts{
+				Name: "search_cache_capacity",
+				Help: "Maximum number of items in search cache, 0 is unlimited",
+			},
+			func() float64 { return float64(config.Capacity) }),
+	}
+}
+
+func evictionReason(reason ttlcache.EvictionReason) string {
+	switch reason {
+	case ttlcache.EvictionReasonDeleted:
+		return "deleted"
+	case ttlcache.EvictionReasonCapacityReached:
+		return "capacity"
+	case ttlcache.EvictionReasonExpired:
+		return "expired"
+	default:
+		return "unknown"
+	}
+}
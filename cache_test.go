@@ -0,0 +1,114 @@
+package main
+
+import (
+	"golang-developer-test-task/structs"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCacheConfigLoadDefaults(t *testing.T) {
+	config := CacheConfig{}
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if config.Capacity != defaultCacheCapacity {
+		t.Errorf("got capacity %d but wanted %d", config.Capacity, defaultCacheCapacity)
+	}
+	if config.TTL != defaultCacheTTL {
+		t.Errorf("got ttl %s but wanted %s", config.TTL, defaultCacheTTL)
+	}
+	if config.Policy != CachePolicyLRU {
+		t.Errorf("got policy %q but wanted %q", config.Policy, CachePolicyLRU)
+	}
+}
+
+func TestCacheConfigLoad(t *testing.T) {
+	t.Setenv("CACHE_CAPACITY", "42")
+	t.Setenv("CACHE_TTL", "30s")
+	t.Setenv("CACHE_POLICY", CachePolicySliding)
+	config := CacheConfig{}
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if config.Capacity != 42 {
+		t.Errorf("got capacity %d but wanted %d", config.Capacity, 42)
+	}
+	if config.TTL != 30*time.Second {
+		t.Errorf("got ttl %s but wanted %s", config.TTL, 30*time.Second)
+	}
+	if config.Policy != CachePolicySliding {
+		t.Errorf("got policy %q but wanted %q", config.Policy, CachePolicySliding)
+	}
+}
+
+func TestCacheConfigLoadErr(t *testing.T) {
+	tests := map[string]string{
+		"CACHE_CAPACITY": "-1",
+		"CACHE_TTL":      "abracadabra",
+		"CACHE_POLICY":   "random",
+	}
+	for env, value := range tests {
+		t.Run(env, func(t *testing.T) {
+			t.Setenv(env, value)
+			config := CacheConfig{}
+			if err := config.Load(); err == nil {
+				t.Errorf("expected error for %s=%s", env, value)
+			}
+		})
+	}
+}
+
+func TestSearchCacheCapacityEviction(t *testing.T) {
+	config := CacheConfig{Capacity: 2, TTL: time.Minute, Policy: CachePolicyLRU}
+	cache := NewSearchCache(config)
+	collectors := NewCacheCollectors(cache, config)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors...)
+
+	for i := 0; i < 5; i++ {
+		cache.Set(strconv.Itoa(i), structs.PaginationObject{}, ttlcache.DefaultTTL)
+	}
+	_ = cache.Get("4")
+	_ = cache.Get("0")
+
+	if cache.Len() != 2 {
+		t.Errorf("got cache size %d but wanted %d", cache.Len(), 2)
+	}
+	if got := testutil.ToFloat64(collectors[1]); got != 1 {
+		t.Errorf("got %v hits but wanted %v", got, 1)
+	}
+	if got := testutil.ToFloat64(collectors[2]); got != 1 {
+		t.Errorf("got %v misses but wanted %v", got, 1)
+	}
+	if got := testutil.ToFloat64(collectors[3]); got != 2 {
+		t.Errorf("got size gauge %v but wanted %v", got, 2)
+	}
+	// eviction callbacks are executed in separate goroutines
+	evictions := collectors[0].(*prometheus.CounterVec).WithLabelValues("capacity")
+	deadline := time.Now().Add(time.Second)
+	for testutil.ToFloat64(evictions) != 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := testutil.ToFloat64(evictions); got != 3 {
+		t.Errorf("got %v capacity evictions but wanted %v", got, 3)
+	}
+}
+
+func TestSearchCachePolicy(t *testing.T) {
+	ttl := 100 * time.Millisecond
+	for policy, alive := range map[string]bool{CachePolicyLRU: false, CachePolicySliding: true} {
+		cache := NewSearchCache(CacheConfig{TTL: ttl, Policy: policy})
+		cache.Set("key", structs.PaginationObject{}, ttlcache.DefaultTTL)
+		time.Sleep(ttl * 2 / 3)
+		_ = cache.Get("key")
+		time.Sleep(ttl * 2 / 3)
+		if got := cache.Get("key") != nil; got != alive {
+			t.Errorf("policy %q: item alive = %v but wanted %v", policy, got, alive)
+		}
+	}
+}
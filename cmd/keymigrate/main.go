@@ -0,0 +1,80 @@
+// Command keymigrate moves keys of the service into namespace given by REDIS_KEY_PREFIX,
+// it is run once with the service stopped before starting it with the new prefix.
+// Without -apply it only reports keys which would be moved.
+// Redis connection is configured as for the service: by flags, environment or redis section of config file
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"golang-developer-test-task/infrastructure/config"
+	"golang-developer-test-task/infrastructure/redclient"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	var redisConfig redclient.RedisConfig
+	settings := redisConfig.Settings()
+	fs := flag.NewFlagSet("keymigrate", flag.ContinueOnError)
+	from := fs.String("from", "", "key prefix the keys are kept with now, bare keys if empty")
+	apply := fs.Bool("apply", false, "move the keys, without it keys to move are only counted")
+	configFile := fs.String("config", "", "path to YAML config file of the service (env CONFIG_FILE)")
+	flags := config.RegisterFlags(fs, settings)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *configFile == "" {
+		*configFile = os.Getenv("CONFIG_FILE")
+	}
+
+	file := make(map[string]string)
+	if *configFile != "" {
+		values, err := config.ReadFile(*configFile)
+		if err != nil {
+			return err
+		}
+		// settings of other sections are not known here
+		for key, value := range values {
+			if strings.HasPrefix(key, "redis.") {
+				file[key] = value
+			}
+		}
+	}
+	errs := config.Apply(settings, config.Sources{File: file, Env: os.LookupEnv, Flags: flags})
+	errs.Check("redis", redisConfig.Validate())
+	if err := errs.Err(); err != nil {
+		return err
+	}
+
+	client, err := redclient.NewLazyRedisClient(redisConfig)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result, err := client.MigrateKeys(ctx, *from, !*apply)
+	verb := "renamed"
+	if !*apply {
+		verb = "would rename"
+	}
+	fmt.Printf("%s %d keys from prefix %q to %q\n", verb, result.Renamed, *from, redisConfig.KeyPrefix)
+	for _, key := range result.Conflicts {
+		fmt.Printf("kept %s, its new name is taken\n", key)
+	}
+	if err == nil && !*apply {
+		fmt.Println("dry run, run with -apply to move the keys")
+	}
+	return err
+}
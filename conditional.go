@@ -0,0 +1,60 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// searchETag builds entity tag for search response from dataset version and query
+func searchETag(version int64, query string) string {
+	h := sha256.New()
+	_, _ = h.Write([]byte(strconv.FormatInt(version, 10)))
+	_, _ = h.Write([]byte{'|'})
+	_, _ = h.Write([]byte(query))
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// setValidators writes ETag, Last-Modified and Cache-Control headers
+func setValidators(w http.ResponseWriter, etag string, modified time.Time) {
+	w.Header().Set("ETag", etag)
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	// caches may store the response but must revalidate it on each request
+	w.Header().Set("Cache-Control", "no-cache")
+}
+
+// notModified reports whether request preconditions match current validators (RFC 7232, section 6)
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagMatches(inm, etag)
+	}
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || modified.IsZero() {
+		return false
+	}
+	t, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	// Last-Modified has seconds precision
+	return !modified.Truncate(time.Second).After(t)
+}
+
+// etagMatches does weak comparison of etag with If-None-Match header value
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"golang-developer-test-task/infrastructure/redclient"
+	"golang-developer-test-task/structs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/mailru/easyjson"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+func TestSearchETag(t *testing.T) {
+	etag := searchETag(1, "777|0")
+	if etag != searchETag(1, "777|0") {
+		t.Errorf("etag is not stable")
+	}
+	if etag == searchETag(2, "777|0") {
+		t.Errorf("etag does not depend on version")
+	}
+	if etag == searchETag(1, "777|5") {
+		t.Errorf("etag does not depend on query")
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	etag := `"abc"`
+	modified := time.Date(2022, 9, 1, 12, 0, 0, 500, time.UTC)
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{"no headers", nil, false},
+		{"etag match", map[string]string{"If-None-Match": `"abc"`}, true},
+		{"weak etag match", map[string]string{"If-None-Match": `W/"abc"`}, true},
+		{"etag list match", map[string]string{"If-None-Match": `"x", "abc"`}, true},
+		{"etag star", map[string]string{"If-None-Match": `*`}, true},
+		{"etag mismatch", map[string]string{"If-None-Match": `"x"`}, false},
+		{"etag has priority", map[string]string{
+			"If-None-Match":     `"x"`,
+			"If-Modified-Since": modified.Format(http.TimeFormat),
+		}, false},
+		{"not modified since", map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, true},
+		{"modified since", map[string]string{
+			"If-Modified-Since": modified.Add(-time.Second).Format(http.TimeFormat),
+		}, false},
+		{"broken date", map[string]string{"If-Modified-Since": "yesterday"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/search", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			if got := notModified(req, etag, modified); got != tt.want {
+				t.Errorf("got %v but wanted %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleSearchConditional(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redclient.NewRedisClient(context.Background(), redclient.RedisConfig{Addr: mr.Addr()})
+	info := structs.Info{GlobalID: 42, SystemObjectID: "777", ID: 1, IDEn: 9, Mode: "abc", ModeEn: "cba"}
+	if err := client.AddValue(context.Background(), info); err != nil {
+		t.Fatal(err)
+	}
+	modified := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := client.BumpDatasetVersion(context.Background(), modified); err != nil {
+		t.Fatal(err)
+	}
+
+	logger, _ := zap.NewProduction()
+	defer func() {
+		_ = logger.Sync()
+	}()
+	cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+	processor := NewDBProcessor(client, logger, &singleflight.Group{}, cache)
+
+	search := func(headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/search?system_object_id="+info.SystemObjectID, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		res := httptest.NewRecorder()
+		processor.HandleSearch(res, req)
+		return res
+	}
+
+	res := search(nil)
+	if res.Code != http.StatusOK {
+		t.Fatalf("got status %d but wanted %d", res.Code, http.StatusOK)
+	}
+	etag := res.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("ETag header is empty")
+	}
+	if got := res.Header().Get("Last-Modified"); got != modified.Format(http.TimeFormat) {
+		t.Errorf("got Last-Modified %q but wanted %q", got, modified.Format(http.TimeFormat))
+	}
+
+	res = search(map[string]string{"If-None-Match": etag})
+	if res.Code != http.StatusNotModified {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusNotModified)
+	}
+	if res.Body.Len() != 0 {
+		t.Errorf("304 response has body %q", res.Body.String())
+	}
+
+	res = search(map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)})
+	if res.Code != http.StatusNotModified {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusNotModified)
+	}
+
+	if _, err := client.BumpDatasetVersion(context.Background(), modified.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	res = search(map[string]string{"If-None-Match": etag})
+	if res.Code != http.StatusOK {
+		t.Errorf("got status %d but wanted %d after import", res.Code, http.StatusOK)
+	}
+	if res.Header().Get("ETag") == etag {
+		t.Errorf("ETag was not changed after import")
+	}
+
+	// preconditions of POST are ignored
+	bs, _ := easyjson.Marshal(structs.SearchObject{SystemObjectID: &info.SystemObjectID})
+	req := httptest.NewRequest("POST", "/api/search", bytes.NewBuffer(bs))
+	req.Header.Set("If-None-Match", "*")
+	res = httptest.NewRecorder()
+	processor.HandleSearch(res, req)
+	if res.Code != http.StatusOK {
+		t.Errorf("got status %d of POST but wanted %d", res.Code, http.StatusOK)
+	}
+}
+
+func TestDatasetVersionTTL(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redclient.NewRedisClient(context.Background(), redclient.RedisConfig{Addr: mr.Addr()})
+	info := structs.Info{GlobalID: 42, SystemObjectID: "777", ID: 1, IDEn: 9, Mode: "abc", ModeEn: "cba"}
+	if err := client.AddValue(context.Background(), info); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.BumpDatasetVersion(context.Background(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	logger, _ := zap.NewProduction()
+	defer func() {
+		_ = logger.Sync()
+	}()
+	cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+	processor := NewDBProcessor(client, logger, &singleflight.Group{}, cache, WithDatasetVersionTTL(time.Hour))
+	search := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/search?system_object_id="+info.SystemObjectID, nil)
+		res := httptest.NewRecorder()
+		processor.HandleSearch(res, req)
+		return res
+	}
+	etag := search().Header().Get("ETag")
+
+	// cached result is served with kept version while storage is down
+	mr.SetError("down")
+	res := search()
+	if res.Code != http.StatusOK || res.Header().Get("ETag") != etag {
+		t.Errorf("got status %d and ETag %q but wanted cached response with %q",
+			res.Code, res.Header().Get("ETag"), etag)
+	}
+	mr.SetError("")
+
+	// import of this process replaces kept version at once
+	bs, _ := easyjson.Marshal(structs.InfoList{info})
+	req := httptest.NewRequest("POST", "/api/load_json", bytes.NewBuffer(bs))
+	processor.HandleLoadJSON(httptest.NewRecorder(), req)
+	if err := processor.WaitImports(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := search().Header().Get("ETag"); got == etag {
+		t.Errorf("ETag was not changed after import")
+	}
+}
+
+func TestHandleParkingConditional(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redclient.NewRedisClient(context.Background(), redclient.RedisConfig{Addr: mr.Addr()})
+	info := structs.Info{GlobalID: 42, SystemObjectID: "777", ID: 1, IDEn: 9, Mode: "abc", ModeEn: "cba"}
+	if err := client.AddValue(context.Background(), info); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.BumpDatasetVersion(context.Background(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	logger, _ := zap.NewProduction()
+	defer func() {
+		_ = logger.Sync()
+	}()
+	cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+	processor := NewDBProcessor(client, logger, &singleflight.Group{}, cache)
+	get := func(target, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		res := httptest.NewRecorder()
+		processor.HandleParking(res, req)
+		return res
+	}
+
+	etag := get("/api/parkings/777", "").Header().Get("ETag")
+	if res := get("/api/parkings/777", etag); res.Code != http.StatusNotModified {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusNotModified)
+	}
+	if err := client.DeleteValue(context.Background(), info.SystemObjectID); err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range []string{"/api/parkings/777", "/api/parkings/by-global-id/42"} {
+		if res := get(target, "*"); res.Code != http.StatusNotFound {
+			t.Errorf("%s: got status %d of deleted parking but wanted %d", target, res.Code, http.StatusNotFound)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"golang-developer-test-task/infrastructure/config"
+	"golang-developer-test-task/infrastructure/redclient"
+	"io"
+)
+
+// Config is configuration of the whole service
+type Config struct {
+	Server    ServerConfig
+	Health    HealthConfig
+	Storage   StorageConfig
+	Redis     redclient.RedisConfig
+	Cache     CacheConfig
+	Auth      AuthConfig
+	CSRF      CSRFConfig
+	Fetch     FetchConfig
+	Limits    BodyLimits
+	RateLimit RateLimitConfig
+
+	// configFile is path to YAML config file, it is set only by flag or environment
+	configFile string
+	// printConfig asks to print effective configuration and exit
+	printConfig bool
+}
+
+func (c *Config) settings() []config.Setting {
+	var settings []config.Setting
+	for _, section := range [][]config.Setting{
+		c.Server.settings(),
+		c.Health.settings(),
+		c.Storage.settings(),
+		c.Redis.Settings(),
+		c.Cache.settings(),
+		c.Auth.settings(),
+		c.CSRF.settings(),
+		c.Fetch.settings(),
+		c.Limits.settings(),
+		c.RateLimit.settings(),
+	} {
+		settings = append(settings, section...)
+	}
+	return settings
+}
+
+// LoadConfig loads configuration from command-line arguments, environment variables,
+// YAML file given by --config flag or CONFIG_FILE variable and defaults, in order of decreasing priority,
+// all invalid settings are listed in returned config.Errors
+func LoadConfig(name string, args []string, lookupEnv func(string) (string, bool), output io.Writer) (*Config, error) {
+	c := &Config{}
+	settings := c.settings()
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&c.configFile, "config", "", "path to YAML config file (env CONFIG_FILE)")
+	fs.BoolVar(&c.printConfig, "print-config", false, "print effective configuration and exit")
+	flags := config.RegisterFlags(fs, settings)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if c.configFile == "" {
+		c.configFile, _ = lookupEnv("CONFIG_FILE")
+	}
+
+	var file map[string]string
+	if c.configFile != "" {
+		var err error
+		if file, err = config.ReadFile(c.configFile); err != nil {
+			return nil, err
+		}
+	}
+	errs := config.Apply(settings, config.Sources{File: file, Env: lookupEnv, Flags: flags})
+	errs.Check("server", c.Server.Validate())
+	errs.Check("health", c.Health.Validate())
+	errs.Check("storage", c.Storage.Validate())
+	errs.Check("redis", c.Redis.Validate())
+	errs.Check("cache", c.Cache.Validate())
+	errs.Check("auth", c.Auth.Validate())
+	errs.Check("csrf", c.CSRF.Validate())
+	errs.Check("fetch", c.Fetch.Validate())
+	errs.Check("body_limit", c.Limits.Validate())
+	errs.Check("rate_limit", c.RateLimit.Validate())
+	return c, errs.Err()
+}
+
+// Print writes effective configuration in format of config file, secrets are masked
+func (c *Config) Print(w io.Writer) error {
+	return config.Print(w, c.settings())
+}
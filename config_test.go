@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"golang-developer-test-task/infrastructure/config"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigSettingsUnique(t *testing.T) {
+	var c Config
+	keys := make(map[string]bool)
+	envs := make(map[string]bool)
+	for _, s := range c.settings() {
+		if keys[s.Key] {
+			t.Errorf("duplicate key %s", s.Key)
+		}
+		keys[s.Key] = true
+		for _, env := range s.Env {
+			if envs[env] {
+				t.Errorf("duplicate environment variable %s", env)
+			}
+			envs[env] = true
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `
+server:
+  port: 9000
+redis:
+  addr: file:6379
+  db: 3
+cache:
+  policy: sliding
+fetch:
+  allowed_hosts: [data.mos.ru]
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{
+		"CONFIG_FILE":  path,
+		"AUTH_ENABLED": "false",
+		"REDIS_ADDR":   "env:6379",
+		"PORT":         "9001",
+	}
+	conf, err := LoadConfig("test", []string{"--server-port", "9002", "--cache-ttl=1m"},
+		func(name string) (string, bool) {
+			v, ok := env[name]
+			return v, ok
+		}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if conf.Server.Port != 9002 {
+		t.Errorf("got port %d but wanted %d", conf.Server.Port, 9002)
+	}
+	if conf.Redis.Addr != "env:6379" || conf.Redis.DB != 3 {
+		t.Errorf("got redis config %+v", conf.Redis)
+	}
+	if conf.Cache.Policy != CachePolicySliding || conf.Cache.TTL != time.Minute {
+		t.Errorf("got cache config %+v", conf.Cache)
+	}
+	if len(conf.Fetch.AllowedHosts) != 1 || conf.Fetch.AllowedHosts[0] != "data.mos.ru" {
+		t.Errorf("got fetch config %+v", conf.Fetch)
+	}
+	if conf.Auth.Enabled || conf.RateLimit.SearchLimit != defaultSearchRateLimit {
+		t.Errorf("got auth config %+v and rate limit config %+v", conf.Auth, conf.RateLimit)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	env := map[string]string{"REDIS_DB": "first", "ADMIN_API_KEY": "short"}
+	conf, err := LoadConfig("test", []string{"--print-config", "--cache-policy", "fifo", "--body-limit-query=0"},
+		func(name string) (string, bool) {
+			v, ok := env[name]
+			return v, ok
+		}, io.Discard)
+
+	var errs config.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("got error %v but wanted config.Errors", err)
+	}
+	for _, want := range []string{"REDIS_DB", "cache:", "auth:", "body_limit:"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%s is not listed in %v", want, err)
+		}
+	}
+	// invalid configuration is printed too, to see where the wrong value comes from
+	if conf == nil || !conf.printConfig {
+		t.Fatalf("print-config is not parsed")
+	}
+	var b strings.Builder
+	if err = conf.Print(&b); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(b.String(), "short") {
+		t.Errorf("admin key is printed:\n%s", b.String())
+	}
+	if !strings.Contains(b.String(), "policy: fifo") {
+		t.Errorf("cache policy is not printed:\n%s", b.String())
+	}
+}
+
+func TestLoadConfigFlags(t *testing.T) {
+	noEnv := func(string) (string, bool) { return "", false }
+	if _, err := LoadConfig("test", []string{"-h"}, noEnv, io.Discard); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("got error %v but wanted %v", err, flag.ErrHelp)
+	}
+	if _, err := LoadConfig("test", []string{"--unknown"}, noEnv, io.Discard); err == nil {
+		t.Errorf("expected error of unknown flag")
+	}
+	if _, err := LoadConfig("test", []string{"--config", "missing.yaml"}, noEnv, io.Discard); err == nil {
+		t.Errorf("expected error of missing config file")
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"golang-developer-test-task/infrastructure/config"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// csrfCookieName is cookie which keeps CSRF token of the form, it must match "token" form field
+	csrfCookieName = "csrf_token"
+	csrfFormField  = "token"
+	csrfNonceLen   = 16
+
+	defaultCSRFTTL = time.Hour
+	// minCSRFSecretLength keeps signatures of CSRF tokens from being forged
+	minCSRFSecretLength = 32
+)
+
+// CSRFConfig is struct for storing CSRF protection settings
+type CSRFConfig struct {
+	// Secret signs CSRF tokens, random secret is generated if it is empty,
+	// then tokens are not accepted after restart or by other replicas
+	Secret string
+	TTL    time.Duration
+}
+
+// settings binds CSRFConfig fields to config file keys, environment variables and flags
+func (c *CSRFConfig) settings() []config.Setting {
+	return []config.Setting{
+		{Key: "csrf.secret", Env: []string{"CSRF_SECRET"},
+			Usage:  fmt.Sprintf("secret of at least %d characters signing CSRF tokens, random if empty", minCSRFSecretLength),
+			Secret: true, Value: config.String(&c.Secret)},
+		{Key: "csrf.ttl", Env: []string{"CSRF_TTL"}, Usage: "lifetime of CSRF tokens",
+			Default: defaultCSRFTTL.String(), Value: config.Duration(&c.TTL)},
+	}
+}
+
+// Load is useful for loading CSRFConfig data from environment
+func (c *CSRFConfig) Load() error {
+	if err := config.LoadEnv(c.settings()); err != nil {
+		return err
+	}
+	return c.Validate()
+}
+
+// Validate checks that CSRFConfig values are usable
+func (c *CSRFConfig) Validate() error {
+	if c.Secret != "" && len(c.Secret) < minCSRFSecretLength {
+		return fmt.Errorf("csrf secret must be at least %d characters long", minCSRFSecretLength)
+	}
+	if c.TTL <= 0 {
+		return fmt.Errorf("csrf ttl must be positive, got %s", c.TTL)
+	}
+	return nil
+}
+
+// CSRFProtector issues and verifies signed CSRF tokens in "<nonce>.<expiry>.<signature>" format,
+// token is submitted both in cookie and in form field
+type CSRFProtector struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewCSRFProtector is constructor for CSRFProtector
+func NewCSRFProtector(config CSRFConfig) *CSRFProtector {
+	secret := []byte(config.Secret)
+	if len(secret) == 0 {
+		secret = make([]byte, minCSRFSecretLength)
+		_, _ = rand.Read(secret)
+	}
+	ttl := config.TTL
+	if ttl <= 0 {
+		ttl = defaultCSRFTTL
+	}
+	return &CSRFProtector{secret: secret, ttl: ttl, now: time.Now}
+}
+
+// NewToken returns new random token valid for TTL of CSRFProtector
+func (c *CSRFProtector) NewToken() string {
+	nonce := make([]byte, csrfNonceLen)
+	_, _ = rand.Read(nonce)
+	payload := base64.RawURLEncoding.EncodeToString(nonce) + "." +
+		strconv.FormatInt(c.now().Add(c.ttl).Unix(), 10)
+	return payload + "." + c.sign(payload)
+}
+
+// SetCookie issues new token, sets it to cookie and returns it to be put into the form
+func (c *CSRFProtector) SetCookie(w http.ResponseWriter, r *http.Request) string {
+	token := c.NewToken()
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookieName,
+		Value:    token,
+		Path:     "/api/",
+		MaxAge:   int(c.ttl.Seconds()),
+		Secure:   r.TLS != nil,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	return token
+}
+
+// Verify checks that request has the same valid token in cookie and form field,
+// form must be parsed before
+func (c *CSRFProtector) Verify(r *http.Request) error {
+	cookie, err := r.Cookie(csrfCookieName)
+	if err != nil {
+		return newAPIError(KindForbidden, "csrf cookie is missing", err)
+	}
+	token := r.FormValue(csrfFormField)
+	if token == "" {
+		return newAPIError(KindForbidden, "csrf token is missing", nil)
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(cookie.Value)) != 1 {
+		return newAPIError(KindForbidden, "csrf token does not match cookie", nil)
+	}
+	return c.validate(token)
+}
+
+// validate checks signature and expiry of token
+func (c *CSRFProtector) validate(token string) error {
+	invalid := newAPIError(KindForbidden, "csrf token is invalid", nil)
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 {
+		return invalid
+	}
+	payload, signature := token[:i], token[i+1:]
+	if !hmac.Equal([]byte(signature), []byte(c.sign(payload))) {
+		return invalid
+	}
+	_, expiry, ok := strings.Cut(payload, ".")
+	if !ok {
+		return invalid
+	}
+	expiresAt, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil {
+		return invalid
+	}
+	if c.now().Unix() >= expiresAt {
+		return newAPIError(KindForbidden, "csrf token is expired", nil)
+	}
+	return nil
+}
+
+func (c *CSRFProtector) sign(payload string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	_, _ = mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
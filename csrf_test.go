@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"golang-developer-test-task/infrastructure/redclient"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v8"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// writeCSRFToken adds valid CSRF token to the form and returns cookie which must be sent with it
+func writeCSRFToken(processor *DBProcessor, writer *multipart.Writer) *http.Cookie {
+	token := processor.csrf.NewToken()
+	_ = writer.WriteField(csrfFormField, token)
+	return &http.Cookie{Name: csrfCookieName, Value: token}
+}
+
+func TestCSRFConfigLoad(t *testing.T) {
+	t.Setenv("CSRF_SECRET", "")
+	t.Setenv("CSRF_TTL", "")
+	var config CSRFConfig
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if config.TTL != defaultCSRFTTL {
+		t.Errorf("got ttl %s but wanted %s", config.TTL, defaultCSRFTTL)
+	}
+
+	for secret, ttl := range map[string]string{
+		"short":                         "1h",
+		strings.Repeat("s", 32):         "-1h",
+		strings.Repeat("s", 32) + "abc": "hour",
+	} {
+		t.Setenv("CSRF_SECRET", secret)
+		t.Setenv("CSRF_TTL", ttl)
+		if err := config.Load(); err == nil {
+			t.Errorf("%q, %q: expected error", secret, ttl)
+		}
+	}
+}
+
+func TestCSRFProtectorValidate(t *testing.T) {
+	now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	csrf := NewCSRFProtector(CSRFConfig{Secret: strings.Repeat("s", 32), TTL: time.Hour})
+	csrf.now = func() time.Time { return now }
+	token := csrf.NewToken()
+
+	if err := csrf.validate(token); err != nil {
+		t.Fatal(err)
+	}
+	if token == csrf.NewToken() {
+		t.Errorf("tokens are not random")
+	}
+
+	other := NewCSRFProtector(CSRFConfig{Secret: strings.Repeat("o", 32), TTL: time.Hour})
+	other.now = csrf.now
+	parts := strings.Split(token, ".")
+	forged := []string{
+		"",
+		"abc",
+		other.NewToken(),
+		parts[0] + "." + parts[1] + "." + other.sign(parts[0]+"."+parts[1]),
+		parts[0] + "." + "9999999999" + "." + parts[2],
+		"x" + token,
+		parts[0] + "." + parts[2],
+	}
+	for _, token := range forged {
+		if err := csrf.validate(token); err == nil {
+			t.Errorf("%q: forged token is accepted", token)
+		}
+	}
+
+	now = now.Add(time.Hour)
+	if err := csrf.validate(token); err == nil {
+		t.Errorf("expired token is accepted")
+	}
+}
+
+func TestHandleLoadFileCSRF(t *testing.T) {
+	db, _ := redismock.NewClientMock()
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
+
+	logger, _ := zap.NewProduction()
+	defer func() {
+		_ = logger.Sync()
+	}()
+	cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+	now := time.Now()
+	csrf := NewCSRFProtector(CSRFConfig{TTL: time.Hour})
+	csrf.now = func() time.Time { return now }
+	processor := NewDBProcessor(client, logger, &singleflight.Group{}, cache, WithCSRFProtector(csrf))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	res := httptest.NewRecorder()
+	processor.HandleMainPage(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("got status %d but wanted %d", res.Code, http.StatusOK)
+	}
+	cookies := res.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != csrfCookieName || !cookies[0].HttpOnly ||
+		cookies[0].SameSite != http.SameSiteStrictMode {
+		t.Fatalf("wrong csrf cookie: %v", cookies)
+	}
+	token := cookies[0].Value
+	if !strings.Contains(res.Body.String(), `value="`+token+`"`) {
+		t.Fatalf("csrf token is not rendered in form")
+	}
+	expired := csrf.NewToken()
+
+	tests := []struct {
+		name   string
+		cookie string
+		field  string
+		header string
+		status int
+	}{
+		{"valid token", token, token, "", http.StatusBadRequest},
+		{"missing cookie", "", token, "", http.StatusForbidden},
+		{"missing field", token, "", "", http.StatusForbidden},
+		{"mismatched token", token, csrf.NewToken(), "", http.StatusForbidden},
+		{"forged token", "forged", "forged", "", http.StatusForbidden},
+		{"expired token", expired, expired, "", http.StatusForbidden},
+		{"api key client", "", "", "key", http.StatusBadRequest},
+	}
+	now = now.Add(time.Hour)
+	token = csrf.NewToken()
+	tests[0].cookie, tests[0].field = token, token
+	for _, tt := range tests {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		if tt.field != "" {
+			_ = writer.WriteField(csrfFormField, tt.field)
+		}
+		_ = writer.Close()
+
+		req := httptest.NewRequest("POST", "/api/load_file", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		if tt.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: tt.cookie})
+		}
+		if tt.header != "" {
+			req.Header.Set(APIKeyHeader, tt.header)
+		}
+		res := httptest.NewRecorder()
+		processor.HandleLoadFile(res, req)
+
+		// requests which pass CSRF check fail later because the form has no file
+		if res.Code != tt.status {
+			t.Errorf("%s: got status %d but wanted %d", tt.name, res.Code, tt.status)
+		}
+	}
+}
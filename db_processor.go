@@ -2,20 +2,21 @@ package main
 
 import (
 	"context"
-	"crypto/md5"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"golang-developer-test-task/infrastructure/redclient"
+	"golang-developer-test-task/infrastructure/storage"
 	"golang-developer-test-task/structs"
 	"html/template"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/go-redis/redis/v8"
 	"github.com/jellydator/ttlcache/v3"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/mailru/easyjson"
@@ -29,11 +30,21 @@ type (
 
 	// DBProcessor needs for dependency injection
 	DBProcessor struct {
-		client        *redclient.RedisClient
+		store         storage.Store
 		logger        *zap.Logger
 		jsonProcessor jsonObjectsProcessorFunc
 		group         *singleflight.Group
 		cache         *ttlcache.Cache[string, structs.PaginationObject]
+		csrf          *CSRFProtector
+		fetcher       *URLFetcher
+		limits        BodyLimits
+		// uploadForm is shown on main page only without authentication, browsers cannot send API key headers
+		uploadForm bool
+		// imports tracks datasets saved in background
+		imports sync.WaitGroup
+		// versionTTL is how long dataset version read from store is used for validators and cache keys
+		versionTTL time.Duration
+		version    cachedVersion
 		// respCache     *ttlcache.Cache[string, string]
 	}
 
@@ -41,16 +52,81 @@ type (
 	Handler func(http.ResponseWriter, *http.Request)
 
 	infoProcessor func(structs.Info)
+
+	// DBProcessorOption sets optional dependency of DBProcessor
+	DBProcessorOption func(*DBProcessor)
+
+	// mainPage is data of static/index.tmpl
+	mainPage struct {
+		// Form shows the upload form protected with CSRF Token
+		Form  bool
+		Token string
+	}
+
+	// cachedVersion is dataset version with the time it was read at
+	cachedVersion struct {
+		sync.Mutex
+		version storage.DatasetVersion
+		err     error
+		readAt  time.Time
+	}
 )
 
+// WithCSRFProtector sets CSRFProtector for the upload form,
+// by default tokens are signed with random secret
+func WithCSRFProtector(csrf *CSRFProtector) DBProcessorOption {
+	return func(d *DBProcessor) {
+		d.csrf = csrf
+	}
+}
+
+// WithUploadForm sets whether main page shows the upload form, it is shown by default,
+// with authentication enabled the page explains how to upload with API key instead
+func WithUploadForm(enabled bool) DBProcessorOption {
+	return func(d *DBProcessor) {
+		d.uploadForm = enabled
+	}
+}
+
+// WithURLFetcher sets URLFetcher for loading dataset from URL,
+// by default only public addresses are fetched
+func WithURLFetcher(fetcher *URLFetcher) DBProcessorOption {
+	return func(d *DBProcessor) {
+		d.fetcher = fetcher
+	}
+}
+
+// WithBodyLimits sets limits of request and upstream bodies
+func WithBodyLimits(limits BodyLimits) DBProcessorOption {
+	return func(d *DBProcessor) {
+		d.limits = limits
+	}
+}
+
+// WithDatasetVersionTTL sets how long dataset version is kept in process instead of reading it
+// on every search, imports of other instances are seen after it, by default it is read every time
+func WithDatasetVersionTTL(ttl time.Duration) DBProcessorOption {
+	return func(d *DBProcessor) {
+		d.versionTTL = ttl
+	}
+}
+
 // NewDBProcessor is a constructor for creating basic version of DBProcessor
-func NewDBProcessor(client *redclient.RedisClient, logger *zap.Logger,
-	group *singleflight.Group, cache *ttlcache.Cache[string, structs.PaginationObject]) *DBProcessor {
+func NewDBProcessor(store storage.Store, logger *zap.Logger,
+	group *singleflight.Group, cache *ttlcache.Cache[string, structs.PaginationObject],
+	opts ...DBProcessorOption) *DBProcessor {
 	d := &DBProcessor{}
-	d.client = client
+	d.store = store
 	d.logger = logger
 	d.group = group
 	d.cache = cache
+	d.csrf = NewCSRFProtector(CSRFConfig{})
+	d.uploadForm = true
+	d.fetcher = NewURLFetcher(FetchConfig{})
+	d.limits = DefaultBodyLimits()
+	for _, opt := range opts {
+		opt(d)
+	}
 	d.jsonProcessor = func(prc infoProcessor) jsonObjectsProcessorFunc {
 		return func(reader io.Reader) error {
 			return d.processJSONs(reader, prc)
@@ -61,15 +137,15 @@ func NewDBProcessor(client *redclient.RedisClient, logger *zap.Logger,
 
 // saveInfo is method for info saving to DB
 func (d *DBProcessor) saveInfo(info structs.Info) {
-	err := d.client.AddValue(context.Background(), info)
-	if err != nil && err != redis.Nil {
+	err := d.store.AddValues(context.Background(), structs.InfoList{info})
+	if err != nil {
 		d.logger.Error("error inside processJSONs in goroutine",
 			zap.Error(err))
 		return
 	}
 }
 
-// processJSONs read jsons from reader and write it to Redis client
+// processJSONs read jsons from reader and write it to storage
 func (d *DBProcessor) processJSONs(reader io.Reader, processor infoProcessor) (err error) {
 	// out, err := io.ReadAll(reader)
 	// if err != nil {
@@ -112,11 +188,17 @@ func (d *DBProcessor) processJSONs(reader io.Reader, processor infoProcessor) (e
 		return err
 	}
 	for _, info := range infoList {
-		go processor(info)
+		d.imports.Add(1)
+		go func(info structs.Info) {
+			defer d.imports.Done()
+			processor(info)
+		}(info)
 	}
 	return nil
 }
 
+// processJSONArray parses dataset from reader and saves it to DB in background,
+// reading errors are returned as is because their kind depends on the source of reader
 func (d *DBProcessor) processJSONArray(reader io.Reader) error {
 	bs, err := io.ReadAll(reader)
 	if err != nil {
@@ -125,18 +207,43 @@ func (d *DBProcessor) processJSONArray(reader io.Reader) error {
 	var infoList structs.InfoList
 	err = easyjson.Unmarshal(bs, &infoList)
 	if err != nil {
-		return err
+		return newAPIError(KindValidation, "dataset is not a valid JSON array of parkings", err)
 	}
+	d.imports.Add(1)
 	go func() {
+		defer d.imports.Done()
 		ctx := context.Background()
-		err := d.client.AddValues(ctx, infoList)
+		err := d.store.AddValues(ctx, infoList)
 		if err != nil {
 			d.logger.Error("error during AddValues in processJSONArray", zap.Error(err))
+			return
+		}
+		version, err := d.store.BumpDatasetVersion(ctx, time.Now())
+		if err != nil {
+			d.logger.Error("error during BumpDatasetVersion in processJSONArray", zap.Error(err))
+			return
 		}
+		d.setDatasetVersion(version)
 	}()
 	return nil
 }
 
+// WaitImports waits for datasets being saved in background until ctx is done,
+// it is called after server stopped handling requests
+func (d *DBProcessor) WaitImports(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		d.imports.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("imports are not finished: %w", ctx.Err())
+	}
+}
+
 // func (d *DBProcessor) streamUnmarshalJSONs(reader io.Reader) (infoList structs.InfoList, err error) {
 //	dec := json.NewDecoder(reader)
 //	_, err = dec.Token()
@@ -166,31 +273,38 @@ func (d *DBProcessor) processJSONArray(reader io.Reader) error {
 // processFileFromURL handle json file from URL
 // func (d *DBProcessor) processFileFromURL(url string, processor jsonObjectsProcessorFunc) error {
 func (d *DBProcessor) processFileFromURL(url string) error {
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	resp, err := d.fetcher.Get(context.Background(), url)
 	if err != nil {
-		d.logger.Error("error during make NewRequest in processFileFromURL", zap.Error(err))
-		return err
+		return fetchError(err)
 	}
-	req.Header.Set("Content-Type", "application/octet-stream")
-	client := http.Client{
-		Timeout: 30 * time.Second,
-	}
-	resp, err := client.Do(req)
-	if err != nil {
-		d.logger.Error("error inside processFileFromURL in singleflight", zap.Error(err))
-		return err
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newAPIError(KindUpstreamFetch,
+			fmt.Sprintf("url responded with status %d", resp.StatusCode), nil)
 	}
-	if resp.ContentLength > 32<<20 {
-		d.logger.Error("too big resp body", zap.Int64("content_length", resp.ContentLength))
-		return errors.New("too big resp body in processFileFromURL")
+	if resp.ContentLength > d.limits.Upstream {
+		return newAPIError(KindPayloadTooLarge, fmt.Sprintf("url content exceeds %d bytes", d.limits.Upstream),
+			fmt.Errorf("content length %d", resp.ContentLength))
 	}
 	if contentType := resp.Header.Get("Content-Type"); contentType != "application/json" && contentType != "application/octet-stream" {
-		d.logger.Error("unsupported Content-Type", zap.String("content_type", contentType))
-		return errors.New("unsupported Content-Type")
+		return newAPIError(KindUpstreamFetch,
+			fmt.Sprintf("url responded with unsupported Content-Type %q", contentType), nil)
 	}
-	err = d.processJSONArray(resp.Body)
+	// chunked responses have no Content-Length, so the limit is checked while reading too
+	err = d.processJSONArray(newMaxBytesReader(resp.Body, d.limits.Upstream))
 	// err = processor(resp.Body)
-	return err
+	var apiErr *APIError
+	var maxErr *http.MaxBytesError
+	switch {
+	case err == nil || errors.As(err, &apiErr):
+		return err
+	case errors.As(err, &maxErr):
+		return newAPIError(KindPayloadTooLarge, fmt.Sprintf("url content exceeds %d bytes", maxErr.Limit), err)
+	default:
+		return newAPIError(KindUpstreamFetch, "cannot read url content", err)
+	}
 }
 
 // processFileFromRequest handle json file from request
@@ -198,42 +312,53 @@ func (d *DBProcessor) processFileFromURL(url string) error {
 func (d *DBProcessor) processFileFromRequest(r *http.Request, fileName string) (err error) {
 	file, _, err := r.FormFile(fileName)
 	if err != nil {
-		d.logger.Error("error inside processFileFromRequest",
-			zap.Error(err))
-		return err
+		return newAPIError(KindBadRequest, fmt.Sprintf("form file %q is missing", fileName), err)
 	}
 	defer func() {
 		_ = file.Close()
 	}()
 	// err = processor(file)
 	err = d.processJSONArray(file)
+	var apiErr *APIError
+	if err != nil && !errors.As(err, &apiErr) {
+		return newAPIError(KindBadRequest, "cannot read form file", err)
+	}
 	return err
 }
 
 // methodMiddleware is a function to return wrapped handler
 func (d *DBProcessor) methodMiddleware(handler Handler, validMethod string) Handler {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != validMethod {
-			w.WriteHeader(http.StatusBadRequest)
-			return
-		}
-		handler(w, r)
-	}
+	return methodsMiddleware(http.HandlerFunc(handler), allowedMethods([]string{validMethod}), d.writeError).ServeHTTP
 }
 
 // HandleLoadFile is handler for /api/load_file
 func (d *DBProcessor) HandleLoadFile(w http.ResponseWriter, r *http.Request) {
 	err := r.ParseMultipartForm(32 << 20)
+	if limitErr := bodyLimitError(r, err); limitErr != nil {
+		d.writeError(w, r, limitErr)
+		return
+	}
+	if errors.Is(err, http.ErrNotMultipart) {
+		d.writeError(w, r, newAPIError(KindUnsupportedMediaType,
+			"request body must be multipart/form-data", err))
+		return
+	}
 	if err != nil {
-		d.logger.Error("error during file parsing in HandleLoadFile", zap.Error(err))
-		w.WriteHeader(http.StatusInternalServerError)
+		d.writeError(w, r, newAPIError(KindBadRequest, "cannot parse multipart form", err))
 		return
 	}
+	// browsers do not send API key headers with cross-site forms, so only requests without them are checked,
+	// they reach here only with authentication disabled, when the upload form is shown
+	if apiKeyFromRequest(r) == "" {
+		if err = d.csrf.Verify(r); err != nil {
+			d.writeError(w, r, err)
+			return
+		}
+	}
 	// err = d.processFileFromRequest(r, "uploadFile", d.jsonProcessor)
 	err = d.processFileFromRequest(r, "uploadFile")
 	if err != nil {
-		d.logger.Error("error during file processing in HandleLoadFile", zap.Error(err))
-		w.WriteHeader(http.StatusInternalServerError)
+		d.writeError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -241,10 +366,21 @@ func (d *DBProcessor) HandleLoadFile(w http.ResponseWriter, r *http.Request) {
 
 // HandleLoadJSON is handler for /api/load_json
 func (d *DBProcessor) HandleLoadJSON(w http.ResponseWriter, r *http.Request) {
+	if err := checkJSONContentType(r); err != nil {
+		d.writeError(w, r, err)
+		return
+	}
 	err := d.processJSONArray(r.Body)
+	var apiErr *APIError
+	if err != nil && !errors.As(err, &apiErr) {
+		if limitErr := bodyLimitError(r, err); limitErr != nil {
+			err = limitErr
+		} else {
+			err = newAPIError(KindBadRequest, "cannot read request body", err)
+		}
+	}
 	if err != nil {
-		d.logger.Error("error during using jsonProcessor in HandleLoadJSON", zap.Error(err))
-		w.WriteHeader(http.StatusInternalServerError)
+		d.writeError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -252,52 +388,132 @@ func (d *DBProcessor) HandleLoadJSON(w http.ResponseWriter, r *http.Request) {
 
 // HandleLoadFromURL is handler for /api/load_from_url
 func (d *DBProcessor) HandleLoadFromURL(w http.ResponseWriter, r *http.Request) {
-	bs, err := io.ReadAll(r.Body)
-	if err != nil {
-		d.logger.Error("during ReadAll in HandleLoadFromURL")
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
 	var urlObj structs.URLObject
-	err = jsoniter.Unmarshal(bs, &urlObj)
-	if err != nil {
-		d.logger.Error("during Unmarshal in HandleLoadFromURL")
-		w.WriteHeader(http.StatusInternalServerError)
+	if err := readJSON(r, &urlObj); err != nil {
+		d.writeError(w, r, err)
 		return
 	}
 	if _, err := url.Parse(urlObj.URL); err != nil {
-		d.logger.Error("during url parsing in HandleLoadFromURL")
-		w.WriteHeader(http.StatusBadRequest)
+		d.writeError(w, r, newAPIError(KindValidation, "url is not valid", err))
 		return
 	}
 	// err = d.processFileFromURL(urlObj.URL, d.jsonProcessor)
-	err = d.processFileFromURL(urlObj.URL)
+	err := d.processFileFromURL(urlObj.URL)
 	if err != nil {
-		d.logger.Error("error during file processing from url", zap.Error(err))
-		w.WriteHeader(http.StatusInternalServerError)
+		d.writeError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
 }
 
-// HandleSearch is handler for /api/search
-func (d *DBProcessor) HandleSearch(w http.ResponseWriter, r *http.Request) {
+// checkJSONContentType rejects request bodies which are declared as non-JSON
+func checkJSONContentType(r *http.Request) error {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "application/json" {
+		return newAPIError(KindUnsupportedMediaType,
+			fmt.Sprintf("request body must be application/json, got %q", contentType), err)
+	}
+	return nil
+}
+
+// readJSON reads request body and unmarshals it to v
+func readJSON(r *http.Request, v interface{}) error {
+	if err := checkJSONContentType(r); err != nil {
+		return err
+	}
 	bs, err := io.ReadAll(r.Body)
+	if limitErr := bodyLimitError(r, err); limitErr != nil {
+		return limitErr
+	}
 	if err != nil {
-		d.logger.Error("during ReadAll", zap.Error(err))
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+		return newAPIError(KindBadRequest, "cannot read request body", err)
+	}
+	err = jsoniter.Unmarshal(bs, v)
+	if err != nil {
+		return newAPIError(KindBadRequest, "request body is not valid JSON", err)
+	}
+	return nil
+}
+
+// datasetVersion returns dataset version read from store at most versionTTL ago
+func (d *DBProcessor) datasetVersion(ctx context.Context) (storage.DatasetVersion, error) {
+	if d.versionTTL <= 0 {
+		return d.store.GetDatasetVersion(ctx)
+	}
+	d.version.Lock()
+	defer d.version.Unlock()
+	if !d.version.readAt.IsZero() && time.Since(d.version.readAt) < d.versionTTL {
+		return d.version.version, d.version.err
+	}
+	version, err := d.store.GetDatasetVersion(ctx)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return version, err
+	}
+	d.version.version, d.version.err, d.version.readAt = version, err, time.Now()
+	return version, err
+}
+
+// setDatasetVersion replaces kept dataset version after import done by this process
+func (d *DBProcessor) setDatasetVersion(version storage.DatasetVersion) {
+	d.version.Lock()
+	defer d.version.Unlock()
+	d.version.version, d.version.err, d.version.readAt = version, nil, time.Now()
+}
+
+// handleConditional sets validators of query response for current dataset version
+// and answers 304 to GET and HEAD requests if their preconditions match them,
+// preconditions of other methods are ignored
+func (d *DBProcessor) handleConditional(ctx context.Context, w http.ResponseWriter, r *http.Request,
+	query string) (version int64, done bool) {
+	datasetVersion, err := d.datasetVersion(ctx)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			d.logger.Warn("during getting dataset version, conditional headers are skipped", zap.Error(err))
+		}
+		return 0, false
 	}
+	etag := searchETag(datasetVersion.Version, query)
+	setValidators(w, etag, datasetVersion.LastModified)
+	safe := r.Method == http.MethodGet || r.Method == http.MethodHead
+	if safe && notModified(r, etag, datasetVersion.LastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return datasetVersion.Version, true
+	}
+	return datasetVersion.Version, false
+}
 
+// HandleSearch is handler for /api/search
+func (d *DBProcessor) HandleSearch(w http.ResponseWriter, r *http.Request) {
 	var searchObj structs.SearchObject
-	err = jsoniter.Unmarshal(bs, &searchObj)
+	if r.Method == http.MethodGet {
+		var err error
+		searchObj, err = parseSearchQuery(r.URL.Query())
+		if err != nil {
+			d.writeError(w, r, newAPIError(KindBadRequest, err.Error(), nil))
+			return
+		}
+	} else if err := readJSON(r, &searchObj); err != nil {
+		d.writeError(w, r, err)
+		return
+	}
+	if err := validatePagination(&searchObj); err != nil {
+		d.writeError(w, r, newAPIError(KindValidation, err.Error(), nil))
+		return
+	}
+	if err := validateFields(&searchObj); err != nil {
+		d.writeError(w, r, newAPIError(KindValidation, err.Error(), nil))
+		return
+	}
+	filter, err := searchFilter(&searchObj)
 	if err != nil {
-		d.logger.Error("during Unmarshal",
-			zap.Error(err),
-			zap.String("searchObj", fmt.Sprintf("%v", searchObj)))
-		w.WriteHeader(http.StatusInternalServerError)
+		d.writeError(w, r, newAPIError(KindValidation, err.Error(), nil))
 		return
 	}
+	filtered := !filter.IsZero()
 
 	searchStr := ""
 	multiple := false
@@ -316,55 +532,246 @@ func (d *DBProcessor) HandleSearch(w http.ResponseWriter, r *http.Request) {
 	case searchObj.ModeEn != nil:
 		searchStr = fmt.Sprintf("mode_en:%s", *searchObj.ModeEn)
 		multiple = true
+	case filtered:
+		// filters alone narrow down all records
+		multiple = true
 	default:
-		d.logger.Error("searchObj'group all necessary fields are nil")
-		w.WriteHeader(http.StatusBadRequest)
+		d.writeError(w, r, newAPIError(KindValidation,
+			"one of system_object_id, global_id, id, id_en, mode, mode_en or a filter is required", nil))
+		return
+	}
+	finder, canFilter := d.store.(storage.FilterFinder)
+	switch {
+	case filtered && !multiple:
+		d.writeError(w, r, newAPIError(KindValidation, "filters are combined only with mode or mode_en", nil))
+		return
+	case filtered && !canFilter:
+		d.writeError(w, r, newAPIError(KindValidation, "filters need redisearch storage backend", nil))
 		return
 	}
 
-	result, err, _ := d.group.Do(searchStr, func() (interface{}, error) {
-		// TODO: add changing cache on insert to Redis(with condition)
-		item := d.cache.Get(searchStr)
+	ctx := context.Background()
+	// pages of one query are different results, so offset and limit are part of cache key
+	query := fmt.Sprintf("%s|%d|%d", searchStr, searchObj.Offset, searchObj.Limit)
+	if len(searchObj.Fields) > 0 {
+		query += "|" + strings.Join(searchObj.Fields, ",")
+	}
+	if filtered {
+		query += "|" + filter.String()
+	}
+	version, done := d.handleConditional(ctx, w, r, query)
+	if done {
+		return
+	}
+	// cached results of previous dataset versions are never read again
+	cacheKey := fmt.Sprintf("%d|%s", version, query)
+
+	result, err, _ := d.group.Do(cacheKey, func() (interface{}, error) {
+		item := d.cache.Get(cacheKey)
 		if item != nil {
 			return item.Value(), nil
 		}
 
-		ctx := context.Background()
 		paginationObj := structs.PaginationObject{}
 		paginationObj.Offset = int64(searchObj.Offset)
-		var paginationSize int64 = 5
-		infoList, totalSize, err := d.client.FindValues(
-			ctx, searchStr, multiple, paginationSize,
-			paginationObj.Offset)
-		if err != nil && err != redis.Nil {
-			d.logger.Error("during search in DB in singleflight", zap.Error(err))
-			return paginationObj, err
+		paginationSize := int64(searchObj.Limit)
+		var infoList structs.InfoList
+		var totalSize int64
+		var err error
+		if filtered {
+			infoList, totalSize, err = finder.FindFiltered(ctx, searchStr, filter, paginationSize,
+				paginationObj.Offset)
+			if errors.Is(err, storage.ErrFilterUnsupported) {
+				return paginationObj, newAPIError(KindValidation, "filters need RediSearch module in storage", err)
+			}
+		} else if finder, ok := d.store.(storage.FieldFinder); ok && len(searchObj.Fields) > 0 {
+			infoList, totalSize, err = finder.FindFields(ctx, searchStr, multiple, paginationSize,
+				paginationObj.Offset, searchObj.Fields)
+		} else {
+			infoList, totalSize, err = d.store.FindValues(
+				ctx, searchStr, multiple, paginationSize,
+				paginationObj.Offset)
+		}
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return paginationObj, newAPIError(KindStorage, "cannot search in storage", err)
 		}
 		paginationObj.Size = totalSize
 		paginationObj.Data = infoList
+		paginationObj.HasPrevious = paginationObj.Offset > 0
+		paginationObj.HasNext = paginationObj.Offset+int64(len(infoList)) < totalSize
 
-		d.cache.Set(searchStr, paginationObj, ttlcache.DefaultTTL)
+		d.cache.Set(cacheKey, paginationObj, ttlcache.DefaultTTL)
 
 		return paginationObj, nil
 	})
 	if err != nil {
-		d.logger.Error("during search in DB", zap.Error(err))
-		w.WriteHeader(http.StatusInternalServerError)
+		d.writeError(w, r, err)
 		return
 	}
 	paginationObj := result.(structs.PaginationObject)
 
-	bs, _ = jsoniter.Marshal(paginationObj)
+	var bs []byte
+	if len(searchObj.Fields) > 0 {
+		bs, _ = jsoniter.Marshal(projectFields(paginationObj, searchObj.Fields))
+	} else {
+		bs, _ = jsoniter.Marshal(paginationObj)
+	}
 	w.Header().Set("Content-Type", "application/json; charset=windows-1251")
 	_, _ = w.Write(bs)
 }
 
+// projectFields reduces records of page to fields
+func projectFields(paginationObj structs.PaginationObject, fields []string) structs.FieldsPaginationObject {
+	projected := structs.FieldsPaginationObject{
+		HasNext:     paginationObj.HasNext,
+		HasPrevious: paginationObj.HasPrevious,
+		Size:        paginationObj.Size,
+		Offset:      paginationObj.Offset,
+		Data:        make([]map[string]interface{}, 0, len(paginationObj.Data)),
+	}
+	for i := range paginationObj.Data {
+		projected.Data = append(projected.Data, paginationObj.Data[i].Project(fields))
+	}
+	return projected
+}
+
+// HandleParking is handler for /api/parkings/{system_object_id} and
+// /api/parkings/by-global-id/{id}, /api/parkings/by-id/{id}, /api/parkings/by-id-en/{id}
+func (d *DBProcessor) HandleParking(w http.ResponseWriter, r *http.Request) {
+	key, err := parkingKey(r.URL.Path)
+	if err != nil {
+		d.writeError(w, r, err)
+		return
+	}
+
+	ctx := context.Background()
+	// record is looked up first, so missing one is 404 whatever validators of request are
+	infoList, _, err := d.store.FindValues(ctx, key, false, 0, 0)
+	if errors.Is(err, storage.ErrNotFound) {
+		d.writeError(w, r, newAPIError(KindNotFound, "parking not found", nil))
+		return
+	}
+	if err != nil {
+		d.writeError(w, r, newAPIError(KindStorage, "cannot search in storage", err))
+		return
+	}
+	if _, done := d.handleConditional(ctx, w, r, key); done {
+		return
+	}
+
+	bs, _ := easyjson.Marshal(infoList[0])
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(bs)
+}
+
+// parkingKey maps path of /api/parkings/ resource onto DB key
+func parkingKey(path string) (string, error) {
+	parts := strings.Split(strings.TrimPrefix(path, "/api/parkings/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] != "" && !storage.IsPointerKey(parts[0]):
+		return parts[0], nil
+	case len(parts) != 2 || parts[1] == "":
+		return "", newAPIError(KindNotFound, fmt.Sprintf("unknown parking resource %q", path), nil)
+	}
+
+	var prefix string
+	switch parts[0] {
+	case "by-global-id":
+		prefix = "global_id"
+	case "by-id":
+		prefix = "id"
+	case "by-id-en":
+		prefix = "id_en"
+	default:
+		return "", newAPIError(KindNotFound, fmt.Sprintf("unknown parking resource %q", path), nil)
+	}
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", newAPIError(KindBadRequest,
+			fmt.Sprintf("%s must be an integer, got %q", parts[0], parts[1]), err)
+	}
+	return fmt.Sprintf("%s:%d", prefix, id), nil
+}
+
+// maxBatchSize limits number of keys inside one batch search query
+const maxBatchSize = 5000
+
+// HandleSearchBatch is handler for /api/search/batch
+func (d *DBProcessor) HandleSearchBatch(w http.ResponseWriter, r *http.Request) {
+	var batchObj structs.BatchSearchObject
+	if err := readJSON(r, &batchObj); err != nil {
+		d.writeError(w, r, err)
+		return
+	}
+
+	keys := make([]string, 0, len(batchObj.SystemObjectIDs)+len(batchObj.GlobalIDs)+
+		len(batchObj.IDs)+len(batchObj.IDEns))
+	keys = append(keys, batchObj.SystemObjectIDs...)
+	for _, globalID := range batchObj.GlobalIDs {
+		keys = append(keys, fmt.Sprintf("global_id:%d", globalID))
+	}
+	for _, id := range batchObj.IDs {
+		keys = append(keys, fmt.Sprintf("id:%d", id))
+	}
+	for _, idEn := range batchObj.IDEns {
+		keys = append(keys, fmt.Sprintf("id_en:%d", idEn))
+	}
+	if len(keys) == 0 || len(keys) > maxBatchSize {
+		d.writeError(w, r, newAPIError(KindValidation,
+			fmt.Sprintf("number of keys must be between 1 and %d, got %d", maxBatchSize, len(keys)), nil))
+		return
+	}
+
+	infos, err := d.store.FindValuesByKeys(context.Background(), keys)
+	if err != nil {
+		d.writeError(w, r, newAPIError(KindStorage, "cannot search in storage", err))
+		return
+	}
+
+	result := structs.BatchResult{Data: make(structs.InfoList, 0, len(infos))}
+	seen := make(map[string]bool, len(infos))
+	// keys are ordered as system_object_id, global_id, id, id_en
+	n1 := len(batchObj.SystemObjectIDs)
+	n2 := n1 + len(batchObj.GlobalIDs)
+	n3 := n2 + len(batchObj.IDs)
+	for i, info := range infos {
+		if info == nil {
+			switch {
+			case i < n1:
+				result.Missing.SystemObjectIDs = append(result.Missing.SystemObjectIDs, batchObj.SystemObjectIDs[i])
+			case i < n2:
+				result.Missing.GlobalIDs = append(result.Missing.GlobalIDs, batchObj.GlobalIDs[i-n1])
+			case i < n3:
+				result.Missing.IDs = append(result.Missing.IDs, batchObj.IDs[i-n2])
+			default:
+				result.Missing.IDEns = append(result.Missing.IDEns, batchObj.IDEns[i-n3])
+			}
+			continue
+		}
+		// the same record can be requested by several keys
+		if seen[info.SystemObjectID] {
+			continue
+		}
+		seen[info.SystemObjectID] = true
+		result.Data = append(result.Data, *info)
+	}
+
+	bs, _ := easyjson.Marshal(result)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(bs)
+}
+
 // HandleMainPage is handler for main page
 func (d *DBProcessor) HandleMainPage(w http.ResponseWriter, r *http.Request) {
-	tmp := time.Now().Unix()
-	h := md5.New()
-	_, _ = io.WriteString(h, strconv.FormatInt(tmp, 10))
-	token := fmt.Sprintf("%x", h.Sum(nil))
-	t, _ := template.ParseFiles("static/index.tmpl")
-	_ = t.Execute(w, token)
+	t, err := template.ParseFiles("static/index.tmpl")
+	if err != nil {
+		d.writeError(w, r, err)
+		return
+	}
+	page := mainPage{}
+	if d.uploadForm {
+		page.Form = true
+		page.Token = d.csrf.SetCookie(w, r)
+	}
+	_ = t.Execute(w, page)
 }
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"golang-developer-test-task/infrastructure/redclient"
+	"golang-developer-test-task/infrastructure/storage/memstore"
 	"golang-developer-test-task/structs"
 	"io"
 	"mime/multipart"
@@ -14,9 +15,11 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/jellydator/ttlcache/v3"
 	"golang.org/x/sync/singleflight"
 
@@ -33,7 +36,7 @@ func (errReader) Read(p []byte) (n int, err error) {
 
 func TestProcessJSONsReadAllErr(t *testing.T) {
 	db, _ := redismock.NewClientMock()
-	client := &redclient.RedisClient{*db, 10}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -56,7 +59,7 @@ func TestProcessJSONsReadAllErr(t *testing.T) {
 
 func TestHandleMainPage(t *testing.T) {
 	db, _ := redismock.NewClientMock()
-	client := &redclient.RedisClient{*db, 10}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -83,9 +86,35 @@ func TestHandleMainPage(t *testing.T) {
 	}
 }
 
+func TestHandleMainPageUploadForm(t *testing.T) {
+	db, _ := redismock.NewClientMock()
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
+	cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+
+	for _, form := range []bool{true, false} {
+		processor := NewDBProcessor(client, zap.NewNop(), &singleflight.Group{}, cache, WithUploadForm(form))
+		req := httptest.NewRequest("GET", "/", nil)
+		res := httptest.NewRecorder()
+		processor.HandleMainPage(res, req)
+
+		if res.Code != http.StatusOK {
+			t.Errorf("form %v: got status %d but wanted %d", form, res.Code, http.StatusOK)
+		}
+		if got := strings.Contains(res.Body.String(), "<form"); got != form {
+			t.Errorf("form %v: got form rendered %v", form, got)
+		}
+		if got := len(res.Result().Cookies()) == 1; got != form {
+			t.Errorf("form %v: got csrf cookie set %v", form, got)
+		}
+		if !strings.Contains(res.Body.String(), "/api/load_file") {
+			t.Errorf("form %v: upload route is not mentioned", form)
+		}
+	}
+}
+
 func TestHandleMainPageBadRequest(t *testing.T) {
 	db, _ := redismock.NewClientMock()
-	client := &redclient.RedisClient{*db, 10}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -107,14 +136,14 @@ func TestHandleMainPageBadRequest(t *testing.T) {
 	h := processor.methodMiddleware(processor.HandleMainPage, "GET")
 	h(res, req)
 
-	if res.Code != http.StatusBadRequest {
-		t.Errorf("got status %d but wanted %d", res.Code, http.StatusBadRequest)
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusMethodNotAllowed)
 	}
 }
 
 func TestSearchURLErrReader(t *testing.T) {
 	db, _ := redismock.NewClientMock()
-	client := &redclient.RedisClient{*db, 10}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -136,14 +165,14 @@ func TestSearchURLErrReader(t *testing.T) {
 	h := processor.methodMiddleware(processor.HandleSearch, "POST")
 	h(res, req)
 
-	if res.Code != http.StatusInternalServerError {
-		t.Errorf("got status %d but wanted %d", res.Code, http.StatusInternalServerError)
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusBadRequest)
 	}
 }
 
 func TestHandleSearchBadRequest(t *testing.T) {
 	db, _ := redismock.NewClientMock()
-	client := &redclient.RedisClient{*db, 10}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -164,9 +193,39 @@ func TestHandleSearchBadRequest(t *testing.T) {
 	h := processor.methodMiddleware(processor.HandleSearch, "POST")
 	h(res, req)
 
-	if res.Code != http.StatusBadRequest {
-		t.Errorf("got status %d but wanted %d", res.Code, http.StatusBadRequest)
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+// recordHash returns record hash as HGETALL does
+func recordHash(info structs.Info) map[string]string {
+	hash := make(map[string]string)
+	values := info.FieldValues()
+	for i, name := range structs.InfoFieldNames() {
+		hash[name] = values[i]
+	}
+	return hash
+}
+
+// expectFindRecord expects script of redis storage reading all fields of record by key,
+// reply is record hash prefixed with its type
+func expectFindRecord(mock redismock.ClientMock, key string, pointer bool) *redismock.ExpectedCmd {
+	flag := "0"
+	if pointer {
+		flag = "1"
 	}
+	return mock.Regexp().ExpectEvalSha("^[0-9a-f]{40}$", []string{"^" + key + "$"}, "^"+flag+"$", "^$")
+}
+
+// recordReply returns reply of script reading all fields of record
+func recordReply(info structs.Info) []interface{} {
+	reply := []interface{}{"hash"}
+	values := info.FieldValues()
+	for i, name := range structs.InfoFieldNames() {
+		reply = append(reply, name, values[i])
+	}
+	return reply
 }
 
 func TestHandleSearchMode(t *testing.T) {
@@ -179,36 +238,15 @@ func TestHandleSearchMode(t *testing.T) {
 		ModeEn:         "cba",
 	}
 
-	globalID := fmt.Sprintf("global_id:%d", info.GlobalID)
-	id := fmt.Sprintf("id:%d", info.ID)
-	idEn := fmt.Sprintf("id_en:%d", info.IDEn)
 	mode := fmt.Sprintf("mode:%s", info.Mode)
-	modeEn := fmt.Sprintf("mode_en:%s", info.ModeEn)
-
-	bs, _ := easyjson.Marshal(info)
 
 	db, mock := redismock.NewClientMock()
-	mock.ExpectWatch(info.SystemObjectID, globalID, id, idEn, mode, modeEn)
-	mock.ExpectGet(info.SystemObjectID).SetVal("")
-	mock.ExpectTxPipeline()
-	mock.ExpectSet(info.SystemObjectID, bs, 0).SetVal("OK")
-	mock.ExpectSet(globalID, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(id, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(idEn, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectRPush(mode, info.SystemObjectID).SetVal(0)
-	mock.ExpectRPush(modeEn, info.SystemObjectID).SetVal(0)
-	mock.ExpectTxPipelineExec()
-
 	var paginationSize int64 = 5
 	mock.ExpectLLen(mode).SetVal(1)
-	mock.ExpectLRange(mode, 0, paginationSize).SetVal([]string{info.SystemObjectID})
-	mock.ExpectGet(info.SystemObjectID).SetVal(string(bs))
+	mock.ExpectLRange(mode, 0, paginationSize-1).SetVal([]string{info.SystemObjectID})
+	mock.ExpectHGetAll(info.SystemObjectID).SetVal(recordHash(info))
 
-	client := &redclient.RedisClient{*db, 10}
-	err := client.AddValue(context.Background(), info)
-	if err != nil {
-		t.Fatal(err)
-	}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -239,7 +277,7 @@ func TestHandleSearchMode(t *testing.T) {
 	}
 
 	var result structs.PaginationObject
-	err = easyjson.Unmarshal(res.Body.Bytes(), &result)
+	err := easyjson.Unmarshal(res.Body.Bytes(), &result)
 	fmt.Println(err)
 	fmt.Println(result)
 
@@ -271,36 +309,15 @@ func TestHandleSearchModeEn(t *testing.T) {
 		ModeEn:         "cba",
 	}
 
-	globalID := fmt.Sprintf("global_id:%d", info.GlobalID)
-	id := fmt.Sprintf("id:%d", info.ID)
-	idEn := fmt.Sprintf("id_en:%d", info.IDEn)
-	mode := fmt.Sprintf("mode:%s", info.Mode)
 	modeEn := fmt.Sprintf("mode_en:%s", info.ModeEn)
 
-	bs, _ := easyjson.Marshal(info)
-
 	db, mock := redismock.NewClientMock()
-	mock.ExpectWatch(info.SystemObjectID, globalID, id, idEn, mode, modeEn)
-	mock.ExpectGet(info.SystemObjectID).SetVal("")
-	mock.ExpectTxPipeline()
-	mock.ExpectSet(info.SystemObjectID, bs, 0).SetVal("OK")
-	mock.ExpectSet(globalID, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(id, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(idEn, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectRPush(mode, info.SystemObjectID).SetVal(0)
-	mock.ExpectRPush(modeEn, info.SystemObjectID).SetVal(0)
-	mock.ExpectTxPipelineExec()
-
 	var paginationSize int64 = 5
 	mock.ExpectLLen(modeEn).SetVal(1)
-	mock.ExpectLRange(modeEn, 0, paginationSize).SetVal([]string{info.SystemObjectID})
-	mock.ExpectGet(info.SystemObjectID).SetVal(string(bs))
+	mock.ExpectLRange(modeEn, 0, paginationSize-1).SetVal([]string{info.SystemObjectID})
+	mock.ExpectHGetAll(info.SystemObjectID).SetVal(recordHash(info))
 
-	client := &redclient.RedisClient{*db, 10}
-	err := client.AddValue(context.Background(), info)
-	if err != nil {
-		t.Fatal(err)
-	}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -330,7 +347,7 @@ func TestHandleSearchModeEn(t *testing.T) {
 	}
 
 	var result structs.PaginationObject
-	err = easyjson.Unmarshal(res.Body.Bytes(), &result)
+	err := easyjson.Unmarshal(res.Body.Bytes(), &result)
 	fmt.Println(err)
 	fmt.Println(result)
 
@@ -362,34 +379,12 @@ func TestHandleSearchID(t *testing.T) {
 		ModeEn:         "cba",
 	}
 
-	globalID := fmt.Sprintf("global_id:%d", info.GlobalID)
 	id := fmt.Sprintf("id:%d", info.ID)
-	idEn := fmt.Sprintf("id_en:%d", info.IDEn)
-	mode := fmt.Sprintf("mode:%s", info.Mode)
-	modeEn := fmt.Sprintf("mode_en:%s", info.ModeEn)
-
-	bs, _ := easyjson.Marshal(info)
 
 	db, mock := redismock.NewClientMock()
-	mock.ExpectWatch(info.SystemObjectID, globalID, id, idEn, mode, modeEn)
-	mock.ExpectGet(info.SystemObjectID).SetVal("")
-	mock.ExpectTxPipeline()
-	mock.ExpectSet(info.SystemObjectID, bs, 0).SetVal("OK")
-	mock.ExpectSet(globalID, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(id, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(idEn, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectRPush(mode, info.SystemObjectID).SetVal(0)
-	mock.ExpectRPush(modeEn, info.SystemObjectID).SetVal(0)
-	mock.ExpectTxPipelineExec()
-
-	mock.ExpectGet(id).SetVal(info.SystemObjectID)
-	mock.ExpectGet(info.SystemObjectID).SetVal(string(bs))
-
-	client := &redclient.RedisClient{*db, 10}
-	err := client.AddValue(context.Background(), info)
-	if err != nil {
-		t.Fatal(err)
-	}
+	expectFindRecord(mock, id, true).SetVal(recordReply(info))
+
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -419,7 +414,7 @@ func TestHandleSearchID(t *testing.T) {
 	}
 
 	var result structs.PaginationObject
-	err = easyjson.Unmarshal(res.Body.Bytes(), &result)
+	err := easyjson.Unmarshal(res.Body.Bytes(), &result)
 	fmt.Println(err)
 	fmt.Println(result)
 
@@ -451,34 +446,12 @@ func TestHandleSearchIDEn(t *testing.T) {
 		ModeEn:         "cba",
 	}
 
-	globalID := fmt.Sprintf("global_id:%d", info.GlobalID)
-	id := fmt.Sprintf("id:%d", info.ID)
 	idEn := fmt.Sprintf("id_en:%d", info.IDEn)
-	mode := fmt.Sprintf("mode:%s", info.Mode)
-	modeEn := fmt.Sprintf("mode_en:%s", info.ModeEn)
-
-	bs, _ := easyjson.Marshal(info)
 
 	db, mock := redismock.NewClientMock()
-	mock.ExpectWatch(info.SystemObjectID, globalID, id, idEn, mode, modeEn)
-	mock.ExpectGet(info.SystemObjectID).SetVal("")
-	mock.ExpectTxPipeline()
-	mock.ExpectSet(info.SystemObjectID, bs, 0).SetVal("OK")
-	mock.ExpectSet(globalID, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(id, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(idEn, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectRPush(mode, info.SystemObjectID).SetVal(0)
-	mock.ExpectRPush(modeEn, info.SystemObjectID).SetVal(0)
-	mock.ExpectTxPipelineExec()
-
-	mock.ExpectGet(idEn).SetVal(info.SystemObjectID)
-	mock.ExpectGet(info.SystemObjectID).SetVal(string(bs))
-
-	client := &redclient.RedisClient{*db, 10}
-	err := client.AddValue(context.Background(), info)
-	if err != nil {
-		t.Fatal(err)
-	}
+	expectFindRecord(mock, idEn, true).SetVal(recordReply(info))
+
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -508,7 +481,7 @@ func TestHandleSearchIDEn(t *testing.T) {
 	}
 
 	var result structs.PaginationObject
-	err = easyjson.Unmarshal(res.Body.Bytes(), &result)
+	err := easyjson.Unmarshal(res.Body.Bytes(), &result)
 	fmt.Println(err)
 	fmt.Println(result)
 
@@ -540,33 +513,10 @@ func TestHandleSearchSystemObjectID(t *testing.T) {
 		ModeEn:         "cba",
 	}
 
-	globalID := fmt.Sprintf("global_id:%d", info.GlobalID)
-	id := fmt.Sprintf("id:%d", info.ID)
-	idEn := fmt.Sprintf("id_en:%d", info.IDEn)
-	mode := fmt.Sprintf("mode:%s", info.Mode)
-	modeEn := fmt.Sprintf("mode_en:%s", info.ModeEn)
-
-	bs, _ := easyjson.Marshal(info)
-
 	db, mock := redismock.NewClientMock()
-	mock.ExpectWatch(info.SystemObjectID, globalID, id, idEn, mode, modeEn)
-	mock.ExpectGet(info.SystemObjectID).SetVal("")
-	mock.ExpectTxPipeline()
-	mock.ExpectSet(info.SystemObjectID, bs, 0).SetVal("OK")
-	mock.ExpectSet(globalID, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(id, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(idEn, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectRPush(mode, info.SystemObjectID).SetVal(0)
-	mock.ExpectRPush(modeEn, info.SystemObjectID).SetVal(0)
-	mock.ExpectTxPipelineExec()
-
-	mock.ExpectGet(info.SystemObjectID).SetVal(string(bs))
-
-	client := &redclient.RedisClient{*db, 10}
-	err := client.AddValue(context.Background(), info)
-	if err != nil {
-		t.Fatal(err)
-	}
+	expectFindRecord(mock, info.SystemObjectID, false).SetVal(recordReply(info))
+
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -596,7 +546,7 @@ func TestHandleSearchSystemObjectID(t *testing.T) {
 	}
 
 	var result structs.PaginationObject
-	err = easyjson.Unmarshal(res.Body.Bytes(), &result)
+	err := easyjson.Unmarshal(res.Body.Bytes(), &result)
 	fmt.Println(err)
 	fmt.Println(result)
 
@@ -629,33 +579,11 @@ func TestHandleSearchGlobalID(t *testing.T) {
 	}
 
 	globalID := fmt.Sprintf("global_id:%d", info.GlobalID)
-	id := fmt.Sprintf("id:%d", info.ID)
-	idEn := fmt.Sprintf("id_en:%d", info.IDEn)
-	mode := fmt.Sprintf("mode:%s", info.Mode)
-	modeEn := fmt.Sprintf("mode_en:%s", info.ModeEn)
-
-	bs, _ := easyjson.Marshal(info)
 
 	db, mock := redismock.NewClientMock()
-	mock.ExpectWatch(info.SystemObjectID, globalID, id, idEn, mode, modeEn)
-	mock.ExpectGet(info.SystemObjectID).SetVal("")
-	mock.ExpectTxPipeline()
-	mock.ExpectSet(info.SystemObjectID, bs, 0).SetVal("OK")
-	mock.ExpectSet(globalID, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(id, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(idEn, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectRPush(mode, info.SystemObjectID).SetVal(0)
-	mock.ExpectRPush(modeEn, info.SystemObjectID).SetVal(0)
-	mock.ExpectTxPipelineExec()
-
-	mock.ExpectGet(globalID).SetVal(info.SystemObjectID)
-	mock.ExpectGet(info.SystemObjectID).SetVal(string(bs))
-
-	client := &redclient.RedisClient{*db, 10}
-	err := client.AddValue(context.Background(), info)
-	if err != nil {
-		t.Fatal(err)
-	}
+	expectFindRecord(mock, globalID, true).SetVal(recordReply(info))
+
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -685,7 +613,7 @@ func TestHandleSearchGlobalID(t *testing.T) {
 	}
 
 	var result structs.PaginationObject
-	err = easyjson.Unmarshal(res.Body.Bytes(), &result)
+	err := easyjson.Unmarshal(res.Body.Bytes(), &result)
 	fmt.Println(err)
 	fmt.Println(result)
 
@@ -718,7 +646,7 @@ func TestHandleSearchErrDuringSearch(t *testing.T) {
 	}
 
 	db, _ := redismock.NewClientMock()
-	client := &redclient.RedisClient{*db, 10}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -744,14 +672,14 @@ func TestHandleSearchErrDuringSearch(t *testing.T) {
 	h := processor.methodMiddleware(processor.HandleSearch, "POST")
 	h(res, req)
 
-	if res.Code != http.StatusInternalServerError {
-		t.Errorf("got status %d but wanted %d", res.Code, http.StatusInternalServerError)
+	if res.Code != http.StatusServiceUnavailable {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusServiceUnavailable)
 	}
 }
 
 func TestHandleLoadFromURLErrReader(t *testing.T) {
 	db, _ := redismock.NewClientMock()
-	client := &redclient.RedisClient{*db, 10}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -772,14 +700,14 @@ func TestHandleLoadFromURLErrReader(t *testing.T) {
 	h := processor.methodMiddleware(processor.HandleLoadFromURL, "POST")
 	h(res, req)
 
-	if res.Code != http.StatusInternalServerError {
-		t.Errorf("got status %d but wanted %d", res.Code, http.StatusInternalServerError)
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusBadRequest)
 	}
 }
 
 func TestHandleLoadFromURLBadRequest(t *testing.T) {
 	db, _ := redismock.NewClientMock()
-	client := &redclient.RedisClient{*db, 10}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -800,8 +728,8 @@ func TestHandleLoadFromURLBadRequest(t *testing.T) {
 	h := processor.methodMiddleware(processor.HandleLoadFromURL, "POST")
 	h(res, req)
 
-	if res.Code != http.StatusBadRequest {
-		t.Errorf("got status %d but wanted %d", res.Code, http.StatusBadRequest)
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusMethodNotAllowed)
 	}
 }
 
@@ -812,7 +740,7 @@ func TestHandleLoadFromURLResourceWithoutFile(t *testing.T) {
 	defer server.Close()
 
 	db, _ := redismock.NewClientMock()
-	client := &redclient.RedisClient{*db, 10}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -826,7 +754,9 @@ func TestHandleLoadFromURLResourceWithoutFile(t *testing.T) {
 		ttlcache.WithTTL[string, structs.PaginationObject](timeout))
 	go cache.Start()
 
-	processor := NewDBProcessor(client, logger, s, cache)
+	// test server listens on loopback address
+	fetcher := NewURLFetcher(FetchConfig{AllowPrivate: true})
+	processor := NewDBProcessor(client, logger, s, cache, WithURLFetcher(fetcher))
 
 	urlObject := structs.URLObject{URL: server.URL}
 	bs, err := easyjson.Marshal(urlObject)
@@ -839,8 +769,8 @@ func TestHandleLoadFromURLResourceWithoutFile(t *testing.T) {
 	h := processor.methodMiddleware(processor.HandleLoadFromURL, "POST")
 	h(res, req)
 
-	if res.Code != http.StatusInternalServerError {
-		t.Errorf("got status %d but wanted %d", res.Code, http.StatusInternalServerError)
+	if res.Code != http.StatusBadGateway {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusBadGateway)
 	}
 }
 
@@ -854,7 +784,7 @@ func TestHandleLoadFromURLResourceWithoutFileButRightContentType(t *testing.T) {
 	defer server.Close()
 
 	db, _ := redismock.NewClientMock()
-	client := &redclient.RedisClient{*db, 10}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -868,7 +798,9 @@ func TestHandleLoadFromURLResourceWithoutFileButRightContentType(t *testing.T) {
 		ttlcache.WithTTL[string, structs.PaginationObject](timeout))
 	go cache.Start()
 
-	processor := NewDBProcessor(client, logger, s, cache)
+	// test server listens on loopback address
+	fetcher := NewURLFetcher(FetchConfig{AllowPrivate: true})
+	processor := NewDBProcessor(client, logger, s, cache, WithURLFetcher(fetcher))
 
 	urlObject := structs.URLObject{URL: server.URL}
 	bs, err := easyjson.Marshal(urlObject)
@@ -881,8 +813,8 @@ func TestHandleLoadFromURLResourceWithoutFileButRightContentType(t *testing.T) {
 	h := processor.methodMiddleware(processor.HandleLoadFromURL, "POST")
 	h(res, req)
 
-	if res.Code != http.StatusInternalServerError {
-		t.Errorf("got status %d but wanted %d", res.Code, http.StatusInternalServerError)
+	if res.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusRequestEntityTooLarge)
 	}
 }
 
@@ -896,7 +828,7 @@ func TestHandleLoadFromURLResourceBadFile(t *testing.T) {
 	defer server.Close()
 
 	db, _ := redismock.NewClientMock()
-	client := &redclient.RedisClient{*db, 10}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -910,7 +842,9 @@ func TestHandleLoadFromURLResourceBadFile(t *testing.T) {
 		ttlcache.WithTTL[string, structs.PaginationObject](timeout))
 	go cache.Start()
 
-	processor := NewDBProcessor(client, logger, s, cache)
+	// test server listens on loopback address
+	fetcher := NewURLFetcher(FetchConfig{AllowPrivate: true})
+	processor := NewDBProcessor(client, logger, s, cache, WithURLFetcher(fetcher))
 
 	urlObject := structs.URLObject{URL: server.URL}
 	bs, err := easyjson.Marshal(urlObject)
@@ -923,14 +857,14 @@ func TestHandleLoadFromURLResourceBadFile(t *testing.T) {
 	h := processor.methodMiddleware(processor.HandleLoadFromURL, "POST")
 	h(res, req)
 
-	if res.Code != http.StatusInternalServerError {
-		t.Errorf("got status %d but wanted %d", res.Code, http.StatusInternalServerError)
+	if res.Code != http.StatusUnprocessableEntity {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusUnprocessableEntity)
 	}
 }
 
 func TestHandleLoadFromURLWrongResource(t *testing.T) {
 	db, _ := redismock.NewClientMock()
-	client := &redclient.RedisClient{*db, 10}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -957,14 +891,14 @@ func TestHandleLoadFromURLWrongResource(t *testing.T) {
 	h := processor.methodMiddleware(processor.HandleLoadFromURL, "POST")
 	h(res, req)
 
-	if res.Code != http.StatusInternalServerError {
-		t.Errorf("got status %d but wanted %d", res.Code, http.StatusInternalServerError)
+	if res.Code != http.StatusBadGateway {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusBadGateway)
 	}
 }
 
 func TestHandleLoadFromURLWrongURLResource(t *testing.T) {
 	db, _ := redismock.NewClientMock()
-	client := &redclient.RedisClient{*db, 10}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -991,14 +925,14 @@ func TestHandleLoadFromURLWrongURLResource(t *testing.T) {
 	h := processor.methodMiddleware(processor.HandleLoadFromURL, "POST")
 	h(res, req)
 
-	if res.Code != http.StatusBadRequest {
-		t.Errorf("got status %d but wanted %d", res.Code, http.StatusBadRequest)
+	if res.Code != http.StatusUnprocessableEntity {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusUnprocessableEntity)
 	}
 }
 
 func TestHandleLoadFromURLNilBody(t *testing.T) {
 	db, _ := redismock.NewClientMock()
-	client := &redclient.RedisClient{*db, 10}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -1019,14 +953,14 @@ func TestHandleLoadFromURLNilBody(t *testing.T) {
 	h := processor.methodMiddleware(processor.HandleLoadFromURL, "POST")
 	h(res, req)
 
-	if res.Code != http.StatusInternalServerError {
-		t.Errorf("got status %d but wanted %d", res.Code, http.StatusInternalServerError)
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusBadRequest)
 	}
 }
 
 func TestHandleLoadFileBadRequest(t *testing.T) {
 	db, _ := redismock.NewClientMock()
-	client := &redclient.RedisClient{*db, 10}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -1047,14 +981,14 @@ func TestHandleLoadFileBadRequest(t *testing.T) {
 	h := processor.methodMiddleware(processor.HandleLoadFile, "POST")
 	h(res, req)
 
-	if res.Code != http.StatusBadRequest {
-		t.Errorf("got status %d but wanted %d", res.Code, http.StatusBadRequest)
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusMethodNotAllowed)
 	}
 }
 
 func TestHandleLoadFromURLWrongMethod(t *testing.T) {
 	db, _ := redismock.NewClientMock()
-	client := &redclient.RedisClient{*db, 10}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -1074,14 +1008,14 @@ func TestHandleLoadFromURLWrongMethod(t *testing.T) {
 	h := processor.methodMiddleware(processor.HandleLoadFromURL, "POST")
 	h(res, req)
 
-	if res.Code != http.StatusInternalServerError {
-		t.Errorf("got status %d but wanted %d", res.Code, http.StatusInternalServerError)
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusBadRequest)
 	}
 }
 
 func TestHandleLoadFileWrongMethod(t *testing.T) {
 	db, _ := redismock.NewClientMock()
-	client := &redclient.RedisClient{*db, 10}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -1101,15 +1035,15 @@ func TestHandleLoadFileWrongMethod(t *testing.T) {
 	h := processor.methodMiddleware(processor.HandleLoadFile, "POST")
 	h(res, req)
 
-	if res.Code != http.StatusInternalServerError {
-		t.Errorf("got status %d but wanted %d", res.Code, http.StatusInternalServerError)
+	if res.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusUnsupportedMediaType)
 	}
 }
 
 func TestHandleLoadFile(t *testing.T) {
 	db, _ := redismock.NewClientMock()
 	// TODO: add data to mock before it
-	client := &redclient.RedisClient{*db, 10}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -1144,6 +1078,7 @@ func TestHandleLoadFile(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	cookie := writeCSRFToken(processor, writer)
 	err = writer.Close()
 	if err != nil {
 		t.Fatal(err)
@@ -1151,6 +1086,7 @@ func TestHandleLoadFile(t *testing.T) {
 
 	req := httptest.NewRequest("POST", "/api/load_file", body)
 	req.Header.Add("Content-Type", writer.FormDataContentType())
+	req.AddCookie(cookie)
 	res := httptest.NewRecorder()
 	h := processor.methodMiddleware(processor.HandleLoadFile, "POST")
 	h(res, req)
@@ -1163,7 +1099,7 @@ func TestHandleLoadFile(t *testing.T) {
 func TestHandleLoadFileWithParenthesisProblem(t *testing.T) {
 	db, _ := redismock.NewClientMock()
 	// TODO: add data to mock before it
-	client := &redclient.RedisClient{*db, 10}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -1198,6 +1134,7 @@ func TestHandleLoadFileWithParenthesisProblem(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	cookie := writeCSRFToken(processor, writer)
 	err = writer.Close()
 	if err != nil {
 		t.Fatal(err)
@@ -1205,19 +1142,20 @@ func TestHandleLoadFileWithParenthesisProblem(t *testing.T) {
 
 	req := httptest.NewRequest("POST", "/api/load_file", body)
 	req.Header.Add("Content-Type", writer.FormDataContentType())
+	req.AddCookie(cookie)
 	res := httptest.NewRecorder()
 	h := processor.methodMiddleware(processor.HandleLoadFile, "POST")
 	h(res, req)
 
-	if res.Code != http.StatusInternalServerError {
-		t.Errorf("got status %d but wanted %d", res.Code, http.StatusInternalServerError)
+	if res.Code != http.StatusUnprocessableEntity {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusUnprocessableEntity)
 	}
 }
 
 func TestHandleSearchWithoutNilSearchObject(t *testing.T) {
 	db, _ := redismock.NewClientMock()
 	// TODO: add data to mock before it
-	client := &redclient.RedisClient{*db, 10}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -1239,15 +1177,15 @@ func TestHandleSearchWithoutNilSearchObject(t *testing.T) {
 	h := processor.methodMiddleware(processor.HandleSearch, "POST")
 	h(res, req)
 
-	if res.Code != http.StatusInternalServerError {
-		t.Errorf("got status %d but wanted %d", res.Code, http.StatusInternalServerError)
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusBadRequest)
 	}
 }
 
 func TestHandleSearchWithoutNecessaryParamsInsideSearchObject(t *testing.T) {
 	db, _ := redismock.NewClientMock()
 	// TODO: add data to mock before it
-	client := &redclient.RedisClient{*db, 10}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -1272,15 +1210,15 @@ func TestHandleSearchWithoutNecessaryParamsInsideSearchObject(t *testing.T) {
 	h := processor.methodMiddleware(processor.HandleSearch, "POST")
 	h(res, req)
 
-	if res.Code != http.StatusBadRequest {
-		t.Errorf("got status %d but wanted %d", res.Code, http.StatusBadRequest)
+	if res.Code != http.StatusUnprocessableEntity {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusUnprocessableEntity)
 	}
 }
 
 func TestHandleLoadFileWrongFileName(t *testing.T) {
 	db, _ := redismock.NewClientMock()
 	// TODO: add data to mock before it
-	client := &redclient.RedisClient{*db, 10}
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	logger, _ := zap.NewProduction()
 	defer func() {
@@ -1315,6 +1253,7 @@ func TestHandleLoadFileWrongFileName(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	cookie := writeCSRFToken(processor, writer)
 	err = writer.Close()
 	if err != nil {
 		t.Fatal(err)
@@ -1322,11 +1261,279 @@ func TestHandleLoadFileWrongFileName(t *testing.T) {
 
 	req := httptest.NewRequest("POST", "/api/load_file", body)
 	req.Header.Add("Content-Type", writer.FormDataContentType())
+	req.AddCookie(cookie)
 	res := httptest.NewRecorder()
 	h := processor.methodMiddleware(processor.HandleLoadFile, "POST")
 	h(res, req)
 
-	if res.Code != http.StatusInternalServerError {
-		t.Errorf("got status %d but wanted %d", res.Code, http.StatusInternalServerError)
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleSearchBatch(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redclient.NewRedisClient(context.Background(), redclient.RedisConfig{Addr: mr.Addr()})
+	infos := structs.InfoList{
+		{GlobalID: 42, SystemObjectID: "777", ID: 1, IDEn: 9, Mode: "abc", ModeEn: "cba"},
+		{GlobalID: 43, SystemObjectID: "778", ID: 2, IDEn: 10, Mode: "abc", ModeEn: "cba"},
+	}
+	if err := client.AddValues(context.Background(), infos); err != nil {
+		t.Fatal(err)
+	}
+
+	logger, _ := zap.NewProduction()
+	defer func() {
+		_ = logger.Sync()
+	}()
+	s := &singleflight.Group{}
+	cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+	processor := NewDBProcessor(client, logger, s, cache)
+
+	batchObject := structs.BatchSearchObject{
+		GlobalIDs:       []int{42, 1},
+		SystemObjectIDs: []string{"778", "0"},
+		IDs:             []int{1, 2},
+		IDEns:           []int{3},
+	}
+	bs, _ := easyjson.Marshal(batchObject)
+
+	req := httptest.NewRequest("POST", "/api/search/batch", bytes.NewBuffer(bs))
+	res := httptest.NewRecorder()
+	h := processor.methodMiddleware(processor.HandleSearchBatch, "POST")
+	h(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("got status %d but wanted %d", res.Code, http.StatusOK)
+	}
+	var result structs.BatchResult
+	if err := easyjson.Unmarshal(res.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Data) != 2 || result.Data[0] != infos[1] || result.Data[1] != infos[0] {
+		t.Errorf("got data %v but wanted %v", result.Data, structs.InfoList{infos[1], infos[0]})
+	}
+	missing := fmt.Sprintf("%v", result.Missing)
+	if expected := fmt.Sprintf("%v", structs.BatchSearchObject{
+		GlobalIDs:       []int{1},
+		SystemObjectIDs: []string{"0"},
+		IDEns:           []int{3},
+	}); missing != expected {
+		t.Errorf("got missing %s but wanted %s", missing, expected)
+	}
+}
+
+func TestHandleSearchBatchBadRequest(t *testing.T) {
+	db, _ := redismock.NewClientMock()
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
+
+	logger, _ := zap.NewProduction()
+	defer func() {
+		_ = logger.Sync()
+	}()
+	s := &singleflight.Group{}
+	cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+	processor := NewDBProcessor(client, logger, s, cache)
+
+	tooMany := structs.BatchSearchObject{IDs: make([]int, maxBatchSize+1)}
+	tooManyBs, _ := easyjson.Marshal(tooMany)
+	tests := map[string]struct {
+		body   io.Reader
+		status int
+	}{
+		"bad json": {bytes.NewBufferString("{"), http.StatusBadRequest},
+		"empty":    {bytes.NewBufferString("{}"), http.StatusUnprocessableEntity},
+		"too many": {bytes.NewBuffer(tooManyBs), http.StatusUnprocessableEntity},
+	}
+	for name, tt := range tests {
+		req := httptest.NewRequest("POST", "/api/search/batch", tt.body)
+		res := httptest.NewRecorder()
+		h := processor.methodMiddleware(processor.HandleSearchBatch, "POST")
+		h(res, req)
+
+		if res.Code != tt.status {
+			t.Errorf("%s: got status %d but wanted %d", name, res.Code, tt.status)
+		}
+	}
+}
+
+func TestHandleSearchBatchErrDuringSearch(t *testing.T) {
+	db, _ := redismock.NewClientMock()
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
+
+	logger, _ := zap.NewProduction()
+	defer func() {
+		_ = logger.Sync()
+	}()
+	s := &singleflight.Group{}
+	cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+	processor := NewDBProcessor(client, logger, s, cache)
+
+	bs, _ := easyjson.Marshal(structs.BatchSearchObject{GlobalIDs: []int{42}})
+	req := httptest.NewRequest("POST", "/api/search/batch", bytes.NewBuffer(bs))
+	res := httptest.NewRecorder()
+	h := processor.methodMiddleware(processor.HandleSearchBatch, "POST")
+	h(res, req)
+
+	if res.Code != http.StatusServiceUnavailable {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestHandleParking(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redclient.NewRedisClient(context.Background(), redclient.RedisConfig{Addr: mr.Addr()})
+	info := structs.Info{GlobalID: 42, SystemObjectID: "777", ID: 1, IDEn: 9, Mode: "abc", ModeEn: "cba"}
+	if err := client.AddValue(context.Background(), info); err != nil {
+		t.Fatal(err)
+	}
+
+	logger, _ := zap.NewProduction()
+	defer func() {
+		_ = logger.Sync()
+	}()
+	s := &singleflight.Group{}
+	cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+	processor := NewDBProcessor(client, logger, s, cache)
+
+	tests := map[string]int{
+		"/api/parkings/777":                http.StatusOK,
+		"/api/parkings/by-global-id/42":    http.StatusOK,
+		"/api/parkings/by-id/1":            http.StatusOK,
+		"/api/parkings/by-id-en/9":         http.StatusOK,
+		"/api/parkings/778":                http.StatusNotFound,
+		"/api/parkings/by-global-id/43":    http.StatusNotFound,
+		"/api/parkings/by-id/abc":          http.StatusBadRequest,
+		"/api/parkings/by-name/abc":        http.StatusNotFound,
+		"/api/parkings/":                   http.StatusNotFound,
+		"/api/parkings/by-id/":             http.StatusNotFound,
+		"/api/parkings/by-id/1/extra":      http.StatusNotFound,
+		"/api/parkings/global_id:42":       http.StatusNotFound,
+		"/api/parkings/by-global-id/42abc": http.StatusBadRequest,
+	}
+	for target, status := range tests {
+		req := httptest.NewRequest("GET", target, nil)
+		res := httptest.NewRecorder()
+		h := processor.methodMiddleware(processor.HandleParking, "GET")
+		h(res, req)
+
+		if res.Code != status {
+			t.Errorf("%s: got status %d but wanted %d", target, res.Code, status)
+			continue
+		}
+		if status != http.StatusOK {
+			continue
+		}
+		var result structs.Info
+		if err := easyjson.Unmarshal(res.Body.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+		if result != info {
+			t.Errorf("%s: got %v but wanted %v", target, result, info)
+		}
+	}
+}
+
+func TestHandleParkingErrDuringSearch(t *testing.T) {
+	db, _ := redismock.NewClientMock()
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
+
+	logger, _ := zap.NewProduction()
+	defer func() {
+		_ = logger.Sync()
+	}()
+	s := &singleflight.Group{}
+	cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+	processor := NewDBProcessor(client, logger, s, cache)
+
+	req := httptest.NewRequest("GET", "/api/parkings/777", nil)
+	res := httptest.NewRecorder()
+	h := processor.methodMiddleware(processor.HandleParking, "GET")
+	h(res, req)
+
+	if res.Code != http.StatusServiceUnavailable {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	logger, _ := zap.NewProduction()
+	defer func() {
+		_ = logger.Sync()
+	}()
+	s := &singleflight.Group{}
+	cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+	processor := NewDBProcessor(memstore.New(), logger, s, cache)
+
+	infos := structs.InfoList{
+		{GlobalID: 42, SystemObjectID: "777", ID: 1, IDEn: 9, Mode: "abc", ModeEn: "cba"},
+		{GlobalID: 43, SystemObjectID: "778", ID: 2, IDEn: 10, Mode: "abc", ModeEn: "cba"},
+	}
+	bs, _ := easyjson.Marshal(infos)
+	req := httptest.NewRequest("POST", "/api/load_json", bytes.NewBuffer(bs))
+	res := httptest.NewRecorder()
+	processor.HandleLoadJSON(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("got status %d but wanted %d", res.Code, http.StatusOK)
+	}
+	if err := processor.WaitImports(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	req = httptest.NewRequest("GET", "/api/search?mode=abc&limit=1&offset=1", nil)
+	res = httptest.NewRecorder()
+	processor.HandleSearch(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("got status %d but wanted %d", res.Code, http.StatusOK)
+	}
+	var paginationObj structs.PaginationObject
+	if err := easyjson.Unmarshal(res.Body.Bytes(), &paginationObj); err != nil {
+		t.Fatal(err)
+	}
+	if paginationObj.Size != 2 || len(paginationObj.Data) != 1 || paginationObj.Data[0] != infos[1] {
+		t.Errorf("got %+v", paginationObj)
+	}
+	if etag := res.Header().Get("ETag"); etag == "" {
+		t.Errorf("ETag of imported dataset is not set")
+	}
+
+	req = httptest.NewRequest("GET", "/api/parkings/by-id-en/11", nil)
+	res = httptest.NewRecorder()
+	processor.HandleParking(res, req)
+	if res.Code != http.StatusNotFound {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusNotFound)
+	}
+}
+
+// TestSearchCachePages checks that pages of one query are cached apart,
+// cache key had no offset before and every page was served as the first one
+func TestSearchCachePages(t *testing.T) {
+	logger, _ := zap.NewProduction()
+	defer func() {
+		_ = logger.Sync()
+	}()
+	s := &singleflight.Group{}
+	cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+	store := memstore.New()
+	infos := structs.InfoList{
+		{GlobalID: 42, SystemObjectID: "777", ID: 1, IDEn: 9, Mode: "abc", ModeEn: "cba"},
+		{GlobalID: 43, SystemObjectID: "778", ID: 2, IDEn: 10, Mode: "abc", ModeEn: "cba"},
+	}
+	if err := store.AddValues(context.Background(), infos); err != nil {
+		t.Fatal(err)
+	}
+	processor := NewDBProcessor(store, logger, s, cache)
+
+	for i, info := range infos {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/search?mode=abc&limit=1&offset=%d", i), nil)
+		res := httptest.NewRecorder()
+		processor.HandleSearch(res, req)
+		var paginationObj structs.PaginationObject
+		if err := easyjson.Unmarshal(res.Body.Bytes(), &paginationObj); err != nil {
+			t.Fatal(err)
+		}
+		if len(paginationObj.Data) != 1 || paginationObj.Data[0] != info {
+			t.Errorf("offset %d: got %+v but wanted %+v", i, paginationObj.Data, info)
+		}
 	}
 }
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-developer-test-task/infrastructure/config"
+	"golang-developer-test-task/infrastructure/storage"
+	"golang-developer-test-task/structs"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/mailru/easyjson"
+)
+
+const (
+	defaultReadyTimeout = 2 * time.Second
+
+	healthOK          = "ok"
+	healthUnavailable = "unavailable"
+)
+
+// HealthConfig is struct for storing health check settings
+type HealthConfig struct {
+	// ReadyTimeout limits all readiness checks of one request
+	ReadyTimeout time.Duration
+}
+
+// settings binds HealthConfig fields to config file keys, environment variables and flags
+func (c *HealthConfig) settings() []config.Setting {
+	return []config.Setting{
+		{Key: "health.ready_timeout", Env: []string{"READY_TIMEOUT"}, Usage: "timeout of readiness checks",
+			Default: defaultReadyTimeout.String(), Value: config.Duration(&c.ReadyTimeout)},
+	}
+}
+
+// Load is useful for loading HealthConfig data from environment
+func (c *HealthConfig) Load() error {
+	if err := config.LoadEnv(c.settings()); err != nil {
+		return err
+	}
+	return c.Validate()
+}
+
+// Validate checks that HealthConfig values are usable
+func (c *HealthConfig) Validate() error {
+	if c.ReadyTimeout <= 0 {
+		return fmt.Errorf("ready timeout must be positive, got %s", c.ReadyTimeout)
+	}
+	return nil
+}
+
+// Health serves liveness and readiness checks
+type Health struct {
+	store        storage.Store
+	config       HealthConfig
+	shuttingDown atomic.Bool
+}
+
+// NewHealth is constructor for Health
+func NewHealth(store storage.Store, config HealthConfig) *Health {
+	return &Health{store: store, config: config}
+}
+
+// SetShuttingDown makes the service not ready, it is called on stop signal before server closes listener
+func (h *Health) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// HandleLiveness is handler for /healthz, it answers while the process is able to serve requests
+func (h *Health) HandleLiveness(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, structs.HealthObject{Status: healthOK})
+}
+
+// HandleReadiness is handler for /readyz, the service is ready when storage is reachable,
+// a dataset is imported and shutdown has not begun
+func (h *Health) HandleReadiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.config.ReadyTimeout)
+	defer cancel()
+
+	checks := map[string]string{
+		"storage":  healthOK,
+		"dataset":  healthOK,
+		"shutdown": healthOK,
+	}
+	if err := h.store.Check(ctx); err != nil {
+		checks["storage"] = err.Error()
+		checks["dataset"] = "unknown, storage is unavailable"
+	} else if _, err = h.store.GetDatasetVersion(ctx); errors.Is(err, storage.ErrNotFound) {
+		checks["dataset"] = "no dataset is imported"
+	} else if err != nil {
+		checks["dataset"] = err.Error()
+	}
+	if h.shuttingDown.Load() {
+		checks["shutdown"] = "in progress"
+	}
+
+	for _, result := range checks {
+		if result != healthOK {
+			writeHealth(w, http.StatusServiceUnavailable, structs.HealthObject{Status: healthUnavailable, Checks: checks})
+			return
+		}
+	}
+	writeHealth(w, http.StatusOK, structs.HealthObject{Status: healthOK, Checks: checks})
+}
+
+func writeHealth(w http.ResponseWriter, status int, health structs.HealthObject) {
+	bs, _ := easyjson.Marshal(health)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_, _ = w.Write(bs)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"golang-developer-test-task/infrastructure/redclient"
+	"golang-developer-test-task/structs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/mailru/easyjson"
+)
+
+func TestHealthConfigLoad(t *testing.T) {
+	t.Setenv("READY_TIMEOUT", "")
+	var config HealthConfig
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if config.ReadyTimeout != defaultReadyTimeout {
+		t.Errorf("got timeout %s but wanted %s", config.ReadyTimeout, defaultReadyTimeout)
+	}
+
+	for _, v := range []string{"0s", "1"} {
+		t.Setenv("READY_TIMEOUT", v)
+		if err := config.Load(); err == nil {
+			t.Errorf("%q: expected error", v)
+		}
+	}
+}
+
+func checkHealthResponse(t *testing.T, res *httptest.ResponseRecorder, status int, failed ...string) {
+	t.Helper()
+	if res.Code != status {
+		t.Errorf("got status %d but wanted %d", res.Code, status)
+	}
+	var health structs.HealthObject
+	if err := easyjson.Unmarshal(res.Body.Bytes(), &health); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range failed {
+		if health.Checks[name] == healthOK {
+			t.Errorf("check %s is ok: %v", name, health.Checks)
+		}
+	}
+	if len(failed) == 0 && health.Status != healthOK {
+		t.Errorf("got health %v", health)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client, err := redclient.NewLazyRedisClient(redclient.RedisConfig{Addr: mr.Addr()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	health := NewHealth(client, HealthConfig{ReadyTimeout: time.Second})
+	serve := func(handler http.HandlerFunc) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		handler(res, httptest.NewRequest("GET", "/readyz", nil))
+		return res
+	}
+
+	checkHealthResponse(t, serve(health.HandleReadiness), http.StatusServiceUnavailable, "dataset")
+
+	if _, err := client.BumpDatasetVersion(context.Background(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	checkHealthResponse(t, serve(health.HandleReadiness), http.StatusOK)
+
+	health.SetShuttingDown()
+	checkHealthResponse(t, serve(health.HandleReadiness), http.StatusServiceUnavailable, "shutdown")
+
+	mr.Close()
+	checkHealthResponse(t, serve(health.HandleReadiness), http.StatusServiceUnavailable, "storage", "dataset")
+	checkHealthResponse(t, serve(health.HandleLiveness), http.StatusOK)
+}
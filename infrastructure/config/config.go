@@ -0,0 +1,203 @@
+// Package config loads settings from command-line flags, environment variables,
+// YAML config file and defaults, in order of decreasing priority
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maskedSecret replaces values of secret settings in printed configuration
+const maskedSecret = "******"
+
+// Setting binds one configuration value to its config file key, environment variables and command-line flag
+type Setting struct {
+	// Key is dotted path in config file, e.g. "redis.addr", flag name is Key with dashes, e.g. "redis-addr"
+	Key string
+	// Env are environment variables, the first non-empty one is used, the rest are deprecated names
+	Env     []string
+	Usage   string
+	Default string
+	// Secret settings are masked when configuration is printed
+	Secret bool
+	Value  flag.Getter
+}
+
+// FlagName returns name of command-line flag of setting
+func (s Setting) FlagName() string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(s.Key)
+}
+
+// Errors lists all problems found in configuration
+type Errors []error
+
+func (e Errors) Error() string {
+	var b strings.Builder
+	b.WriteString("invalid configuration:")
+	for _, err := range e {
+		b.WriteString("\n  - ")
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// Check appends err to the list if it is not nil, name tells which part of configuration is wrong
+func (e *Errors) Check(name string, err error) {
+	if err != nil {
+		*e = append(*e, fmt.Errorf("%s: %w", name, err))
+	}
+}
+
+// Err returns nil if there are no errors
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Sources are values of settings by key, found in config file, environment and flags
+type Sources struct {
+	File  map[string]string
+	Env   func(string) (string, bool)
+	Flags map[string]string
+}
+
+// Apply sets every setting from the source of the highest priority: flags, environment,
+// config file and default value, all parsing errors are returned together
+func Apply(settings []Setting, sources Sources) Errors {
+	var errs Errors
+	for _, s := range settings {
+		errs.Check("default of "+s.Key, s.Value.Set(s.Default))
+		if v, ok := sources.File[s.Key]; ok {
+			errs.Check("config file "+s.Key, s.Value.Set(v))
+		}
+		if sources.Env != nil {
+			for _, name := range s.Env {
+				if v, ok := sources.Env(name); ok && v != "" {
+					errs.Check(name, s.Value.Set(v))
+					break
+				}
+			}
+		}
+		if v, ok := sources.Flags[s.Key]; ok {
+			errs.Check("flag --"+s.FlagName(), s.Value.Set(v))
+		}
+	}
+	var unknown []string
+	for key := range sources.File {
+		if !hasKey(settings, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		errs = append(errs, fmt.Errorf("config file: unknown setting %q", key))
+	}
+	return errs
+}
+
+// LoadEnv sets settings from environment variables and defaults
+func LoadEnv(settings []Setting) error {
+	return Apply(settings, Sources{Env: os.LookupEnv}).Err()
+}
+
+func hasKey(settings []Setting, key string) bool {
+	for _, s := range settings {
+		if s.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterFlags defines flag of every setting in fs, values of parsed flags are stored by setting key
+// and applied by Apply, so flags are not parsed before other sources
+func RegisterFlags(fs *flag.FlagSet, settings []Setting) map[string]string {
+	values := make(map[string]string)
+	for _, s := range settings {
+		key := s.Key
+		usage := s.Usage
+		if len(s.Env) > 0 {
+			usage += " (env " + s.Env[0] + ")"
+		}
+		if s.Default != "" && !s.Secret {
+			usage += " (default " + s.Default + ")"
+		}
+		fs.Func(s.FlagName(), usage, func(v string) error {
+			values[key] = v
+			return nil
+		})
+	}
+	return values
+}
+
+// ReadFile reads YAML config file of nested sections, e.g. "redis: {addr: localhost:6379}",
+// into values by dotted keys, lists are joined by commas
+func ReadFile(path string) (map[string]string, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err = yaml.Unmarshal(bs, &doc); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	values := make(map[string]string)
+	flatten("", doc, values)
+	return values, nil
+}
+
+func flatten(prefix string, node map[string]interface{}, values map[string]string) {
+	for name, v := range node {
+		key := prefix + name
+		switch v := v.(type) {
+		case map[string]interface{}:
+			flatten(key+".", v, values)
+		case []interface{}:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[key] = strings.Join(items, ",")
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+// Print writes effective configuration in format of config file, secrets are masked
+func Print(w io.Writer, settings []Setting) error {
+	doc := make(map[string]interface{})
+	for _, s := range settings {
+		var value interface{} = s.Value.Get()
+		if s.Secret && s.Value.String() != "" {
+			value = maskedSecret
+		}
+		path := strings.Split(s.Key, ".")
+		node := doc
+		for _, name := range path[:len(path)-1] {
+			child, ok := node[name].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				node[name] = child
+			}
+			node = child
+		}
+		node[path[len(path)-1]] = value
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
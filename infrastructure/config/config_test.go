@@ -0,0 +1,181 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Addr    string
+	Secret  string
+	Port    int
+	Size    int64
+	Count   uint64
+	Enabled bool
+	TTL     time.Duration
+	Hosts   []string
+}
+
+func (c *testConfig) settings() []Setting {
+	return []Setting{
+		{Key: "server.addr", Env: []string{"TEST_ADDR", "Addr"}, Default: "localhost", Value: String(&c.Addr)},
+		{Key: "server.secret", Env: []string{"TEST_SECRET"}, Secret: true, Value: String(&c.Secret)},
+		{Key: "server.port", Env: []string{"TEST_PORT"}, Default: "8080", Value: Int(&c.Port)},
+		{Key: "limits.size", Env: []string{"TEST_SIZE"}, Default: "1024", Value: Int64(&c.Size)},
+		{Key: "limits.count", Env: []string{"TEST_COUNT"}, Default: "10", Value: Uint64(&c.Count)},
+		{Key: "enabled", Env: []string{"TEST_ENABLED"}, Default: "true", Value: Bool(&c.Enabled)},
+		{Key: "cache.ttl", Env: []string{"TEST_TTL"}, Default: "1m", Value: Duration(&c.TTL)},
+		{Key: "fetch.allowed_hosts", Env: []string{"TEST_HOSTS"}, Value: LowerList(&c.Hosts)},
+	}
+}
+
+func envOf(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+func TestApplyPriority(t *testing.T) {
+	var c testConfig
+	errs := Apply(c.settings(), Sources{
+		File: map[string]string{
+			"server.addr": "file", "server.port": "1", "limits.size": "2", "cache.ttl": "5s",
+		},
+		Env: envOf(map[string]string{
+			"TEST_ADDR": "", "Addr": "deprecated", "TEST_PORT": "3", "TEST_HOSTS": "A.example.com, ,b.example.com",
+		}),
+		Flags: map[string]string{"server.port": "4", "enabled": "false"},
+	})
+	if err := errs.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := testConfig{
+		Addr:    "deprecated",
+		Port:    4,
+		Size:    2,
+		Count:   10,
+		Enabled: false,
+		TTL:     5 * time.Second,
+		Hosts:   []string{"a.example.com", "b.example.com"},
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("got config %+v but wanted %+v", c, want)
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	var c testConfig
+	errs := Apply(c.settings(), Sources{
+		File:  map[string]string{"server.port": "x", "server.unknown": "1", "cache.ttl": "1m"},
+		Env:   envOf(map[string]string{"TEST_COUNT": "-1", "TEST_TTL": "forever"}),
+		Flags: map[string]string{"enabled": "sometimes"},
+	})
+	if len(errs) != 5 {
+		t.Fatalf("got %d errors but wanted 5: %v", len(errs), errs)
+	}
+	for _, name := range []string{"config file server.port", "TEST_COUNT", "TEST_TTL", "flag --enabled", `"server.unknown"`} {
+		if !strings.Contains(errs.Error(), name) {
+			t.Errorf("error of %s is not listed: %v", name, errs)
+		}
+	}
+}
+
+func TestRegisterFlags(t *testing.T) {
+	var c testConfig
+	settings := c.settings()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := RegisterFlags(fs, settings)
+	if err := fs.Parse([]string{"--server-port", "9090", "-fetch-allowed-hosts=x.org"}); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"server.port": "9090", "fetch.allowed_hosts": "x.org"}
+	if !reflect.DeepEqual(flags, want) {
+		t.Errorf("got flags %v but wanted %v", flags, want)
+	}
+	// flags are applied only by Apply
+	if c.Port != 0 {
+		t.Errorf("flag is applied while parsing")
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `
+server:
+  addr: example.com
+  port: 9090
+cache:
+  ttl: 10s
+fetch:
+  allowed_hosts:
+    - a.example.com
+    - b.example.com
+enabled: false
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	values, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"server.addr":         "example.com",
+		"server.port":         "9090",
+		"cache.ttl":           "10s",
+		"fetch.allowed_hosts": "a.example.com,b.example.com",
+		"enabled":             "false",
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("got values %v but wanted %v", values, want)
+	}
+
+	if err = os.WriteFile(path, []byte("server: [1"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ReadFile(path); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestPrint(t *testing.T) {
+	var c testConfig
+	settings := c.settings()
+	if err := Apply(settings, Sources{Env: envOf(map[string]string{
+		"TEST_SECRET": "password", "TEST_HOSTS": "a.example.com,b.example.com",
+	})}).Err(); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Print(&buf, settings); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "password") {
+		t.Errorf("secret is printed:\n%s", buf.String())
+	}
+
+	// printed configuration is valid config file with the same values
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	values, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values["server.secret"] = "password"
+	var printed testConfig
+	if err = Apply(printed.settings(), Sources{File: values}).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(printed, c) {
+		t.Errorf("got config %+v but wanted %+v", printed, c)
+	}
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"flag"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type stringValue struct{ p *string }
+
+// String binds setting to string variable
+func String(p *string) flag.Getter { return stringValue{p} }
+
+func (v stringValue) Set(s string) error { *v.p = s; return nil }
+func (v stringValue) String() string     { return *v.p }
+func (v stringValue) Get() interface{}   { return *v.p }
+
+type boolValue struct{ p *bool }
+
+// Bool binds setting to bool variable, empty value is false
+func Bool(p *bool) flag.Getter { return boolValue{p} }
+
+func (v boolValue) Set(s string) error {
+	if s == "" {
+		*v.p = false
+		return nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*v.p = b
+	return nil
+}
+func (v boolValue) String() string   { return strconv.FormatBool(*v.p) }
+func (v boolValue) Get() interface{} { return *v.p }
+
+type intValue struct{ p *int }
+
+// Int binds setting to int variable, empty value is zero
+func Int(p *int) flag.Getter { return intValue{p} }
+
+func (v intValue) Set(s string) error {
+	n, err := parseInt(s, strconv.IntSize)
+	if err != nil {
+		return err
+	}
+	*v.p = int(n)
+	return nil
+}
+func (v intValue) String() string   { return strconv.Itoa(*v.p) }
+func (v intValue) Get() interface{} { return *v.p }
+
+type int64Value struct{ p *int64 }
+
+// Int64 binds setting to int64 variable, empty value is zero
+func Int64(p *int64) flag.Getter { return int64Value{p} }
+
+func (v int64Value) Set(s string) error {
+	n, err := parseInt(s, 64)
+	if err != nil {
+		return err
+	}
+	*v.p = n
+	return nil
+}
+func (v int64Value) String() string   { return strconv.FormatInt(*v.p, 10) }
+func (v int64Value) Get() interface{} { return *v.p }
+
+type uint64Value struct{ p *uint64 }
+
+// Uint64 binds setting to uint64 variable, empty value is zero
+func Uint64(p *uint64) flag.Getter { return uint64Value{p} }
+
+func (v uint64Value) Set(s string) error {
+	if s == "" {
+		*v.p = 0
+		return nil
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	*v.p = n
+	return nil
+}
+func (v uint64Value) String() string   { return strconv.FormatUint(*v.p, 10) }
+func (v uint64Value) Get() interface{} { return *v.p }
+
+type durationValue struct{ p *time.Duration }
+
+// Duration binds setting to time.Duration variable, empty value is zero
+func Duration(p *time.Duration) flag.Getter { return durationValue{p} }
+
+func (v durationValue) Set(s string) error {
+	if s == "" {
+		*v.p = 0
+		return nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*v.p = d
+	return nil
+}
+func (v durationValue) String() string   { return v.p.String() }
+func (v durationValue) Get() interface{} { return v.p.String() }
+
+type listValue struct {
+	p     *[]string
+	lower bool
+}
+
+// List binds setting to comma-separated list, empty items are dropped
+func List(p *[]string) flag.Getter { return listValue{p: p} }
+
+// LowerList binds setting to comma-separated list of case-insensitive items, e.g. hosts
+func LowerList(p *[]string) flag.Getter { return listValue{p: p, lower: true} }
+
+func (v listValue) Set(s string) error {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if v.lower {
+			item = strings.ToLower(item)
+		}
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	*v.p = items
+	return nil
+}
+func (v listValue) String() string { return strings.Join(*v.p, ",") }
+func (v listValue) Get() interface{} {
+	if *v.p == nil {
+		return []string{}
+	}
+	return *v.p
+}
+
+func parseInt(s string, bitSize int) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(s, 10, bitSize)
+}
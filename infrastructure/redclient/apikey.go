@@ -0,0 +1,56 @@
+package redclient
+
+import (
+	"context"
+	"golang-developer-test-task/infrastructure/storage"
+	"time"
+)
+
+const (
+	apiKeyPrefix         = "apikey:"
+	apiKeyHashField      = "hash"
+	apiKeyRoleField      = "role"
+	apiKeyCreatedAtField = "created_at"
+)
+
+// SaveAPIKey stores API key by its ID
+func (r *RedisClient) SaveAPIKey(ctx context.Context, key storage.APIKey) error {
+	return r.HSet(ctx, r.keys.key(apiKeyPrefix+key.ID),
+		apiKeyHashField, key.Hash,
+		apiKeyRoleField, key.Role,
+		apiKeyCreatedAtField, key.CreatedAt.UTC().Format(time.RFC3339Nano),
+	).Err()
+}
+
+// GetAPIKey returns API key by its ID, storage.ErrNotFound if key does not exist
+func (r *RedisClient) GetAPIKey(ctx context.Context, id string) (key storage.APIKey, err error) {
+	fields, err := r.HGetAll(ctx, r.keys.key(apiKeyPrefix+id)).Result()
+	if err != nil {
+		return key, err
+	}
+	if len(fields) == 0 {
+		return key, storage.ErrNotFound
+	}
+	key.ID = id
+	key.Hash = fields[apiKeyHashField]
+	key.Role = fields[apiKeyRoleField]
+	if createdAt, ok := fields[apiKeyCreatedAtField]; ok {
+		key.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt)
+		if err != nil {
+			return key, err
+		}
+	}
+	return key, nil
+}
+
+// DeleteAPIKey revokes API key by its ID, storage.ErrNotFound if key does not exist
+func (r *RedisClient) DeleteAPIKey(ctx context.Context, id string) error {
+	n, err := r.Del(ctx, r.keys.key(apiKeyPrefix+id)).Result()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package redclient
+
+import (
+	"context"
+	"errors"
+	"golang-developer-test-task/infrastructure/storage"
+	"testing"
+
+	"github.com/go-redis/redismock/v8"
+)
+
+func TestGetAPIKeyBrokenCreatedAt(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	mock.ExpectHGetAll(apiKeyPrefix + "abc").
+		SetVal(map[string]string{apiKeyHashField: "hash", apiKeyCreatedAtField: "yesterday"})
+	client := &RedisClient{UniversalClient: db, MaxRetries: 10}
+
+	_, err := client.GetAPIKey(context.Background(), "abc")
+	if err == nil || errors.Is(err, storage.ErrNotFound) {
+		t.Fatal(err)
+	}
+}
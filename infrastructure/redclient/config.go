@@ -1,26 +1,195 @@
 package redclient
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"golang-developer-test-task/infrastructure/config"
 	"os"
-	"strconv"
+	"strings"
 )
 
+const (
+	// ModeSingle connects to one Redis server
+	ModeSingle = "single"
+	// ModeSentinel connects to master of MasterName found by sentinels at Addr
+	ModeSentinel = "sentinel"
+)
+
+// modeCluster is rejected, records are linked by shared pointer and list keys which scripts change
+// together, so the dataset could not be spread over cluster slots
+const modeCluster = "cluster"
+
 // RedisConfig is struct for storing data about path to Redis Storage
 type RedisConfig struct {
-	Addr     string
+	// Mode is topology of Redis: ModeSingle or ModeSentinel, ModeSingle if empty
+	Mode string
+	// Addr is comma-separated list of addresses: the server or sentinels depending on Mode
+	Addr string
+	// Username is Redis 6 ACL user, "default" user is used if empty
+	Username string
 	Password string
 	DB       int
 	PoolSize int
+	// MasterName is name of master monitored by sentinels
+	MasterName       string
+	SentinelPassword string
+	// KeyPrefix is namespace of the service keys, they start with it followed by ":", keys are bare if it is empty
+	KeyPrefix string
+	TLS       TLSConfig
+}
+
+// TLSConfig is for connecting to Redis over TLS
+type TLSConfig struct {
+	Enabled bool
+	// CAFile is PEM bundle of certificate authorities of servers, system ones are used if empty
+	CAFile string
+	// CertFile and KeyFile are PEM client certificate and its key, both or none of them are set
+	CertFile string
+	KeyFile  string
+	// ServerName is checked in server certificate instead of host of the address
+	ServerName string
+	// MinVersion is the lowest TLS version: "1.2" or "1.3"
+	MinVersion string
+}
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
 }
 
-// Load is useful for loading RedisConfig data
-func (r *RedisConfig) Load() {
-	r.Addr = os.Getenv("Addr")
-	r.Password = os.Getenv("Password")
-	DB, err := strconv.ParseInt(os.Getenv("DB"), 10, 32)
-	if err != nil {
-		panic(err)
+// Settings binds RedisConfig fields to config file keys, environment variables and flags,
+// "Addr", "Password" and "DB" variables are deprecated names
+func (r *RedisConfig) Settings() []config.Setting {
+	return []config.Setting{
+		{Key: "redis.mode", Env: []string{"REDIS_MODE"}, Usage: "Redis topology: single or sentinel",
+			Default: ModeSingle, Value: config.String(&r.Mode)},
+		{Key: "redis.addr", Env: []string{"REDIS_ADDR", "Addr"},
+			Usage:   "comma-separated Redis addresses host:port: the server or sentinels",
+			Default: "localhost:6379", Value: config.String(&r.Addr)},
+		{Key: "redis.username", Env: []string{"REDIS_USERNAME"}, Usage: "Redis ACL username",
+			Value: config.String(&r.Username)},
+		{Key: "redis.password", Env: []string{"REDIS_PASSWORD", "Password"}, Usage: "Redis password",
+			Secret: true, Value: config.String(&r.Password)},
+		{Key: "redis.db", Env: []string{"REDIS_DB", "DB"}, Usage: "Redis database number",
+			Default: "0", Value: config.Int(&r.DB)},
+		{Key: "redis.pool_size", Env: []string{"REDIS_POOL_SIZE"},
+			Usage:   "maximum number of Redis connections",
+			Default: "1000", Value: config.Int(&r.PoolSize)},
+		{Key: "redis.master_name", Env: []string{"REDIS_MASTER_NAME"}, Usage: "name of master monitored by sentinels",
+			Value: config.String(&r.MasterName)},
+		{Key: "redis.sentinel_password", Env: []string{"REDIS_SENTINEL_PASSWORD"}, Usage: "password of sentinels",
+			Secret: true, Value: config.String(&r.SentinelPassword)},
+		{Key: "redis.key_prefix", Env: []string{"REDIS_KEY_PREFIX"},
+			Usage: "namespace of keys to share Redis database, keys are bare if empty",
+			Value: config.String(&r.KeyPrefix)},
+		{Key: "redis.tls.enabled", Env: []string{"REDIS_TLS_ENABLED"}, Usage: "connect to Redis over TLS",
+			Default: "false", Value: config.Bool(&r.TLS.Enabled)},
+		{Key: "redis.tls.ca_file", Env: []string{"REDIS_TLS_CA_FILE"},
+			Usage: "PEM file of certificate authorities of Redis, system ones if empty",
+			Value: config.String(&r.TLS.CAFile)},
+		{Key: "redis.tls.cert_file", Env: []string{"REDIS_TLS_CERT_FILE"}, Usage: "PEM file of client certificate",
+			Value: config.String(&r.TLS.CertFile)},
+		{Key: "redis.tls.key_file", Env: []string{"REDIS_TLS_KEY_FILE"}, Usage: "PEM file of client certificate key",
+			Value: config.String(&r.TLS.KeyFile)},
+		{Key: "redis.tls.server_name", Env: []string{"REDIS_TLS_SERVER_NAME"},
+			Usage: "name of Redis in its certificate, host of the address if empty",
+			Value: config.String(&r.TLS.ServerName)},
+		{Key: "redis.tls.min_version", Env: []string{"REDIS_TLS_MIN_VERSION"}, Usage: "minimal TLS version: 1.2 or 1.3",
+			Default: "1.2", Value: config.String(&r.TLS.MinVersion)},
+	}
+}
+
+// Load is useful for loading RedisConfig data from environment
+func (r *RedisConfig) Load() error {
+	if err := config.LoadEnv(r.Settings()); err != nil {
+		return err
+	}
+	return r.Validate()
+}
+
+// Addrs returns addresses of Addr list
+func (r *RedisConfig) Addrs() []string {
+	var addrs []string
+	for _, addr := range strings.Split(r.Addr, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// Validate checks that RedisConfig values are usable
+func (r *RedisConfig) Validate() error {
+	addrs := r.Addrs()
+	if len(addrs) == 0 {
+		return errors.New("redis address must not be empty")
+	}
+	if r.DB < 0 {
+		return fmt.Errorf("redis db must not be negative, got %d", r.DB)
+	}
+	if r.PoolSize <= 0 {
+		return fmt.Errorf("redis pool size must be positive, got %d", r.PoolSize)
+	}
+	switch r.Mode {
+	case ModeSingle, "":
+		if len(addrs) > 1 {
+			return fmt.Errorf("single redis server needs one address, got %d", len(addrs))
+		}
+	case ModeSentinel:
+		if r.MasterName == "" {
+			return errors.New("redis master name is required in sentinel mode")
+		}
+	case modeCluster:
+		return fmt.Errorf("redis cluster is not supported, the dataset is kept by one server, use %q for failover",
+			ModeSentinel)
+	default:
+		return fmt.Errorf("unknown redis mode %q, expected %q or %q", r.Mode, ModeSingle, ModeSentinel)
+	}
+	// glob characters would break SCAN patterns of the prefix
+	if strings.ContainsAny(r.KeyPrefix, "*?[]\\") {
+		return fmt.Errorf("redis key prefix must not contain glob characters, got %q", r.KeyPrefix)
+	}
+	_, err := r.TLS.Config()
+	return err
+}
+
+// Config builds tls.Config of Redis connections reading certificate files, it is nil if TLS is disabled
+func (t *TLSConfig) Config() (*tls.Config, error) {
+	if !t.Enabled {
+		if t.CAFile != "" || t.CertFile != "" || t.KeyFile != "" {
+			return nil, errors.New("redis tls files are set but tls is disabled")
+		}
+		return nil, nil
+	}
+	conf := &tls.Config{ServerName: t.ServerName, MinVersion: tls.VersionTLS12}
+	if t.MinVersion != "" {
+		version, ok := tlsVersions[t.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unknown redis tls version %q, expected 1.2 or 1.3", t.MinVersion)
+		}
+		conf.MinVersion = version
+	}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading redis tls ca: %w", err)
+		}
+		conf.RootCAs = x509.NewCertPool()
+		if !conf.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in redis tls ca file %s", t.CAFile)
+		}
+	}
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return nil, errors.New("redis tls cert file and key file must be set together")
+	}
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading redis tls client certificate: %w", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
 	}
-	r.DB = int(DB)
-	r.PoolSize = 1000
+	return conf, nil
 }
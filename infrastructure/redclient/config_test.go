@@ -1,39 +1,118 @@
 package redclient
 
-import "testing"
+import (
+	"crypto/tls"
+	"testing"
+)
 
 func TestRedisConfigLoad(t *testing.T) {
+	t.Setenv("REDIS_ADDR", "")
+	t.Setenv("REDIS_PASSWORD", "")
+	t.Setenv("REDIS_DB", "")
+	t.Setenv("REDIS_POOL_SIZE", "")
 	t.Setenv("Addr", "a")
 	t.Setenv("Password", "b")
-	t.Setenv("DB", "0")
+	t.Setenv("DB", "")
 	config := RedisConfig{}
-	config.Load()
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	want := RedisConfig{Mode: ModeSingle, Addr: "a", Password: "b", DB: 0, PoolSize: 1000, TLS: TLSConfig{MinVersion: "1.2"}}
+	if config != want {
+		t.Errorf("got config %v but wanted %v", config, want)
+	}
+
+	t.Setenv("REDIS_ADDR", "redis:6379")
+	t.Setenv("REDIS_DB", "2")
+	t.Setenv("DB", "1")
+	t.Setenv("REDIS_POOL_SIZE", "10")
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	want = RedisConfig{Mode: ModeSingle, Addr: "redis:6379", Password: "b", DB: 2, PoolSize: 10, TLS: TLSConfig{MinVersion: "1.2"}}
+	if config != want {
+		t.Errorf("got config %v but wanted %v", config, want)
+	}
+}
+
+func TestRedisConfigLoadInvalid(t *testing.T) {
+	for name, value := range map[string]string{
+		"REDIS_DB":        "abracadabra",
+		"DB":              "-1",
+		"REDIS_POOL_SIZE": "0",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("REDIS_DB", "")
+			t.Setenv("DB", "")
+			t.Setenv(name, value)
+			config := RedisConfig{}
+			if err := config.Load(); err == nil {
+				t.Errorf("%s=%q: expected error", name, value)
+			}
+		})
+	}
 }
 
-func TestRedisConfigLoadPanic(t *testing.T) {
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("the code did not panic")
+func TestRedisConfigValidate(t *testing.T) {
+	valid := []RedisConfig{
+		{Addr: "redis:6379", PoolSize: 1},
+		{Mode: ModeSentinel, Addr: "s1:26379, s2:26379", MasterName: "mymaster", DB: 1, PoolSize: 1},
+		{Addr: "redis:6379", PoolSize: 1, KeyPrefix: "parkings:prod"},
+		{Addr: "redis:6379", PoolSize: 1, KeyPrefix: "{svc}"},
+	}
+	for _, config := range valid {
+		if err := config.Validate(); err != nil {
+			t.Errorf("config %+v: %v", config, err)
 		}
-	}()
+	}
 
-	t.Setenv("Addr", "a")
-	t.Setenv("Password", "b")
-	// t.Setenv("DB", "0")
-	config := RedisConfig{}
-	config.Load()
+	invalid := []RedisConfig{
+		{Addr: " , ", PoolSize: 1},
+		{Addr: "a:6379,b:6379", PoolSize: 1},
+		{Mode: ModeSentinel, Addr: "s1:26379", PoolSize: 1},
+		{Mode: "cluster", Addr: "n1:6379,n2:6379,n3:6379", PoolSize: 1},
+		{Mode: "replica", Addr: "redis:6379", PoolSize: 1},
+		{Addr: "redis:6379", PoolSize: 1, KeyPrefix: "svc*"},
+	}
+	for _, config := range invalid {
+		if err := config.Validate(); err == nil {
+			t.Errorf("config %+v: expected error", config)
+		}
+	}
 }
 
-func TestRedisConfigLoadPanicConvertDBToInt(t *testing.T) {
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("the code did not panic")
-		}
-	}()
+func TestTLSConfig(t *testing.T) {
+	certs := newTestCerts(t)
+	valid := TLSConfig{Enabled: true, CAFile: certs.CAFile, CertFile: certs.ClientCertFile,
+		KeyFile: certs.ClientKeyFile, ServerName: "redis.test", MinVersion: "1.3"}
+	conf, err := valid.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.ServerName != "redis.test" || conf.MinVersion != tls.VersionTLS13 || len(conf.Certificates) != 1 || conf.RootCAs == nil {
+		t.Errorf("got tls config %+v", conf)
+	}
+	if conf, err = (&TLSConfig{}).Config(); conf != nil || err != nil {
+		t.Errorf("got tls config %v and error %v of disabled tls", conf, err)
+	}
 
-	t.Setenv("Addr", "a")
-	t.Setenv("Password", "b")
-	t.Setenv("DB", "abracadabra")
-	config := RedisConfig{}
-	config.Load()
+	invalid := map[string]func(c *TLSConfig){
+		"disabled with files": func(c *TLSConfig) { c.Enabled = false },
+		"unknown version":     func(c *TLSConfig) { c.MinVersion = "1.1" },
+		"missing ca file":     func(c *TLSConfig) { c.CAFile = "missing.crt" },
+		"ca file without pem": func(c *TLSConfig) { c.CAFile = "config_test.go" },
+		"cert without key":    func(c *TLSConfig) { c.KeyFile = "" },
+		"key of another cert": func(c *TLSConfig) { c.KeyFile = certs.ServerKeyFile },
+	}
+	for name, change := range invalid {
+		config := valid
+		change(&config)
+		if _, err := config.Config(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+		redis := RedisConfig{Addr: "redis:6379", PoolSize: 1, TLS: config}
+		if err := redis.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
 }
@@ -0,0 +1,82 @@
+package redclient
+
+import (
+	"context"
+	"golang-developer-test-task/infrastructure/storage"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	datasetKey               = "dataset"
+	datasetVersionField      = "version"
+	datasetLastModifiedField = "last_modified"
+	// datasetRecordsField counts records, it is changed by transactions adding and removing them
+	datasetRecordsField = "records"
+)
+
+// BumpDatasetVersion increments dataset version and sets its modification time
+func (r *RedisClient) BumpDatasetVersion(ctx context.Context, modified time.Time) (version storage.DatasetVersion, err error) {
+	var incr *redis.IntCmd
+	_, err = r.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.HIncrBy(ctx, r.keys.key(datasetKey), datasetVersionField, 1)
+		pipe.HSet(ctx, r.keys.key(datasetKey), datasetLastModifiedField, modified.UTC().Format(time.RFC3339Nano))
+		return nil
+	})
+	if err != nil {
+		return version, err
+	}
+	version.Version = incr.Val()
+	version.LastModified = modified.UTC()
+	return version, nil
+}
+
+// GetDatasetVersion returns current dataset version, storage.ErrNotFound if nothing was imported yet
+func (r *RedisClient) GetDatasetVersion(ctx context.Context) (version storage.DatasetVersion, err error) {
+	vs, err := r.HMGet(ctx, r.keys.key(datasetKey), datasetVersionField, datasetLastModifiedField).Result()
+	if err != nil {
+		return version, err
+	}
+	return parseDatasetVersion(vs[0], vs[1])
+}
+
+// Stats returns number of records and dataset version
+func (r *RedisClient) Stats(ctx context.Context) (stats storage.Stats, err error) {
+	vs, err := r.HMGet(ctx, r.keys.key(datasetKey),
+		datasetVersionField, datasetLastModifiedField, datasetRecordsField).Result()
+	if err != nil {
+		return stats, err
+	}
+	stats.Dataset, err = parseDatasetVersion(vs[0], vs[1])
+	if err != nil && err != storage.ErrNotFound {
+		return stats, err
+	}
+	if records, ok := vs[2].(string); ok {
+		stats.Records, err = strconv.ParseInt(records, 10, 64)
+		if err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// parseDatasetVersion parses fields of dataset hash returned by HMGET
+func parseDatasetVersion(versionField, lastModifiedField interface{}) (version storage.DatasetVersion, err error) {
+	v, ok := versionField.(string)
+	if !ok {
+		return version, storage.ErrNotFound
+	}
+	version.Version, err = strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return version, err
+	}
+	if modified, ok := lastModifiedField.(string); ok {
+		version.LastModified, err = time.Parse(time.RFC3339Nano, modified)
+		if err != nil {
+			return version, err
+		}
+	}
+	return version, nil
+}
@@ -0,0 +1,61 @@
+package redclient
+
+import (
+	"context"
+	"golang-developer-test-task/infrastructure/storage"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redismock/v8"
+)
+
+func TestGetDatasetVersionNothingImported(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := NewRedisClient(context.Background(), RedisConfig{Addr: mr.Addr()})
+
+	_, err := client.GetDatasetVersion(context.Background())
+	if err != storage.ErrNotFound {
+		t.Fatal(err)
+	}
+}
+
+func TestBumpDatasetVersion(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := NewRedisClient(context.Background(), RedisConfig{Addr: mr.Addr()})
+	ctx := context.Background()
+
+	modified := time.Date(2022, 9, 1, 12, 0, 0, 42, time.UTC)
+	for i := int64(1); i <= 3; i++ {
+		version, err := client.BumpDatasetVersion(ctx, modified)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version.Version != i {
+			t.Errorf("got version %d but wanted %d", version.Version, i)
+		}
+	}
+
+	version, err := client.GetDatasetVersion(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version.Version != 3 {
+		t.Errorf("got version %d but wanted %d", version.Version, 3)
+	}
+	if !version.LastModified.Equal(modified) {
+		t.Errorf("got last modified %s but wanted %s", version.LastModified, modified)
+	}
+}
+
+func TestGetDatasetVersionBrokenValue(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	mock.ExpectHMGet(datasetKey, datasetVersionField, datasetLastModifiedField).
+		SetVal([]interface{}{"abracadabra", nil})
+	client := &RedisClient{UniversalClient: db, MaxRetries: 10}
+
+	_, err := client.GetDatasetVersion(context.Background())
+	if err == nil || err == storage.ErrNotFound {
+		t.Fatal(err)
+	}
+}
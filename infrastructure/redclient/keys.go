@@ -0,0 +1,32 @@
+package redclient
+
+// keyBuilder maps logical keys of the service, e.g. "<system_object_id>" or "global_id:<id>",
+// onto names of Redis keys, they are the same unless namespace is set
+type keyBuilder struct {
+	// prefix starts all keys of the service, it is empty or namespace followed by ":"
+	prefix string
+}
+
+func newKeyBuilder(namespace string) keyBuilder {
+	if namespace != "" {
+		namespace += ":"
+	}
+	return keyBuilder{prefix: namespace}
+}
+
+// key returns name of Redis key of logical key
+func (b keyBuilder) key(logical string) string {
+	return b.prefix + logical
+}
+
+// keys returns names of Redis keys of logical keys
+func (b keyBuilder) keys(logical []string) []string {
+	if b.prefix == "" {
+		return logical
+	}
+	names := make([]string, len(logical))
+	for i, key := range logical {
+		names[i] = b.key(key)
+	}
+	return names
+}
@@ -0,0 +1,62 @@
+package redclient
+
+import (
+	"context"
+	"golang-developer-test-task/infrastructure/storage"
+	"golang-developer-test-task/structs"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestKeyBuilder(t *testing.T) {
+	if key := newKeyBuilder("").key("id:1"); key != "id:1" {
+		t.Errorf("got key %s but wanted %s", key, "id:1")
+	}
+	if key := newKeyBuilder("svc").key("id:1"); key != "svc:id:1" {
+		t.Errorf("got key %s but wanted %s", key, "svc:id:1")
+	}
+	keys := newKeyBuilder("svc").keys([]string{"777", "mode:abc"})
+	if len(keys) != 2 || keys[0] != "svc:777" || keys[1] != "svc:mode:abc" {
+		t.Errorf("got keys %v", keys)
+	}
+}
+
+func TestNamespacedClient(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := newTestLazyClient(t, RedisConfig{Addr: mr.Addr(), KeyPrefix: "svc"})
+	defer client.Close()
+	ctx := context.Background()
+
+	infos := structs.InfoList{{GlobalID: 1, SystemObjectID: "1", ID: 11, IDEn: 111, Mode: "a", ModeEn: "b"}}
+	if err := client.AddValues(ctx, infos); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.BumpDatasetVersion(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SaveAPIKey(ctx, storage.APIKey{ID: "k1", Hash: "h", Role: "admin"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.AllowRequest(ctx, "search:ip:127.0.0.1", 1, time.Minute, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range mr.Keys() {
+		if !strings.HasPrefix(key, "svc:") {
+			t.Errorf("key %s is not in namespace", key)
+		}
+	}
+
+	found, _, err := client.FindValues(ctx, "id:11", false, 0, 0)
+	if err != nil || len(found) != 1 || found[0].GlobalID != 1 {
+		t.Errorf("got %v and error %v but wanted record 1", found, err)
+	}
+	if err = client.DeleteValue(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = client.FindValues(ctx, "1", false, 0, 0); err != storage.ErrNotFound {
+		t.Errorf("got error %v of deleted record", err)
+	}
+}
@@ -0,0 +1,251 @@
+package redclient
+
+import (
+	"context"
+	"errors"
+	"golang-developer-test-task/infrastructure/storage"
+	"golang-developer-test-task/structs"
+	"sort"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/mailru/easyjson"
+	"github.com/mailru/easyjson/jlexer"
+)
+
+// migrateScanCount is number of keys asked from SCAN at once
+const migrateScanCount = 1000
+
+// MigrateRecords converts records kept as JSON strings into hashes and returns number of converted ones,
+// records are read in both formats, so it is safe to serve requests meanwhile
+func (r *RedisClient) MigrateRecords(ctx context.Context) (migrated int, err error) {
+	iter := r.ScanType(ctx, 0, r.keys.key("*"), migrateScanCount, "string").Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		// pointer keys are strings as well
+		if storage.IsPointerKey(strings.TrimPrefix(key, r.keys.prefix)) {
+			continue
+		}
+		converted, err := r.migrateRecord(ctx, key)
+		if err != nil {
+			return migrated, err
+		}
+		if converted {
+			migrated++
+		}
+	}
+	return migrated, iter.Err()
+}
+
+// migrateRecordScript replaces JSON string of record by hash unless the string was changed meanwhile
+var migrateRecordScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+redis.call("DEL", KEYS[1])
+redis.call("HSET", KEYS[1], unpack(ARGV, 2))
+return 1
+`)
+
+// migrateRecord converts record kept as JSON string into hash, strings which are not records are kept
+func (r *RedisClient) migrateRecord(ctx context.Context, key string) (converted bool, err error) {
+	value, err := r.Get(ctx, key).Result()
+	if err == redis.Nil || isWrongType(err) {
+		// removed or converted meanwhile
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var info structs.Info
+	if easyjson.Unmarshal([]byte(value), &info) != nil || r.keys.key(info.SystemObjectID) != key {
+		return false, nil
+	}
+	n, err := migrateRecordScript.Run(ctx, r, []string{key}, append([]interface{}{value}, recordArgs(info)...)...).Int()
+	if isWrongType(err) {
+		return false, nil
+	}
+	return n == 1, err
+}
+
+// KeyMigration is result of MigrateKeys
+type KeyMigration struct {
+	// Renamed is number of keys moved into namespace of the client, or to be moved by dry run
+	Renamed int
+	// Conflicts are keys which were kept as their new names are taken
+	Conflicts []string
+}
+
+// foundKeys are keys of the service found in the old namespace by their names and types,
+// records and pointers are moved once they are known to be records of the dataset
+type foundKeys struct {
+	// moves maps names of keys to move onto their new names
+	moves map[string]string
+	// pointers maps pointer keys onto system_object_ids they point to
+	pointers map[string]string
+	// records maps keys which may be records onto their system_object_ids
+	records map[string]string
+	// listed are system_object_ids of list keys
+	listed map[string]bool
+}
+
+// MigrateKeys renames keys of the service kept with key prefix from, bare keys if it is empty,
+// into namespace of the client, dry run only reports what would be renamed. Keys are recognized by names
+// and types: dataset and seq keys, pointer strings pointing to records, mode lists, RediSearch records,
+// API keys and rate limit counters. Other keys are records only if pointers or lists refer to them
+// and their system_object_id matches the name, so keys of other applications are kept.
+// RediSearch index of the old namespace is dropped, SearchStore indexes records in the new one
+func (r *RedisClient) MigrateKeys(ctx context.Context, from string, dryRun bool) (result KeyMigration, err error) {
+	old := newKeyBuilder(from)
+	if old.prefix == r.keys.prefix {
+		return result, nil
+	}
+
+	found := &foundKeys{
+		moves:    make(map[string]string),
+		pointers: make(map[string]string),
+		records:  make(map[string]string),
+		listed:   make(map[string]bool),
+	}
+	iter := r.Scan(ctx, 0, old.prefix+"*", migrateScanCount).Iterator()
+	for iter.Next(ctx) {
+		if err = r.findKey(ctx, old, iter.Val(), found); err != nil {
+			return result, err
+		}
+	}
+	if err = iter.Err(); err != nil {
+		return result, err
+	}
+	if err = r.findRecords(ctx, old, found); err != nil {
+		return result, err
+	}
+
+	keys := make([]string, 0, len(found.moves))
+	for key := range found.moves {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var renamed bool
+		if dryRun {
+			var n int64
+			n, err = r.Exists(ctx, found.moves[key]).Result()
+			renamed = n == 0
+		} else {
+			renamed, err = r.RenameNX(ctx, key, found.moves[key]).Result()
+		}
+		if err != nil {
+			return result, err
+		}
+		if renamed {
+			result.Renamed++
+		} else {
+			result.Conflicts = append(result.Conflicts, key)
+		}
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	err = r.Do(ctx, "FT.DROPINDEX", old.key(searchIndex)).Err()
+	if err != nil && (isUnknownCommand(err) || strings.Contains(strings.ToLower(err.Error()), "unknown index")) {
+		err = nil
+	}
+	return result, err
+}
+
+// findKey adds key of the old namespace to found keys if it is one of the service
+func (r *RedisClient) findKey(ctx context.Context, old keyBuilder, key string, found *foundKeys) error {
+	typ, err := r.Type(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+
+	logical := key[len(old.prefix):]
+	switch {
+	case typ == keyTypeHash && strings.HasPrefix(logical, apiKeyPrefix),
+		typ == keyTypeString && strings.HasPrefix(logical, rateLimitPrefix):
+		found.moves[key] = r.keys.key(logical)
+	case logical == datasetKey && typ == keyTypeHash, logical == searchSeqKey && typ == keyTypeString:
+		found.moves[key] = r.keys.key(logical)
+	case storage.IsPointerKey(logical):
+		if typ != keyTypeString {
+			return nil
+		}
+		id, err := r.Get(ctx, key).Result()
+		if err != nil {
+			return ignoreMissing(err)
+		}
+		found.pointers[key] = id
+	case strings.HasPrefix(logical, "mode:") || strings.HasPrefix(logical, "mode_en:"):
+		if typ != keyTypeList {
+			return nil
+		}
+		ids, err := r.LRange(ctx, key, 0, -1).Result()
+		if err != nil {
+			return ignoreMissing(err)
+		}
+		for _, id := range ids {
+			found.listed[id] = true
+		}
+		found.moves[key] = r.keys.key(logical)
+	case strings.HasPrefix(logical, searchRecordPrefix) && typ == keyTypeHash:
+		info, err := parseRecord(r.HGet(ctx, key, searchJSONField).Result())
+		if err == nil && info != nil && searchRecordPrefix+info.SystemObjectID == logical {
+			found.moves[key] = r.keys.key(logical)
+		}
+	case typ == keyTypeHash || typ == keyTypeString:
+		found.records[key] = logical
+	}
+	return nil
+}
+
+// findRecords adds pointers to records and records referred to by pointers or lists to found keys
+func (r *RedisClient) findRecords(ctx context.Context, old keyBuilder, found *foundKeys) error {
+	referred := found.listed
+	for key, id := range found.pointers {
+		ok, err := r.isRecord(ctx, old.key(id), id)
+		if err != nil {
+			return err
+		}
+		if ok {
+			found.moves[key] = r.keys.key(key[len(old.prefix):])
+			referred[id] = true
+		}
+	}
+	for key, id := range found.records {
+		if !referred[id] {
+			continue
+		}
+		ok, err := r.isRecord(ctx, key, id)
+		if err != nil {
+			return err
+		}
+		if ok {
+			found.moves[key] = r.keys.key(id)
+		}
+	}
+	return nil
+}
+
+// isRecord reports whether key keeps record with system_object_id id in any format
+func (r *RedisClient) isRecord(ctx context.Context, key, id string) (bool, error) {
+	info, exists, err := readRecord(ctx, r, key, []string{"system_object_id"})
+	if err != nil {
+		var syntaxErr *jlexer.LexerError
+		if errors.As(err, &syntaxErr) || isWrongType(err) {
+			// value of foreign key is not a record
+			return false, nil
+		}
+		return false, err
+	}
+	return exists && info.SystemObjectID == id, nil
+}
+
+// ignoreMissing ignores redis.Nil of keys removed while they are migrated
+func ignoreMissing(err error) error {
+	if err == redis.Nil {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,204 @@
+package redclient
+
+import (
+	"context"
+	"golang-developer-test-task/infrastructure/storage"
+	"golang-developer-test-task/infrastructure/storage/storagetest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/mailru/easyjson"
+)
+
+// setLegacyRecords writes records as JSON strings, as they were kept before migration to hashes
+func setLegacyRecords(t *testing.T, mr *miniredis.Miniredis, prefix string, n int) {
+	t.Helper()
+	for _, info := range storagetest.Infos(n) {
+		bs, err := easyjson.Marshal(info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = mr.Set(prefix+info.SystemObjectID, string(bs)); err != nil {
+			t.Fatal(err)
+		}
+		if err = mr.Set(prefix+"global_id:"+strconv.Itoa(info.GlobalID), info.SystemObjectID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = mr.Push(prefix+"mode:"+info.Mode, info.SystemObjectID); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLegacyRecords(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := newTestLazyClient(t, RedisConfig{Addr: mr.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+	setLegacyRecords(t, mr, "", 3)
+	infos := storagetest.Infos(3)
+
+	infoList, _, err := client.FindValues(ctx, "global_id:1001", false, 0, 0)
+	if err != nil || !reflect.DeepEqual(infoList, infos[1:2]) {
+		t.Errorf("got %v and error %v but wanted %v", infoList, err, infos[1:2])
+	}
+	infoList, _, err = client.FindFields(ctx, "mode:a", true, 10, 0, []string{"Name"})
+	if err != nil || len(infoList) != 2 || infoList[1].SystemObjectID != "C0" {
+		t.Errorf("got %v and error %v of legacy list", infoList, err)
+	}
+	found, err := client.FindValuesByKeys(ctx, []string{"A0", "missing", "global_id:1002"})
+	if err != nil || found[0] == nil || *found[0] != infos[0] || found[1] != nil || found[2] == nil || *found[2] != infos[2] {
+		t.Errorf("got %v and error %v of legacy records", found, err)
+	}
+
+	replaced := infos[0]
+	replaced.Name = "replaced"
+	if err = client.ReplaceValue(ctx, replaced); err != nil {
+		t.Fatal(err)
+	}
+	if got := mr.HGet("A0", "Name"); got != "replaced" {
+		t.Errorf("got name %q of replaced legacy record", got)
+	}
+}
+
+func TestMigrateRecords(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := newTestLazyClient(t, RedisConfig{Addr: mr.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+	prefix := client.keys.key("")
+	setLegacyRecords(t, mr, prefix, 3)
+	// strings which are not records are kept
+	if err := mr.Set(prefix+"counter", "5"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mr.Set(prefix+"other", `{"system_object_id":"A0"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	migrated, err := client.MigrateRecords(ctx)
+	if err != nil || migrated != 3 {
+		t.Fatalf("got %d migrated records and error %v but wanted 3", migrated, err)
+	}
+	for _, key := range []string{"A0", "B0", "C0"} {
+		if typ := mr.Type(prefix + key); typ != "hash" {
+			t.Errorf("%s: got type %s but wanted hash", key, typ)
+		}
+	}
+	for _, key := range []string{"global_id:1000", "counter", "other"} {
+		if typ := mr.Type(prefix + key); typ != "string" {
+			t.Errorf("%s: got type %s but wanted string", key, typ)
+		}
+	}
+	infoList, _, err := client.FindValues(ctx, "global_id:1002", false, 0, 0)
+	if want := storagetest.Infos(3)[2:]; err != nil || !reflect.DeepEqual(infoList, want) {
+		t.Errorf("got %v and error %v but wanted %v", infoList, err, want)
+	}
+
+	if migrated, err = client.MigrateRecords(ctx); err != nil || migrated != 0 {
+		t.Errorf("got %d migrated records and error %v of migrated dataset", migrated, err)
+	}
+}
+
+func TestMigrateKeys(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ctx := context.Background()
+	bare := newTestLazyClient(t, RedisConfig{Addr: mr.Addr()})
+	defer bare.Close()
+	if err := bare.AddValues(ctx, storagetest.Infos(3)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bare.BumpDatasetVersion(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := bare.SaveAPIKey(ctx, storage.APIKey{ID: "k1", Hash: "h", Role: "admin"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bare.AllowRequest(ctx, "1.2.3.4", 10, time.Minute, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	// keys of other applications are kept even if their names look like keys of the service:
+	// list key of other type, pointer to no record, record nothing refers to and record of other id
+	foreign := []string{"session:1", bare.keys.key("mode_en:x"), bare.keys.key("global_id:7"),
+		bare.keys.key("Z9"), bare.keys.key("D0"), bare.keys.key("E0"), "apikey:x"}
+	for _, key := range foreign[:3] {
+		if err := mr.Set(key, "x"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mr.HSet(bare.keys.key("Z9"), "system_object_id", "Z9")
+	mr.HSet(bare.keys.key("D0"), "system_object_id", "other")
+	if _, err := mr.Push(bare.keys.key("E0"), "x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mr.Set("apikey:x", "x"); err != nil {
+		t.Fatal(err)
+	}
+	// the first new name is taken
+	if err := mr.Set("svc:"+bare.keys.key("global_id:1000"), "taken"); err != nil {
+		t.Fatal(err)
+	}
+	kept := append([]string{bare.keys.key("global_id:1000")}, foreign...)
+
+	client := newTestLazyClient(t, RedisConfig{Addr: mr.Addr(), KeyPrefix: "svc"})
+	defer client.Close()
+	// 3 records with 3 pointers each but the taken one, 4 lists, dataset, API key and rate limit counter
+	wantRenamed := 3 + 3*3 - 1 + 4 + 3
+	keys := mr.Keys()
+	result, err := client.MigrateKeys(ctx, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Renamed != wantRenamed || len(result.Conflicts) != 1 {
+		t.Errorf("got %d keys to rename and conflicts %v of dry run", result.Renamed, result.Conflicts)
+	}
+	if got := mr.Keys(); !equalStrings(got, keys) {
+		t.Errorf("got keys %v after dry run but wanted %v", got, keys)
+	}
+
+	result, err = client.MigrateKeys(ctx, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Renamed != wantRenamed || len(result.Conflicts) != 1 {
+		t.Errorf("got %d renamed keys and conflicts %v", result.Renamed, result.Conflicts)
+	}
+	for _, key := range mr.Keys() {
+		if !strings.HasPrefix(key, "svc:") && !contains(kept, key) {
+			t.Errorf("key %s is not migrated", key)
+		}
+	}
+	for _, key := range foreign {
+		if !mr.Exists(key) {
+			t.Errorf("foreign key %s is migrated", key)
+		}
+	}
+
+	infoList, total, err := client.FindValues(ctx, "mode:a", true, 10, 0)
+	if err != nil || total != 2 || len(infoList) != 2 {
+		t.Errorf("got %v of %d and error %v after migration", infoList, total, err)
+	}
+	if stats, err := client.Stats(ctx); err != nil || stats.Records != 3 || stats.Dataset.Version != 1 {
+		t.Errorf("got stats %+v and error %v after migration", stats, err)
+	}
+	if key, err := client.GetAPIKey(ctx, "k1"); err != nil || key.Role != "admin" {
+		t.Errorf("got API key %+v and error %v after migration", key, err)
+	}
+
+	if result, err = client.MigrateKeys(ctx, "svc", false); err != nil || result.Renamed != 0 {
+		t.Errorf("got %+v and error %v of the same namespace", result, err)
+	}
+}
+
+func contains(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
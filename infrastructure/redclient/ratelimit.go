@@ -0,0 +1,52 @@
+package redclient
+
+import (
+	"context"
+	"golang-developer-test-task/infrastructure/storage"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const rateLimitPrefix = "ratelimit:"
+
+// slidingWindowScript counts request in the current window if the estimated number of requests
+// in the sliding window stays within limit, previous window is weighted by its overlap with the sliding one
+var slidingWindowScript = redis.NewScript(`
+local current = tonumber(redis.call("GET", KEYS[1]) or "0")
+local previous = tonumber(redis.call("GET", KEYS[2]) or "0")
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local elapsed = tonumber(ARGV[3])
+if previous * (window - elapsed) / window + current + 1 > limit then
+	return {0, previous, current}
+end
+redis.call("INCR", KEYS[1])
+redis.call("PEXPIRE", KEYS[1], 2 * window)
+return {1, previous, current + 1}
+`)
+
+// AllowRequest counts request of key in sliding window of given size if there are
+// less than limit requests in it, rejected requests are not counted
+func (r *RedisClient) AllowRequest(ctx context.Context, key string, limit int64,
+	window time.Duration, now time.Time) (result storage.RateLimitResult, err error) {
+	windowMs := window.Milliseconds()
+	nowMs := now.UnixMilli()
+	index := nowMs / windowMs
+	elapsed := nowMs % windowMs
+
+	tag := r.keys.key(rateLimitPrefix + key + ":")
+	vs, err := slidingWindowScript.Run(ctx, r,
+		[]string{tag + strconv.FormatInt(index, 10), tag + strconv.FormatInt(index-1, 10)},
+		limit, windowMs, elapsed).Int64Slice()
+	if err != nil {
+		return result, err
+	}
+	if vs[0] == 1 {
+		result.Allowed = true
+		return result, nil
+	}
+	result.RetryAfter = storage.RetryAfter(vs[1], vs[2], limit, windowMs, elapsed)
+	return result, nil
+}
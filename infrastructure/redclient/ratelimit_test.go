@@ -0,0 +1,26 @@
+package redclient
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// TestAllowRequest checks keys of counters, counting is checked by storagetest
+func TestAllowRequest(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := NewRedisClient(context.Background(), RedisConfig{Addr: mr.Addr()})
+	window := time.Minute
+	start := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := client.AllowRequest(context.Background(), "search:ip:127.0.0.1", 3, window, start); err != nil {
+		t.Fatal(err)
+	}
+
+	key := rateLimitPrefix + "search:ip:127.0.0.1:" + strconv.FormatInt(start.UnixMilli()/window.Milliseconds(), 10)
+	if ttl := mr.TTL(key); ttl <= 0 || ttl > 2*window {
+		t.Errorf("got ttl %s of %s", ttl, key)
+	}
+}
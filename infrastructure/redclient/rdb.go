@@ -2,29 +2,149 @@ package redclient
 
 import (
 	"context"
+	"crypto/tls"
+	"golang-developer-test-task/infrastructure/storage"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 )
 
-// RedisClient is for wrapping original redis.Client
+// RedisClient is for wrapping original client of single server or sentinel-managed master
 type RedisClient struct {
-	redis.Client
+	redis.UniversalClient
 	MaxRetries int
+	keys       keyBuilder
 }
 
-// NewRedisClient is constructor for RedisClient
-func NewRedisClient(ctx context.Context, config RedisConfig) *RedisClient {
-	options := redis.Options{
-		Addr:     config.Addr,
-		Password: config.Password,
-		DB:       config.DB,
-		PoolSize: config.PoolSize,
+var (
+	_ storage.Store       = (*RedisClient)(nil)
+	_ storage.FieldFinder = (*RedisClient)(nil)
+)
+
+// Backoff is exponentially growing delay between connection attempts
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+}
+
+// DefaultBackoff returns Backoff used for connecting at startup
+func DefaultBackoff() Backoff {
+	return Backoff{Initial: 100 * time.Millisecond, Max: 5 * time.Second}
+}
+
+// Delay returns delay before attempt following the given one, attempts are counted from 1
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := b.Initial
+	for i := 1; i < attempt && delay < b.Max; i++ {
+		delay *= 2
 	}
-	client := redis.NewClient(&options)
+	if delay > b.Max {
+		return b.Max
+	}
+	return delay
+}
 
-	if _, err := client.Ping(ctx).Result(); err != nil {
+// NewRedisClient is constructor for RedisClient, it panics if Redis is unavailable,
+// use NewLazyRedisClient and WaitConnected to start before Redis
+func NewRedisClient(ctx context.Context, config RedisConfig) *RedisClient {
+	client, err := NewLazyRedisClient(config)
+	if err != nil {
 		panic(err)
 	}
+	if _, err = client.Ping(ctx).Result(); err != nil {
+		panic(err)
+	}
+	return client
+}
+
+// NewLazyRedisClient is constructor for RedisClient which does not connect to Redis,
+// connections are established by the first commands, it fails only if TLS files could not be loaded
+func NewLazyRedisClient(config RedisConfig) (*RedisClient, error) {
+	tlsConfig, err := config.TLS.Config()
+	if err != nil {
+		return nil, err
+	}
 	maxRetries := 10
-	return &RedisClient{Client: *client, MaxRetries: maxRetries}
+	return &RedisClient{
+		UniversalClient: newUniversalClient(config, tlsConfig),
+		MaxRetries:      maxRetries,
+		keys:            newKeyBuilder(config.KeyPrefix),
+	}, nil
+}
+
+// newUniversalClient creates client of topology given by config.Mode
+func newUniversalClient(config RedisConfig, tlsConfig *tls.Config) redis.UniversalClient {
+	addrs := config.Addrs()
+	switch config.Mode {
+	case ModeSentinel:
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       config.MasterName,
+			SentinelAddrs:    addrs,
+			SentinelPassword: config.SentinelPassword,
+			Username:         config.Username,
+			Password:         config.Password,
+			DB:               config.DB,
+			PoolSize:         config.PoolSize,
+			TLSConfig:        tlsConfig,
+		})
+	default:
+		var addr string
+		if len(addrs) > 0 {
+			addr = addrs[0]
+		}
+		return redis.NewClient(&redis.Options{
+			Addr:      addr,
+			Username:  config.Username,
+			Password:  config.Password,
+			DB:        config.DB,
+			PoolSize:  config.PoolSize,
+			TLSConfig: tlsConfig,
+		})
+	}
+}
+
+// scripts are Lua scripts run by the client
+var scripts = []*redis.Script{upsertScript, deleteScript, findRecordScript, migrateRecordScript, slidingWindowScript,
+	searchUpsertScript}
+
+// LoadScripts loads scripts into script cache of Redis, so they are run by EVALSHA without their source,
+// scripts which are missing later, e.g. after restart of Redis, are sent with EVAL by the first call
+func (r *RedisClient) LoadScripts(ctx context.Context) error {
+	for _, script := range scripts {
+		if err := script.Load(ctx, r).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check pings Redis
+func (r *RedisClient) Check(ctx context.Context) error {
+	return r.Ping(ctx).Err()
+}
+
+// WaitConnected pings Redis with delays of backoff until it answers or ctx is done,
+// onRetry is called with error of every failed attempt and delay before the next one
+func (r *RedisClient) WaitConnected(ctx context.Context, backoff Backoff,
+	onRetry func(attempt int, err error, delay time.Duration)) error {
+	for attempt := 1; ; attempt++ {
+		err := r.Ping(ctx).Err()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		delay := backoff.Delay(attempt)
+		if onRetry != nil {
+			onRetry(attempt, err, delay)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
 }
@@ -2,9 +2,22 @@ package redclient
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
 )
 
 func TestNewRedisClientPanic(t *testing.T) {
@@ -33,3 +46,215 @@ func TestNewRedisClient(t *testing.T) {
 	config := RedisConfig{Addr: mr.Addr(), Password: "", DB: 0}
 	_ = NewRedisClient(context.Background(), config)
 }
+
+func TestBackoffDelay(t *testing.T) {
+	backoff := Backoff{Initial: 100 * time.Millisecond, Max: time.Second}
+	for attempt, want := range map[int]time.Duration{
+		1:   100 * time.Millisecond,
+		2:   200 * time.Millisecond,
+		4:   800 * time.Millisecond,
+		5:   time.Second,
+		100: time.Second,
+	} {
+		if got := backoff.Delay(attempt); got != want {
+			t.Errorf("%d: got delay %s but wanted %s", attempt, got, want)
+		}
+	}
+}
+
+func TestWaitConnected(t *testing.T) {
+	// the address is free until Redis is started on it
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	_ = listener.Close()
+
+	client := newTestLazyClient(t, RedisConfig{Addr: addr})
+	mr := miniredis.NewMiniRedis()
+	defer mr.Close()
+	var attempts int
+	err = client.WaitConnected(context.Background(), Backoff{Initial: time.Millisecond, Max: 10 * time.Millisecond},
+		func(attempt int, err error, delay time.Duration) {
+			attempts = attempt
+			if attempt == 3 {
+				if err := mr.StartAddr(addr); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// after many dial errors the pool retries dialing in background, so there may be more attempts
+	if attempts < 3 {
+		t.Errorf("got %d failed attempts but wanted at least 3", attempts)
+	}
+}
+
+func TestWaitConnectedCanceled(t *testing.T) {
+	client := newTestLazyClient(t, RedisConfig{Addr: ":"})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := client.WaitConnected(ctx, DefaultBackoff(), nil); err == nil {
+		t.Errorf("expected error")
+	}
+	if ctx.Err() == nil {
+		t.Errorf("returned before context is done")
+	}
+}
+
+func TestNewLazyRedisClientModes(t *testing.T) {
+	single := newTestLazyClient(t, RedisConfig{Addr: "redis:6379", PoolSize: 1})
+	if _, ok := single.UniversalClient.(*redis.Client); !ok {
+		t.Errorf("got %T for single server", single.UniversalClient)
+	}
+	sentinel := newTestLazyClient(t, RedisConfig{Mode: ModeSentinel, Addr: "s1:26379,s2:26379", MasterName: "mymaster", PoolSize: 1})
+	if _, ok := sentinel.UniversalClient.(*redis.Client); !ok {
+		t.Errorf("got %T for sentinel", sentinel.UniversalClient)
+	}
+}
+
+func newTestLazyClient(t testing.TB, config RedisConfig) *RedisClient {
+	t.Helper()
+	client, err := NewLazyRedisClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// testCerts are PEM files of certificate authority, server certificate for "redis.test"
+// and client certificate signed by it
+type testCerts struct {
+	CAFile, ServerCertFile, ServerKeyFile, ClientCertFile, ClientKeyFile string
+}
+
+func newTestCerts(t *testing.T) testCerts {
+	t.Helper()
+	dir := t.TempDir()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writePEM := func(name, kind string, der []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	issue := func(name string, serial int64, usage x509.ExtKeyUsage, dnsNames []string) (certFile, keyFile string) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			DNSNames:     dnsNames,
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return writePEM(name+".crt", "CERTIFICATE", der), writePEM(name+".key", "EC PRIVATE KEY", keyDER)
+	}
+
+	certs := testCerts{CAFile: writePEM("ca.crt", "CERTIFICATE", caDER)}
+	certs.ServerCertFile, certs.ServerKeyFile = issue("server", 2, x509.ExtKeyUsageServerAuth, []string{"redis.test"})
+	certs.ClientCertFile, certs.ClientKeyFile = issue("client", 3, x509.ExtKeyUsageClientAuth, nil)
+	return certs
+}
+
+func TestNewRedisClientTLS(t *testing.T) {
+	certs := newTestCerts(t)
+	serverCert, err := tls.LoadX509KeyPair(certs.ServerCertFile, certs.ServerKeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caPEM, err := os.ReadFile(certs.CAFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(caPEM)
+	mr, err := miniredis.RunTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	mr.RequireUserAuth("app", "secret")
+
+	valid := RedisConfig{
+		Addr:     mr.Addr(),
+		Username: "app",
+		Password: "secret",
+		PoolSize: 1,
+		TLS: TLSConfig{
+			Enabled:    true,
+			CAFile:     certs.CAFile,
+			CertFile:   certs.ClientCertFile,
+			KeyFile:    certs.ClientKeyFile,
+			ServerName: "redis.test",
+			MinVersion: "1.3",
+		},
+	}
+	client := NewRedisClient(context.Background(), valid)
+	if err = client.Set(context.Background(), "key", "value", 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+	_ = client.Close()
+
+	tests := map[string]func(c *RedisConfig){
+		"without tls":            func(c *RedisConfig) { c.TLS = TLSConfig{} },
+		"unknown authority":      func(c *RedisConfig) { c.TLS.CAFile = "" },
+		"wrong server name":      func(c *RedisConfig) { c.TLS.ServerName = "" },
+		"without client cert":    func(c *RedisConfig) { c.TLS.CertFile, c.TLS.KeyFile = "", "" },
+		"wrong username":         func(c *RedisConfig) { c.Username = "default" },
+		"client cert as ca file": func(c *RedisConfig) { c.TLS.CAFile = certs.ClientCertFile },
+	}
+	for name, change := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := valid
+			change(&config)
+			client := newTestLazyClient(t, config)
+			defer client.Close()
+			if err := client.Ping(context.Background()).Err(); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package redclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-developer-test-task/infrastructure/storage"
+	"golang-developer-test-task/structs"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/mailru/easyjson"
+)
+
+// types of keys returned by TYPE, records are hashes unless they were kept
+// as JSON strings before migration to hashes
+const (
+	keyTypeNone   = "none"
+	keyTypeString = "string"
+	keyTypeHash   = "hash"
+	keyTypeList   = "list"
+)
+
+// recordArgs returns field-value pairs of record hash for HSET, fields are JSON names of Info,
+// all of them are written, so HSET replaces stored record
+func recordArgs(info structs.Info) []interface{} {
+	values := info.FieldValues()
+	args := make([]interface{}, 0, 2*len(values))
+	for i, name := range structs.InfoFieldNames() {
+		args = append(args, name, values[i])
+	}
+	return args
+}
+
+// findRecordScript reads record by key of it or by pointer key in one round trip.
+// KEYS[1] is the key, ARGV are "1" if it is pointer key, key prefix of records and names of fields to read,
+// all fields are read if there are none. Record of pointer is read by key built from the prefix
+// as all keys of the dataset are on one server. Reply is nil for missing record, "hash" followed
+// by field-value pairs or "string" followed by JSON of record kept as string before migration to hashes
+var findRecordScript = redis.NewScript(`
+local key = KEYS[1]
+if ARGV[1] == "1" then
+	if redis.call("TYPE", key)["ok"] ~= "string" then
+		return nil
+	end
+	key = ARGV[2] .. redis.call("GET", key)
+end
+local typ = redis.call("TYPE", key)["ok"]
+if typ == "string" then
+	return {"string", redis.call("GET", key)}
+elseif typ ~= "hash" then
+	return nil
+end
+if #ARGV == 2 then
+	local reply = redis.call("HGETALL", key)
+	table.insert(reply, 1, "hash")
+	return reply
+end
+local values = redis.call("HMGET", key, unpack(ARGV, 3))
+local reply = {"hash"}
+for i, value in ipairs(values) do
+	if value then
+		table.insert(reply, ARGV[i + 2])
+		table.insert(reply, value)
+	end
+end
+return reply
+`)
+
+// findRecord reads record by system_object_id or pointer key with findRecordScript
+func (r *RedisClient) findRecord(ctx context.Context, key string, fields []string) (info structs.Info, exists bool, err error) {
+	pointer := "0"
+	if storage.IsPointerKey(key) {
+		pointer = "1"
+	}
+	args := make([]interface{}, 0, 2+len(fields))
+	args = append(args, pointer, r.keys.prefix)
+	for _, field := range fields {
+		args = append(args, field)
+	}
+	reply, err := findRecordScript.Run(ctx, r, []string{r.keys.key(key)}, args...).Slice()
+	if err == redis.Nil {
+		return info, false, nil
+	}
+	if err != nil {
+		return info, false, err
+	}
+	return recordFromReply(reply)
+}
+
+// recordFromReply parses reply of findRecordScript
+func recordFromReply(reply []interface{}) (info structs.Info, exists bool, err error) {
+	if len(reply) == 0 {
+		return info, false, errors.New("unexpected empty reply of record")
+	}
+	kind, _ := reply[0].(string)
+	switch {
+	case kind == keyTypeString && len(reply) == 2:
+		s, _ := reply[1].(string)
+		err = easyjson.Unmarshal([]byte(s), &info)
+		return info, err == nil, err
+	case kind != keyTypeHash || len(reply)%2 != 1:
+		return info, false, fmt.Errorf("unexpected reply of record %v", reply)
+	}
+	values := make(map[string]string, len(reply)/2)
+	for i := 1; i < len(reply); i += 2 {
+		name, _ := reply[i].(string)
+		value, _ := reply[i+1].(string)
+		values[name] = value
+	}
+	return recordFromHash(values)
+}
+
+// readRecord reads record hash, only fields are read unless they are empty,
+// records kept as JSON strings before migration to hashes are read as well
+func readRecord(ctx context.Context, c redis.Cmdable, key string, fields []string) (info structs.Info, exists bool, err error) {
+	if len(fields) == 0 {
+		values, err := c.HGetAll(ctx, key).Result()
+		if isWrongType(err) {
+			return readLegacyRecord(ctx, c, key)
+		}
+		if err != nil {
+			return info, false, err
+		}
+		return recordFromHash(values)
+	}
+
+	values, err := c.HMGet(ctx, key, fields...).Result()
+	if isWrongType(err) {
+		return readLegacyRecord(ctx, c, key)
+	}
+	if err != nil {
+		return info, false, err
+	}
+	return recordFromFields(fields, values)
+}
+
+// recordFromHash parses values of HGETALL, empty hash is missing record
+func recordFromHash(values map[string]string) (info structs.Info, exists bool, err error) {
+	if len(values) == 0 {
+		return info, false, nil
+	}
+	for name, value := range values {
+		if !structs.IsInfoField(name) {
+			continue
+		}
+		if err = info.SetField(name, value); err != nil {
+			return info, false, err
+		}
+	}
+	return info, true, nil
+}
+
+// recordFromFields parses values of HMGET, record is missing if it has none of fields
+func recordFromFields(fields []string, values []interface{}) (info structs.Info, exists bool, err error) {
+	for i, v := range values {
+		value, ok := v.(string)
+		if !ok {
+			continue
+		}
+		exists = true
+		if err = info.SetField(fields[i], value); err != nil {
+			return info, false, err
+		}
+	}
+	return info, exists, nil
+}
+
+// readLegacyRecord reads record kept as JSON string, key of other type, e.g. list key, is no record
+func readLegacyRecord(ctx context.Context, c redis.Cmdable, key string) (info structs.Info, exists bool, err error) {
+	bs, err := c.Get(ctx, key).Bytes()
+	if err == redis.Nil || isWrongType(err) {
+		return info, false, nil
+	}
+	if err != nil {
+		return info, false, err
+	}
+	err = easyjson.Unmarshal(bs, &info)
+	return info, err == nil, err
+}
+
+// isWrongType reports whether err is reply to command of other type of key,
+// e.g. HGETALL of record kept as JSON string
+func isWrongType(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "WRONGTYPE")
+}
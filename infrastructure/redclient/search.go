@@ -0,0 +1,592 @@
+package redclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-developer-test-task/infrastructure/storage"
+	"golang-developer-test-task/structs"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/mailru/easyjson"
+)
+
+const (
+	searchIndex = "parkings_idx"
+	// searchRecordPrefix starts keys of record hashes, the index covers keys with it
+	searchRecordPrefix = "parking:"
+	// searchSeqKey numbers records added to lists, so lists are returned in order of adding
+	searchSeqKey = "parking_seq"
+
+	searchJSONField     = "json"
+	searchLocationField = "location"
+	// searchTagSeparator splits TAG fields into tags, records with it in tag values are refused,
+	// default comma is a part of modes and areas
+	searchTagSeparator = "\x1f"
+)
+
+// searchSchema defines fields of record hashes for FT.CREATE: fields of lookup keys and of filters,
+// every list field has seq field keeping position of record in the list
+var searchSchema = []interface{}{
+	"global_id", "NUMERIC",
+	"id", "NUMERIC",
+	"id_en", "NUMERIC",
+	"mode", "TAG", "SEPARATOR", searchTagSeparator,
+	"mode_en", "TAG", "SEPARATOR", searchTagSeparator,
+	"adm_area", "TAG", "SEPARATOR", searchTagSeparator,
+	"adm_area_en", "TAG", "SEPARATOR", searchTagSeparator,
+	"district", "TAG", "SEPARATOR", searchTagSeparator,
+	"district_en", "TAG", "SEPARATOR", searchTagSeparator,
+	"car_capacity", "NUMERIC",
+	searchLocationField, "GEO",
+	"name", "TEXT",
+	"name_en", "TEXT",
+	searchSeqFields["mode"], "NUMERIC", "SORTABLE",
+	searchSeqFields["mode_en"], "NUMERIC", "SORTABLE",
+}
+
+// searchPointerFields are fields of pointer keys, they are NUMERIC in the index and list fields are TAG
+var searchPointerFields = map[string]bool{"global_id": true, "id": true, "id_en": true}
+
+// searchSeqFields are seq fields of list fields
+var searchSeqFields = map[string]string{"mode": "seq_mode", "mode_en": "seq_mode_en"}
+
+// SearchStore keeps records as hashes indexed by RediSearch and answers lookups with FT.SEARCH.
+// Without RediSearch module it falls back to the key scheme of RedisClient, records of the key scheme
+// are not indexed, so they are uploaded again when the module appears
+type SearchStore struct {
+	*RedisClient
+
+	mu        sync.Mutex
+	detected  bool
+	available bool
+}
+
+var (
+	_ storage.Store        = (*SearchStore)(nil)
+	_ storage.FilterFinder = (*SearchStore)(nil)
+)
+
+// NewSearchStore is constructor for SearchStore, module is detected on the first use
+func NewSearchStore(client *RedisClient) *SearchStore {
+	return &SearchStore{RedisClient: client}
+}
+
+// Available reports whether RediSearch module is loaded, it creates the index once it is,
+// the answer is cached unless Redis fails to give it
+func (s *SearchStore) Available(ctx context.Context) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.detected {
+		return s.available, nil
+	}
+	err := s.Do(ctx, "FT._LIST").Err()
+	switch {
+	case err == nil:
+		if err = s.createIndex(ctx); err != nil {
+			return false, err
+		}
+		s.available = true
+	case isUnknownCommand(err):
+		s.available = false
+	default:
+		return false, err
+	}
+	s.detected = true
+	return s.available, nil
+}
+
+func isUnknownCommand(err error) bool {
+	return strings.HasPrefix(strings.ToLower(err.Error()), "err unknown command")
+}
+
+func (s *SearchStore) createIndex(ctx context.Context) error {
+	args := []interface{}{"FT.CREATE", s.keys.key(searchIndex), "ON", "HASH",
+		"PREFIX", 1, s.keys.key(searchRecordPrefix), "SCHEMA"}
+	err := s.Do(ctx, append(args, searchSchema...)...).Err()
+	if err != nil && !strings.Contains(strings.ToLower(err.Error()), "index already exists") {
+		return fmt.Errorf("creating search index: %w", err)
+	}
+	return nil
+}
+
+func (s *SearchStore) recordKey(systemObjectID string) string {
+	return s.keys.key(searchRecordPrefix + systemObjectID)
+}
+
+// recordFields returns fields of record hash except seq fields, location is the last one
+// and it is missing if coordinates of record are broken
+func recordFields(info structs.Info) ([]interface{}, error) {
+	for _, tag := range []string{info.Mode, info.ModeEn, info.AdmArea, info.AdmAreaEn, info.District, info.DistrictEn} {
+		if strings.Contains(tag, searchTagSeparator) {
+			return nil, fmt.Errorf("tag %q of record %s contains tag separator %q",
+				tag, info.SystemObjectID, searchTagSeparator)
+		}
+	}
+	bs, err := easyjson.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+	fields := []interface{}{
+		searchJSONField, bs,
+		"global_id", info.GlobalID,
+		"id", info.ID,
+		"id_en", info.IDEn,
+		"mode", info.Mode,
+		"mode_en", info.ModeEn,
+		"adm_area", info.AdmArea,
+		"adm_area_en", info.AdmAreaEn,
+		"district", info.District,
+		"district_en", info.DistrictEn,
+		"car_capacity", info.CarCapacity,
+		"name", info.Name,
+		"name_en", info.NameEn,
+	}
+	if location, ok := recordLocation(info); ok {
+		fields = append(fields, searchLocationField, location)
+	}
+	return fields, nil
+}
+
+// recordLocation returns value of GEO field, document with broken coordinates
+// is not indexed at all, so they are skipped
+func recordLocation(info structs.Info) (string, bool) {
+	lon, lonErr := strconv.ParseFloat(info.LongitudeWGS84, 64)
+	lat, latErr := strconv.ParseFloat(info.LatitudeWGS84, 64)
+	if lonErr != nil || latErr != nil || math.Abs(lon) > 180 || math.Abs(lat) > maxLatitude {
+		return "", false
+	}
+	return formatFloat(lon) + "," + formatFloat(lat), true
+}
+
+// maxLatitude is the last latitude which Redis accepts in geo indexes
+const maxLatitude = 85.05112878
+
+// searchUpsertScript saves record hashes keeping their seq fields of lists which they stay in.
+// KEYS are seq counter and keys of records, every record has its mode, mode_en, number of hash arguments
+// and hash arguments in ARGV. Location of stored record is removed unless hash arguments have it
+var searchUpsertScript = redis.NewScript(`
+local i = 1
+for k = 2, #KEYS do
+	local key = KEYS[k]
+	local nargs = tonumber(ARGV[i + 2])
+	local old = redis.call("HMGET", key, "mode", "mode_en", "seq_mode", "seq_mode_en")
+	local seq, seqEn = old[3], old[4]
+	if old[1] ~= ARGV[i] or not seq then
+		seq = redis.call("INCR", KEYS[1])
+	end
+	if old[2] ~= ARGV[i + 1] or not seqEn then
+		seqEn = redis.call("INCR", KEYS[1])
+	end
+	if ARGV[i + 1 + nargs] ~= "location" then
+		redis.call("HDEL", key, "location")
+	end
+	redis.call("HSET", key, "seq_mode", seq, "seq_mode_en", seqEn, unpack(ARGV, i + 3, i + 2 + nargs))
+	i = i + 3 + nargs
+end
+return #KEYS - 1
+`)
+
+// AddValues saves records as hashes by searchUpsertScript, records added again replace stored ones
+// and keep their places in lists which they stay in, every chunk of infos is saved atomically
+func (s *SearchStore) AddValues(ctx context.Context, infos structs.InfoList) error {
+	ok, err := s.Available(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return s.RedisClient.AddValues(ctx, infos)
+	}
+	for start := 0; start < len(infos); start += upsertChunkSize {
+		end := start + upsertChunkSize
+		if end > len(infos) {
+			end = len(infos)
+		}
+		chunk := infos[start:end]
+		keys := make([]string, 0, 1+len(chunk))
+		keys = append(keys, s.keys.key(searchSeqKey))
+		var args []interface{}
+		for _, info := range chunk {
+			fields, err := recordFields(info)
+			if err != nil {
+				return err
+			}
+			keys = append(keys, s.recordKey(info.SystemObjectID))
+			args = append(args, info.Mode, info.ModeEn, len(fields))
+			args = append(args, fields...)
+		}
+		if err = searchUpsertScript.Run(ctx, s, keys, args...).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplaceValue saves record instead of the stored one, the index drops its old fields by itself
+func (s *SearchStore) ReplaceValue(ctx context.Context, info structs.Info) error {
+	ok, err := s.Available(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return s.RedisClient.ReplaceValue(ctx, info)
+	}
+	return s.AddValues(ctx, structs.InfoList{info})
+}
+
+// DeleteValue removes record hash
+func (s *SearchStore) DeleteValue(ctx context.Context, systemObjectID string) error {
+	ok, err := s.Available(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return s.RedisClient.DeleteValue(ctx, systemObjectID)
+	}
+	n, err := s.Del(ctx, s.recordKey(systemObjectID)).Result()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// FindValues looks up record hash by system_object_id or translates lookup key into FT.SEARCH query
+func (s *SearchStore) FindValues(ctx context.Context, searchStr string, multiple bool,
+	paginationSize, offset int64) (infoList structs.InfoList, totalSize int64, err error) {
+	ok, err := s.Available(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !ok {
+		return s.RedisClient.FindValues(ctx, searchStr, multiple, paginationSize, offset)
+	}
+
+	if !multiple {
+		var info *structs.Info
+		if isSearchPointerKey(searchStr) {
+			infoList, _, err = s.search(ctx, searchStr, 0, 1)
+			if len(infoList) > 0 {
+				info = &infoList[0]
+			}
+		} else if !storage.IsPointerKey(searchStr) {
+			info, err = s.getRecord(ctx, searchStr)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		if info == nil {
+			return nil, 0, storage.ErrNotFound
+		}
+		return structs.InfoList{*info}, 1, nil
+	}
+	if field, _, _ := strings.Cut(searchStr, ":"); searchSeqFields[field] == "" {
+		// only list fields are indexed as lists
+		return nil, 0, nil
+	}
+	if paginationSize <= 0 {
+		// LIMIT 0 0 only counts records
+		offset, paginationSize = 0, 0
+	}
+	return s.search(ctx, searchStr, offset, paginationSize)
+}
+
+// FindFields reads whole records, they are kept as JSON with RediSearch
+func (s *SearchStore) FindFields(ctx context.Context, searchStr string, multiple bool,
+	paginationSize, offset int64, fields []string) (structs.InfoList, int64, error) {
+	ok, err := s.Available(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !ok {
+		return s.RedisClient.FindFields(ctx, searchStr, multiple, paginationSize, offset, fields)
+	}
+	return s.FindValues(ctx, searchStr, multiple, paginationSize, offset)
+}
+
+// FindValuesByKeys looks up records by system_object_ids or pointer keys in one pipeline
+func (s *SearchStore) FindValuesByKeys(ctx context.Context, keys []string) ([]*structs.Info, error) {
+	ok, err := s.Available(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return s.RedisClient.FindValuesByKeys(ctx, keys)
+	}
+
+	infos := make([]*structs.Info, len(keys))
+	if len(keys) == 0 {
+		return infos, nil
+	}
+	cmds := make([]redis.Cmder, len(keys))
+	_, err = s.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			switch {
+			case isSearchPointerKey(key):
+				cmds[i] = pipe.Do(ctx, s.keySearchArgs(key, 0, 1)...)
+			case !storage.IsPointerKey(key):
+				cmds[i] = pipe.HGet(ctx, s.recordKey(key), searchJSONField)
+			}
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	for i, cmd := range cmds {
+		var found structs.InfoList
+		switch cmd := cmd.(type) {
+		case *redis.StringCmd:
+			info, err := parseRecord(cmd.Result())
+			if err != nil {
+				return nil, err
+			}
+			if info != nil {
+				found = structs.InfoList{*info}
+			}
+		case *redis.Cmd:
+			reply, err := cmd.Slice()
+			if err != nil {
+				return nil, err
+			}
+			if found, _, err = parseSearchReply(reply); err != nil {
+				return nil, err
+			}
+		}
+		if len(found) > 0 {
+			infos[i] = &found[0]
+		}
+	}
+	return infos, nil
+}
+
+// isSearchPointerKey reports whether key points to one record, list keys do not
+func isSearchPointerKey(key string) bool {
+	field, _, ok := strings.Cut(key, ":")
+	return ok && searchPointerFields[field]
+}
+
+func (s *SearchStore) getRecord(ctx context.Context, systemObjectID string) (*structs.Info, error) {
+	return parseRecord(s.HGet(ctx, s.recordKey(systemObjectID), searchJSONField).Result())
+}
+
+// parseRecord parses JSON field of record hash, missing record is nil
+func parseRecord(v string, err error) (*structs.Info, error) {
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var info structs.Info
+	if err = easyjson.Unmarshal([]byte(v), &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+func (s *SearchStore) search(ctx context.Context, key string, offset, limit int64) (structs.InfoList, int64, error) {
+	return s.runSearch(ctx, s.keySearchArgs(key, offset, limit))
+}
+
+func (s *SearchStore) runSearch(ctx context.Context, args []interface{}) (structs.InfoList, int64, error) {
+	reply, err := s.Do(ctx, args...).Slice()
+	if err != nil {
+		return nil, 0, err
+	}
+	return parseSearchReply(reply)
+}
+
+// FindFiltered translates list key and filter into FT.SEARCH query, records of list are in order of adding
+// to it, records of all lists are in order of adding to mode lists. ErrFilterUnsupported is returned
+// without RediSearch module, the key scheme has no indexes of filter fields
+func (s *SearchStore) FindFiltered(ctx context.Context, listKey string, filter storage.Filter,
+	paginationSize, offset int64) (infoList structs.InfoList, totalSize int64, err error) {
+	ok, err := s.Available(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !ok {
+		return nil, 0, storage.ErrFilterUnsupported
+	}
+	query, sortBy := filterQuery(filter), searchSeqFields["mode"]
+	if listKey != "" {
+		field, _, _ := strings.Cut(listKey, ":")
+		if sortBy = searchSeqFields[field]; sortBy == "" {
+			return nil, 0, nil
+		}
+		query = strings.TrimSpace(searchQuery(listKey) + " " + query)
+	}
+	if query == "" {
+		query = "*"
+	}
+	if paginationSize <= 0 {
+		offset, paginationSize = 0, 0
+	}
+	return s.runSearch(ctx, s.searchArgs(query, sortBy, offset, paginationSize))
+}
+
+// keySearchArgs returns FT.SEARCH command looking up records of key, records of lists are in order of adding
+func (s *SearchStore) keySearchArgs(key string, offset, limit int64) []interface{} {
+	field, _, _ := strings.Cut(key, ":")
+	return s.searchArgs(searchQuery(key), searchSeqFields[field], offset, limit)
+}
+
+// searchArgs returns FT.SEARCH command running query, records are sorted by sortBy field unless it is empty
+func (s *SearchStore) searchArgs(query, sortBy string, offset, limit int64) []interface{} {
+	args := []interface{}{"FT.SEARCH", s.keys.key(searchIndex), query, "RETURN", 1, searchJSONField}
+	if sortBy != "" {
+		args = append(args, "SORTBY", sortBy, "ASC")
+	}
+	return append(args, "LIMIT", offset, limit)
+}
+
+// searchQuery translates lookup key, e.g. "global_id:42" or "mode:<mode>", into FT.SEARCH query
+func searchQuery(key string) string {
+	field, value, _ := strings.Cut(key, ":")
+	if searchPointerFields[field] {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			// numeric field never matches empty range
+			return fmt.Sprintf("@%s:[1 0]", field)
+		}
+		return fmt.Sprintf("@%s:[%d %d]", field, n, n)
+	}
+	return fmt.Sprintf("@%s:{%s}", field, escapeTag(value))
+}
+
+// filterQuery translates filter into clauses of FT.SEARCH query, it is empty for zero filter
+func filterQuery(filter storage.Filter) string {
+	var clauses []string
+	for _, tag := range []struct {
+		field string
+		value *string
+	}{
+		{"adm_area", filter.AdmArea}, {"adm_area_en", filter.AdmAreaEn},
+		{"district", filter.District}, {"district_en", filter.DistrictEn},
+	} {
+		if tag.value != nil {
+			clauses = append(clauses, fmt.Sprintf("@%s:{%s}", tag.field, escapeTag(*tag.value)))
+		}
+	}
+	if filter.CarCapacityMin != nil || filter.CarCapacityMax != nil {
+		lo, hi := "-inf", "+inf"
+		if filter.CarCapacityMin != nil {
+			lo = strconv.Itoa(*filter.CarCapacityMin)
+		}
+		if filter.CarCapacityMax != nil {
+			hi = strconv.Itoa(*filter.CarCapacityMax)
+		}
+		clauses = append(clauses, fmt.Sprintf("@car_capacity:[%s %s]", lo, hi))
+	}
+	if near := filter.Near; near != nil {
+		clauses = append(clauses, fmt.Sprintf("@%s:[%s %s %s m]", searchLocationField,
+			formatFloat(near.Longitude), formatFloat(near.Latitude), formatFloat(near.Radius)))
+	}
+	for _, text := range []struct {
+		field string
+		value *string
+	}{{"name", filter.Name}, {"name_en", filter.NameEn}} {
+		if text.value == nil {
+			continue
+		}
+		words := strings.Fields(*text.value)
+		for i, word := range words {
+			words[i] = escapeTag(word)
+		}
+		if len(words) > 0 {
+			clauses = append(clauses, fmt.Sprintf("@%s:(%s)", text.field, strings.Join(words, " ")))
+		}
+	}
+	return strings.Join(clauses, " ")
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// escapeTag escapes punctuation and spaces of TAG value or TEXT word, which are separators of query otherwise,
+// it works on bytes, so values which are not UTF-8 are kept as they are
+func escapeTag(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c < 128 && !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// parseSearchReply parses reply of FT.SEARCH with RETURN 1 json: total number
+// of found records followed by key and fields of every record of the page
+func parseSearchReply(reply []interface{}) (infoList structs.InfoList, total int64, err error) {
+	if len(reply) == 0 {
+		return nil, 0, errors.New("empty search reply")
+	}
+	total, ok := reply[0].(int64)
+	if !ok {
+		return nil, 0, fmt.Errorf("unexpected search total %v", reply[0])
+	}
+	for i := 2; i < len(reply); i += 2 {
+		fields, ok := reply[i].([]interface{})
+		if !ok {
+			return nil, 0, fmt.Errorf("unexpected search fields %v", reply[i])
+		}
+		for j := 0; j+1 < len(fields); j += 2 {
+			if fields[j] != searchJSONField {
+				continue
+			}
+			v, _ := fields[j+1].(string)
+			var info structs.Info
+			if err = easyjson.Unmarshal([]byte(v), &info); err != nil {
+				return nil, 0, err
+			}
+			infoList = append(infoList, info)
+		}
+	}
+	return infoList, total, nil
+}
+
+// Stats returns number of indexed records and dataset version
+func (s *SearchStore) Stats(ctx context.Context) (stats storage.Stats, err error) {
+	ok, err := s.Available(ctx)
+	if err != nil {
+		return stats, err
+	}
+	if !ok {
+		return s.RedisClient.Stats(ctx)
+	}
+	stats.Dataset, err = s.GetDatasetVersion(ctx)
+	if err != nil && err != storage.ErrNotFound {
+		return stats, err
+	}
+	info, err := s.Do(ctx, "FT.INFO", s.keys.key(searchIndex)).Slice()
+	if err != nil {
+		return stats, err
+	}
+	for i := 0; i+1 < len(info); i += 2 {
+		if info[i] != "num_docs" {
+			continue
+		}
+		switch v := info[i+1].(type) {
+		case int64:
+			stats.Records = v
+		case string:
+			// RediSearch returns it as string, it may be float
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return stats, err
+			}
+			stats.Records = int64(n)
+		}
+	}
+	return stats, nil
+}
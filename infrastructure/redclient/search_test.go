@@ -0,0 +1,460 @@
+package redclient
+
+import (
+	"context"
+	"golang-developer-test-task/infrastructure/storage"
+	"golang-developer-test-task/infrastructure/storage/storagetest"
+	"golang-developer-test-task/structs"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
+)
+
+// fakeSearch implements FT commands used by SearchStore on top of miniredis,
+// it understands only queries built by searchQuery and filterQuery
+type fakeSearch struct {
+	mr     *miniredis.Miniredis
+	prefix string
+}
+
+func registerFakeSearch(t *testing.T, mr *miniredis.Miniredis) {
+	t.Helper()
+	f := &fakeSearch{mr: mr}
+	for cmd, handler := range map[string]server.Cmd{
+		"FT._LIST":  f.list,
+		"FT.CREATE": f.create,
+		"FT.SEARCH": f.search,
+		"FT.INFO":   f.info,
+	} {
+		if err := mr.Server().Register(cmd, handler); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func (f *fakeSearch) list(c *server.Peer, cmd string, args []string) {
+	if f.prefix == "" {
+		c.WriteLen(0)
+		return
+	}
+	c.WriteStrings([]string{searchIndex})
+}
+
+func (f *fakeSearch) create(c *server.Peer, cmd string, args []string) {
+	if f.prefix != "" {
+		c.WriteError("Index already exists")
+		return
+	}
+	for i, arg := range args {
+		if arg == "PREFIX" && i+2 < len(args) {
+			f.prefix = args[i+2]
+		}
+	}
+	c.WriteOK()
+}
+
+// docs returns keys of indexed hashes ordered by sortBy field
+func (f *fakeSearch) docs(sortBy string) []string {
+	var keys []string
+	for _, key := range f.mr.Keys() {
+		if strings.HasPrefix(key, f.prefix) && f.mr.Type(key) == "hash" {
+			keys = append(keys, key)
+		}
+	}
+	seq := func(key string) int {
+		n, _ := strconv.Atoi(f.mr.HGet(key, sortBy))
+		return n
+	}
+	sort.Slice(keys, func(i, j int) bool { return seq(keys[i]) < seq(keys[j]) })
+	return keys
+}
+
+// matches reports whether hash of key matches every clause of query
+func (f *fakeSearch) matches(key, query string) bool {
+	for _, clause := range splitClauses(query) {
+		field, cond, _ := strings.Cut(strings.TrimPrefix(clause, "@"), ":")
+		value := f.mr.HGet(key, field)
+		inner := cond[1 : len(cond)-1]
+		var ok bool
+		switch cond[0] {
+		case '{':
+			ok = value == unescapeQuery(inner)
+		case '(':
+			words := strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+			})
+			ok = true
+			for _, word := range strings.Fields(inner) {
+				ok = ok && contains(words, strings.ToLower(unescapeQuery(word)))
+			}
+		case '[':
+			bounds := strings.Fields(inner)
+			if len(bounds) == 4 {
+				ok = withinRadius(value, bounds)
+				break
+			}
+			n, err := strconv.ParseFloat(value, 64)
+			lo, _ := strconv.ParseFloat(bounds[0], 64)
+			hi, _ := strconv.ParseFloat(bounds[1], 64)
+			ok = err == nil && n >= lo && n <= hi
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// splitClauses splits query into "@field:{...}", "@field:[...]" and "@field:(...)" clauses,
+// "*" has none of them
+func splitClauses(query string) []string {
+	var clauses []string
+	for i := 0; i < len(query); i++ {
+		if query[i] != '@' {
+			continue
+		}
+		start := i
+		for !strings.ContainsRune("{[(", rune(query[i])) {
+			i++
+		}
+		closing := map[byte]byte{'{': '}', '[': ']', '(': ')'}[query[i]]
+		for i++; query[i] != closing; i++ {
+			if query[i] == '\\' {
+				i++
+			}
+		}
+		clauses = append(clauses, query[start:i+1])
+	}
+	return clauses
+}
+
+func unescapeQuery(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// withinRadius reports whether "lon,lat" value is in circle of bounds "lon lat radius m"
+func withinRadius(value string, bounds []string) bool {
+	lonStr, latStr, ok := strings.Cut(value, ",")
+	if !ok {
+		return false
+	}
+	var coords [5]float64
+	for i, s := range []string{lonStr, latStr, bounds[0], bounds[1], bounds[2]} {
+		coords[i], _ = strconv.ParseFloat(s, 64)
+	}
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat, dLon := rad(coords[3]-coords[1]), rad(coords[2]-coords[0])
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(rad(coords[1]))*math.Cos(rad(coords[3]))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2*6372797.560856*math.Asin(math.Sqrt(h)) <= coords[4]
+}
+
+func (f *fakeSearch) search(c *server.Peer, cmd string, args []string) {
+	var found []string
+	offset, limit, sortBy := 0, 10, ""
+	for i, arg := range args {
+		if arg == "LIMIT" && i+2 < len(args) {
+			offset, _ = strconv.Atoi(args[i+1])
+			limit, _ = strconv.Atoi(args[i+2])
+		}
+		if arg == "SORTBY" && i+1 < len(args) {
+			sortBy = args[i+1]
+		}
+	}
+	for _, key := range f.docs(sortBy) {
+		if f.matches(key, args[1]) {
+			found = append(found, key)
+		}
+	}
+	page := found[:0:0]
+	for i := offset; i < len(found) && len(page) < limit; i++ {
+		page = append(page, found[i])
+	}
+	c.WriteLen(1 + 2*len(page))
+	c.WriteInt(len(found))
+	for _, key := range page {
+		c.WriteBulk(key)
+		c.WriteStrings([]string{searchJSONField, f.mr.HGet(key, searchJSONField)})
+	}
+}
+
+func (f *fakeSearch) info(c *server.Peer, cmd string, args []string) {
+	c.WriteStrings([]string{"index_name", args[0], "num_docs", strconv.Itoa(len(f.docs("")))})
+}
+
+func newTestSearchStore(t *testing.T, withModule bool) (*SearchStore, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	if withModule {
+		registerFakeSearch(t, mr)
+	}
+	client := newTestLazyClient(t, RedisConfig{Addr: mr.Addr(), PoolSize: 10})
+	t.Cleanup(func() { _ = client.Close() })
+	return NewSearchStore(client), mr
+}
+
+func TestSearchStore(t *testing.T) {
+	for _, withModule := range []bool{true, false} {
+		name := "RediSearch"
+		if !withModule {
+			name = "Fallback"
+		}
+		t.Run(name, func(t *testing.T) {
+			storagetest.Run(t, func(t *testing.T) storage.Store {
+				s, _ := newTestSearchStore(t, withModule)
+				return s
+			})
+		})
+	}
+}
+
+func TestSearchStoreAvailable(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newTestSearchStore(t, true)
+	if ok, err := s.Available(ctx); err != nil || !ok {
+		t.Fatalf("got %t and error %v but wanted module to be available", ok, err)
+	}
+	if err := s.AddValues(ctx, storagetest.Infos(1)); err != nil {
+		t.Fatal(err)
+	}
+	// records are hashes, lookup keys of fallback are not written
+	want := []string{"parking:A0", "parking_seq"}
+	if got := mr.Keys(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got keys %v but wanted %v", got, want)
+	}
+	if got := mr.HGet("parking:A0", "mode"); got != "a" {
+		t.Errorf("got mode field %q but wanted %q", got, "a")
+	}
+
+	fallback, _ := newTestSearchStore(t, false)
+	if ok, err := fallback.Available(ctx); err != nil || ok {
+		t.Errorf("got %t and error %v but wanted module to be unavailable", ok, err)
+	}
+}
+
+func TestSearchStoreAvailableErr(t *testing.T) {
+	s, mr := newTestSearchStore(t, true)
+	mr.Close()
+	if _, err := s.Available(context.Background()); err == nil {
+		t.Fatal("got no error of stopped Redis")
+	}
+	if s.detected {
+		t.Error("got failed detection cached")
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+	tests := []struct {
+		key, want string
+	}{
+		{"global_id:42", "@global_id:[42 42]"},
+		{"id_en:-1", "@id_en:[-1 -1]"},
+		{"id:x", "@id:[1 0]"},
+		{"mode:круглосуточно", "@mode:{круглосуточно}"},
+		// windows-1251 bytes are not UTF-8 and stay as they are
+		{"mode:\xea\xf0\xf3\xe3\xeb\xee", "@mode:{\xea\xf0\xf3\xe3\xeb\xee}"},
+		{"mode_en:24 hours, 7 days", `@mode_en:{24\ hours\,\ 7\ days}`},
+		{"system_object_id:a-b{c}", `@system_object_id:{a\-b\{c\}}`},
+	}
+	for _, tt := range tests {
+		if got := searchQuery(tt.key); got != tt.want {
+			t.Errorf("%s: got query %q but wanted %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestSearchSchemaSeparator(t *testing.T) {
+	for i, arg := range searchSchema {
+		if arg != "TAG" {
+			continue
+		}
+		if i+2 >= len(searchSchema) || searchSchema[i+1] != "SEPARATOR" || searchSchema[i+2] != searchTagSeparator {
+			t.Errorf("got TAG field %v without separator %q", searchSchema[i-1], searchTagSeparator)
+		}
+	}
+
+	info := structs.Info{SystemObjectID: "A0", Mode: "a" + searchTagSeparator + "b"}
+	if _, err := recordFields(info); err == nil {
+		t.Error("got no error of mode with tag separator")
+	}
+	info.Mode = "24 hours, 7 days"
+	if _, err := recordFields(info); err != nil {
+		t.Errorf("got error %v of mode with comma", err)
+	}
+}
+
+func TestParseSearchReply(t *testing.T) {
+	reply := []interface{}{int64(5),
+		"parking:A0", []interface{}{"json", `{"system_object_id":"A0","global_id":1}`},
+		"parking:B0", []interface{}{"json", `{"system_object_id":"B0","global_id":2}`},
+	}
+	infoList, total, err := parseSearchReply(reply)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 5 || len(infoList) != 2 || infoList[1].SystemObjectID != "B0" || infoList[1].GlobalID != 2 {
+		t.Errorf("got %v of total %d", infoList, total)
+	}
+
+	for _, broken := range [][]interface{}{
+		nil,
+		{"5"},
+		{int64(1), "parking:A0", "json"},
+		{int64(1), "parking:A0", []interface{}{"json", "{"}},
+	} {
+		if _, _, err = parseSearchReply(broken); err == nil {
+			t.Errorf("got no error of reply %v", broken)
+		}
+	}
+}
+
+// filterInfos are records of filter tests, C0 has broken coordinates
+func filterInfos() structs.InfoList {
+	return structs.InfoList{
+		{SystemObjectID: "A0", GlobalID: 1, Mode: "a", AdmArea: "Центральный административный округ",
+			District: "район Арбат", CarCapacity: 10, LongitudeWGS84: "37.59", LatitudeWGS84: "55.75",
+			Name: "Парковка у Арбата", NameEn: "Arbat parking"},
+		{SystemObjectID: "B0", GlobalID: 2, Mode: "a", AdmArea: "Северный административный округ",
+			District: "район Аэропорт", CarCapacity: 50, LongitudeWGS84: "37.53", LatitudeWGS84: "55.80",
+			Name: "Перехватывающая парковка", NameEn: "Park and ride"},
+		{SystemObjectID: "C0", GlobalID: 3, Mode: "b", AdmArea: "Центральный административный округ",
+			District: "Тверской район", CarCapacity: 100, Name: "Парковка Тверская", NameEn: "Tverskaya parking"},
+	}
+}
+
+func TestSearchStoreFindFiltered(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestSearchStore(t, true)
+	if err := s.AddValues(ctx, filterInfos()); err != nil {
+		t.Fatal(err)
+	}
+	str := func(s string) *string { return &s }
+	num := func(n int) *int { return &n }
+	tests := []struct {
+		name    string
+		listKey string
+		filter  storage.Filter
+		want    []string
+	}{
+		{"all", "", storage.Filter{}, []string{"A0", "B0", "C0"}},
+		{"area", "", storage.Filter{AdmArea: str("Центральный административный округ")}, []string{"A0", "C0"}},
+		{"district", "", storage.Filter{District: str("район Арбат")}, []string{"A0"}},
+		{"capacity min", "", storage.Filter{CarCapacityMin: num(20)}, []string{"B0", "C0"}},
+		{"capacity max", "", storage.Filter{CarCapacityMax: num(50)}, []string{"A0", "B0"}},
+		{"capacity range", "", storage.Filter{CarCapacityMin: num(20), CarCapacityMax: num(60)}, []string{"B0"}},
+		{"near", "", storage.Filter{Near: &storage.GeoRadius{Longitude: 37.59, Latitude: 55.75, Radius: 1000}},
+			[]string{"A0"}},
+		{"far", "", storage.Filter{Near: &storage.GeoRadius{Longitude: 37.59, Latitude: 55.75, Radius: 10000}},
+			[]string{"A0", "B0"}},
+		{"name", "", storage.Filter{Name: str("парковка")}, []string{"A0", "B0", "C0"}},
+		{"name words", "", storage.Filter{Name: str("Парковка Тверская")}, []string{"C0"}},
+		{"name_en", "", storage.Filter{NameEn: str("parking")}, []string{"A0", "C0"}},
+		{"list and area", "mode:a", storage.Filter{AdmArea: str("Центральный административный округ")}, []string{"A0"}},
+		{"list only", "mode:b", storage.Filter{}, []string{"C0"}},
+		{"not a list", "global_id:1", storage.Filter{}, nil},
+	}
+	for _, tt := range tests {
+		infoList, size, err := s.FindFiltered(ctx, tt.listKey, tt.filter, 10, 0)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var got []string
+		for _, info := range infoList {
+			got = append(got, info.SystemObjectID)
+		}
+		if size != int64(len(tt.want)) || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v of size %d but wanted %v", tt.name, got, size, tt.want)
+		}
+	}
+
+	infoList, size, err := s.FindFiltered(ctx, "", storage.Filter{NameEn: str("parking")}, 1, 1)
+	if err != nil || size != 2 || len(infoList) != 1 || infoList[0].SystemObjectID != "C0" {
+		t.Errorf("got page %v of size %d and error %v but wanted C0 of 2", infoList, size, err)
+	}
+
+	fallback, _ := newTestSearchStore(t, false)
+	if _, _, err = fallback.FindFiltered(ctx, "", storage.Filter{}, 10, 0); err != storage.ErrFilterUnsupported {
+		t.Errorf("got error %v without module but wanted %v", err, storage.ErrFilterUnsupported)
+	}
+}
+
+func TestSearchStoreLocation(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newTestSearchStore(t, true)
+	infos := filterInfos()
+	if err := s.AddValues(ctx, infos); err != nil {
+		t.Fatal(err)
+	}
+	if got := mr.HGet("parking:A0", "location"); got != "37.59,55.75" {
+		t.Errorf("got location %q but wanted %q", got, "37.59,55.75")
+	}
+	if mr.HGet("parking:C0", "location") != "" {
+		t.Error("got location of broken coordinates")
+	}
+	// replaced record with broken coordinates drops its location
+	infos[0].LatitudeWGS84 = "unknown"
+	if err := s.ReplaceValue(ctx, infos[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got := mr.HGet("parking:A0", "location"); got != "" {
+		t.Errorf("got stale location %q of replaced record", got)
+	}
+}
+
+func TestRecordLocation(t *testing.T) {
+	for _, tt := range []struct {
+		lon, lat string
+		want     string
+		ok       bool
+	}{
+		{"37.61", "55.75", "37.61,55.75", true},
+		{"-180", "-85", "-180,-85", true},
+		{"37.61", "unknown", "", false},
+		{"", "55.75", "", false},
+		{"181", "55.75", "", false},
+		{"37.61", "89", "", false},
+	} {
+		got, ok := recordLocation(structs.Info{LongitudeWGS84: tt.lon, LatitudeWGS84: tt.lat})
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s,%s: got %q, %t but wanted %q, %t", tt.lon, tt.lat, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFilterQuery(t *testing.T) {
+	area, name := "Центральный округ", "у Арбата"
+	lo, hi := 10, 20
+	tests := []struct {
+		filter storage.Filter
+		want   string
+	}{
+		{storage.Filter{}, ""},
+		{storage.Filter{AdmArea: &area}, `@adm_area:{Центральный\ округ}`},
+		{storage.Filter{DistrictEn: &area}, `@district_en:{Центральный\ округ}`},
+		{storage.Filter{CarCapacityMin: &lo}, "@car_capacity:[10 +inf]"},
+		{storage.Filter{CarCapacityMax: &hi}, "@car_capacity:[-inf 20]"},
+		{storage.Filter{CarCapacityMin: &lo, CarCapacityMax: &hi}, "@car_capacity:[10 20]"},
+		{storage.Filter{Near: &storage.GeoRadius{Longitude: 37.6, Latitude: -55.75, Radius: 500}},
+			"@location:[37.6 -55.75 500 m]"},
+		{storage.Filter{Name: &name}, "@name:(у Арбата)"},
+		{storage.Filter{AdmArea: &area, NameEn: &name}, `@adm_area:{Центральный\ округ} @name_en:(у Арбата)`},
+	}
+	for _, tt := range tests {
+		if got := filterQuery(tt.filter); got != tt.want {
+			t.Errorf("%v: got query %q but wanted %q", tt.filter, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package redclient
+
+import (
+	"golang-developer-test-task/infrastructure/storage"
+	"golang-developer-test-task/infrastructure/storage/storagetest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestStore(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Store {
+		mr := miniredis.RunT(t)
+		client := newTestLazyClient(t, RedisConfig{Addr: mr.Addr(), PoolSize: 10})
+		t.Cleanup(func() { _ = client.Close() })
+		return client
+	})
+}
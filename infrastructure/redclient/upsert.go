@@ -0,0 +1,159 @@
+package redclient
+
+import (
+	"context"
+	"golang-developer-test-task/infrastructure/storage"
+	"golang-developer-test-task/structs"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// upsertChunkSize limits number of records written by one call of upsertScript,
+// Redis serves nothing else while the script runs
+const upsertChunkSize = 100
+
+// indexFieldsLua defines functions of scripts: stored returns "1" followed by index fields of record key,
+// or "0" if it is missing, records kept as JSON strings before migration to hashes are read as well.
+// lookupKey builds pointer or list key of stored record from key prefix in ARGV[2], index is number
+// of the field in indexKeyNames. Scripts build keys of stored records themselves as all keys
+// of the dataset are on one server
+const indexFieldsLua = `
+local indexKeyNames = {"global_id:", "id:", "id_en:", "mode:", "mode_en:"}
+
+local function stored(key)
+	local typ = redis.call("TYPE", key)["ok"]
+	if typ == "hash" then
+		local v = redis.call("HMGET", key, "global_id", "ID", "ID_en", "Mode", "Mode_en")
+		return {"1", v[1] or "0", v[2] or "0", v[3] or "0", v[4] or "", v[5] or ""}
+	elseif typ == "string" then
+		local r = cjson.decode(redis.call("GET", key))
+		return {"1", string.format("%d", r["global_id"] or 0), string.format("%d", r["ID"] or 0),
+			string.format("%d", r["ID_en"] or 0), r["Mode"] or "", r["Mode_en"] or ""}
+	end
+	return {"0"}
+end
+
+local function lookupKey(fields, index)
+	return ARGV[2] .. indexKeyNames[index] .. fields[index + 1]
+end
+`
+
+// upsertScript saves records instead of stored ones with the same system_object_id.
+// KEYS are dataset key followed by 6 keys of every record: key of record, its 3 pointer keys and 2 list keys.
+// ARGV are name of records counter field, key prefix and number of record hash arguments, then every record
+// has system_object_id and hash arguments. Lookup keys of stored record which are not keys of the new one
+// are removed unless other records took them over, record keeps its place in lists which it stays in
+var upsertScript = redis.NewScript(indexFieldsLua + `
+local nargs = tonumber(ARGV[3])
+local added = 0
+local i = 4
+for k = 2, #KEYS, 6 do
+	local key, id = KEYS[k], ARGV[i]
+	local fields = stored(key)
+	local exists = fields[1] == "1"
+
+	if exists then
+		for j = 1, 3 do
+			local old = lookupKey(fields, j)
+			if old ~= KEYS[k + j] and redis.call("GET", old) == id then
+				redis.call("DEL", old)
+			end
+		end
+		if redis.call("TYPE", key)["ok"] == "string" then
+			redis.call("DEL", key)
+		end
+	else
+		added = added + 1
+	end
+	for j = 4, 5 do
+		if not exists then
+			redis.call("RPUSH", KEYS[k + j], id)
+		else
+			local old = lookupKey(fields, j)
+			if old ~= KEYS[k + j] then
+				redis.call("LREM", old, 0, id)
+				redis.call("RPUSH", KEYS[k + j], id)
+			end
+		end
+	end
+
+	redis.call("HSET", key, unpack(ARGV, i + 1, i + nargs))
+	for j = 1, 3 do
+		redis.call("SET", KEYS[k + j], id)
+	end
+	i = i + 1 + nargs
+end
+if added > 0 then
+	redis.call("HINCRBY", KEYS[1], ARGV[1], added)
+end
+return added
+`)
+
+// deleteScript removes record with its lookup keys. KEYS are dataset key and key of record, ARGV are
+// name of records counter field, key prefix and system_object_id. Script returns 0 for missing record,
+// pointer keys taken over by other records are kept
+var deleteScript = redis.NewScript(indexFieldsLua + `
+local key, id = KEYS[2], ARGV[3]
+local fields = stored(key)
+if fields[1] == "0" then
+	return 0
+end
+for j = 1, 3 do
+	local old = lookupKey(fields, j)
+	if redis.call("GET", old) == id then
+		redis.call("DEL", old)
+	end
+end
+for j = 4, 5 do
+	redis.call("LREM", lookupKey(fields, j), 0, id)
+end
+redis.call("DEL", key)
+redis.call("HINCRBY", KEYS[1], ARGV[1], -1)
+return 1
+`)
+
+// upsert saves infos instead of stored records with the same system_object_id by upsertScript,
+// every chunk of infos is saved atomically
+func (r *RedisClient) upsert(ctx context.Context, infos structs.InfoList) error {
+	for start := 0; start < len(infos); start += upsertChunkSize {
+		end := start + upsertChunkSize
+		if end > len(infos) {
+			end = len(infos)
+		}
+		keys, args := r.upsertArgs(infos[start:end])
+		if err := upsertScript.Run(ctx, r, keys, args...).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upsertArgs returns KEYS and ARGV of upsertScript saving chunk
+func (r *RedisClient) upsertArgs(chunk structs.InfoList) (keys []string, args []interface{}) {
+	nargs := 2 * len(structs.InfoFieldNames())
+	keys = make([]string, 0, 1+6*len(chunk))
+	keys = append(keys, r.keys.key(datasetKey))
+	args = make([]interface{}, 0, 3+len(chunk)*(1+nargs))
+	args = append(args, datasetRecordsField, r.keys.prefix, nargs)
+	for _, info := range chunk {
+		keys = append(keys, r.keys.key(info.SystemObjectID))
+		keys = append(keys, r.keys.keys(storage.PointerKeys(info))...)
+		keys = append(keys, r.keys.keys(storage.ListKeys(info))...)
+		args = append(args, info.SystemObjectID)
+		args = append(args, recordArgs(info)...)
+	}
+	return keys, args
+}
+
+// remove removes record with its lookup keys by deleteScript, storage.ErrNotFound if it does not exist
+func (r *RedisClient) remove(ctx context.Context, systemObjectID string) error {
+	n, err := deleteScript.Run(ctx, r, []string{r.keys.key(datasetKey), r.keys.key(systemObjectID)},
+		datasetRecordsField, r.keys.prefix, systemObjectID).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package redclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-developer-test-task/infrastructure/storage"
+	"golang-developer-test-task/infrastructure/storage/storagetest"
+	"golang-developer-test-task/structs"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestLoadScripts(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := newTestLazyClient(t, RedisConfig{Addr: mr.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+	if err := client.LoadScripts(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, script := range scripts {
+		exists, err := client.ScriptExists(ctx, script.Hash()).Result()
+		if err != nil || !exists[0] {
+			t.Errorf("got script %s loaded %v and error %v", script.Hash(), exists, err)
+		}
+	}
+}
+
+func TestAddValuesUpsert(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := newTestLazyClient(t, RedisConfig{Addr: mr.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+	prefix := client.keys.key("")
+	infos := storagetest.Infos(3)
+	if err := client.AddValues(ctx, infos); err != nil {
+		t.Fatal(err)
+	}
+
+	// the same record twice in one batch is one record
+	changed := infos[0]
+	changed.GlobalID = 5000
+	changed.Mode = "b"
+	if err := client.AddValues(ctx, structs.InfoList{changed, changed, infos[1]}); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists(prefix + "global_id:1000") {
+		t.Error("got stale pointer of changed record")
+	}
+	if got, _ := mr.Get(prefix + "global_id:5000"); got != "A0" {
+		t.Errorf("got pointer to %q but wanted A0", got)
+	}
+	for key, want := range map[string][]string{
+		"mode:a":       {"C0"},
+		"mode:b":       {"B0", "A0"},
+		"mode_en:a_en": {"A0", "C0"},
+	} {
+		if got, _ := mr.List(prefix + key); !equalStrings(got, want) {
+			t.Errorf("%s: got list %v but wanted %v", key, got, want)
+		}
+	}
+	if got := mr.HGet(prefix+datasetKey, datasetRecordsField); got != "3" {
+		t.Errorf("got %s records but wanted 3", got)
+	}
+}
+
+func TestAddValuesChunks(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := newTestLazyClient(t, RedisConfig{Addr: mr.Addr()})
+	defer client.Close()
+	n := 2*upsertChunkSize + 1
+	if err := client.AddValues(context.Background(), storagetest.Infos(n)); err != nil {
+		t.Fatal(err)
+	}
+	if got := mr.HGet(datasetKey, datasetRecordsField); got != strconv.Itoa(n) {
+		t.Errorf("got %s records but wanted %d", got, n)
+	}
+}
+
+func TestAddValuesLegacyRecord(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := newTestLazyClient(t, RedisConfig{Addr: mr.Addr()})
+	defer client.Close()
+	setLegacyRecords(t, mr, "", 2)
+	changed := storagetest.Infos(1)[0]
+	changed.GlobalID = 5000
+	if err := client.AddValues(context.Background(), structs.InfoList{changed}); err != nil {
+		t.Fatal(err)
+	}
+	if typ := mr.Type("A0"); typ != "hash" {
+		t.Errorf("got type %s of replaced legacy record", typ)
+	}
+	if mr.Exists("global_id:1000") {
+		t.Error("got stale pointer of legacy record")
+	}
+	if got, _ := mr.List("mode:a"); !equalStrings(got, []string{"A0"}) {
+		t.Errorf("got list %v of legacy record", got)
+	}
+}
+
+// TestAddValuesConcurrent checks that concurrent writes of the same records are not given up
+// and leave every record once in its lists
+func TestAddValuesConcurrent(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := newTestLazyClient(t, RedisConfig{Addr: mr.Addr(), PoolSize: 10})
+	defer client.Close()
+	infos := storagetest.Infos(20)
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- client.AddValues(context.Background(), infos)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := mr.HGet(datasetKey, datasetRecordsField); got != "20" {
+		t.Errorf("got %s records but wanted 20", got)
+	}
+	if got, _ := mr.List("mode:a"); len(got) != 10 {
+		t.Errorf("got list %v of 10 records", got)
+	}
+}
+
+func TestDeleteValueLegacyRecord(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := newTestLazyClient(t, RedisConfig{Addr: mr.Addr()})
+	defer client.Close()
+	setLegacyRecords(t, mr, "", 2)
+	if err := client.DeleteValue(context.Background(), "A0"); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists("A0") || mr.Exists("global_id:1000") {
+		t.Error("got legacy record or its pointer after deleting")
+	}
+	if got, _ := mr.List("mode:a"); len(got) != 0 {
+		t.Errorf("got list %v of deleted legacy record", got)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// addValuesWatching is AddValues done by optimistic transaction watching keys of all records,
+// as it was before upsertScript, it is kept to compare them by benchmarks
+func (r *RedisClient) addValuesWatching(ctx context.Context, infos structs.InfoList) (err error) {
+	keys := make([]string, 0, 6*len(infos))
+	for _, info := range infos {
+		keys = append(keys, r.keys.key(info.SystemObjectID))
+		keys = append(keys, r.keys.keys(storage.PointerKeys(info))...)
+		keys = append(keys, r.keys.keys(storage.ListKeys(info))...)
+	}
+	txf := func(tx *redis.Tx) error {
+		var added int64
+		for _, info := range infos {
+			n, err := tx.Exists(ctx, r.keys.key(info.SystemObjectID)).Result()
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				added++
+			}
+		}
+		_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if added > 0 {
+				pipe.HIncrBy(ctx, r.keys.key(datasetKey), datasetRecordsField, added)
+			}
+			for _, info := range infos {
+				pipe.HSet(ctx, r.keys.key(info.SystemObjectID), recordArgs(info)...)
+				for _, key := range r.keys.keys(storage.PointerKeys(info)) {
+					pipe.Set(ctx, key, info.SystemObjectID, 0)
+				}
+				for _, key := range r.keys.keys(storage.ListKeys(info)) {
+					pipe.RPush(ctx, key, info.SystemObjectID)
+				}
+			}
+			return nil
+		})
+		return err
+	}
+	for i := 0; i < r.MaxRetries; i++ {
+		err = r.Watch(ctx, txf, keys...)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+	return err
+}
+
+// benchmarkAddValues runs add against miniredis or Redis at REDIS_BENCH_ADDR, Lua of miniredis is
+// interpreted by Go, so only the latter compares scripts with transactions fairly.
+// Benchmark keys are written under key prefix of their own and removed afterwards, other keys are not touched
+func benchmarkAddValues(b *testing.B, parallel bool,
+	add func(r *RedisClient, ctx context.Context, infos structs.InfoList) error) {
+	addr := os.Getenv("REDIS_BENCH_ADDR")
+	if addr == "" {
+		addr = miniredis.RunT(b).Addr()
+	}
+	prefix := fmt.Sprintf("bench%d", time.Now().UnixNano())
+	client := newTestLazyClient(b, RedisConfig{Addr: addr, PoolSize: 10, KeyPrefix: prefix})
+	defer client.Close()
+	ctx := context.Background()
+	defer func() {
+		iter := client.Scan(ctx, 0, prefix+":*", 1000).Iterator()
+		for iter.Next(ctx) {
+			client.Del(ctx, iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			b.Error(err)
+		}
+	}()
+	if err := client.LoadScripts(ctx); err != nil {
+		b.Fatal(err)
+	}
+	infos := storagetest.Infos(100)
+	var failed int64
+	var mu sync.Mutex
+	b.ResetTimer()
+	if !parallel {
+		for i := 0; i < b.N; i++ {
+			if err := add(client, ctx, infos); err != nil {
+				b.Fatal(err)
+			}
+		}
+		return
+	}
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			// optimistic transactions give up under contention, failures are counted instead of stopping
+			if err := add(client, ctx, infos); err != nil {
+				mu.Lock()
+				failed++
+				mu.Unlock()
+			}
+		}
+	})
+	b.ReportMetric(float64(failed)/float64(b.N), "failed/op")
+}
+
+func BenchmarkAddValues(b *testing.B) {
+	for _, parallel := range []bool{false, true} {
+		name := "Serial"
+		if parallel {
+			name = "Parallel"
+		}
+		b.Run(name+"/Script", func(b *testing.B) {
+			benchmarkAddValues(b, parallel, (*RedisClient).AddValues)
+		})
+		b.Run(name+"/Watch", func(b *testing.B) {
+			benchmarkAddValues(b, parallel, (*RedisClient).addValuesWatching)
+		})
+	}
+}
@@ -2,178 +2,226 @@ package redclient
 
 import (
 	"context"
-	"errors"
-	"fmt"
+	"golang-developer-test-task/infrastructure/storage"
 	"golang-developer-test-task/structs"
-	"strings"
-
-	jsoniter "github.com/json-iterator/go"
-	"github.com/mailru/easyjson"
 
 	"github.com/go-redis/redis/v8"
 )
 
 // AddValue add info to Redis storage
-func (r *RedisClient) AddValue(ctx context.Context, info structs.Info) (err error) {
-	bs, _ := jsoniter.Marshal(info)
-
-	globalID := fmt.Sprintf("global_id:%d", info.GlobalID)
-	id := fmt.Sprintf("id:%d", info.ID)
-	idEn := fmt.Sprintf("id_en:%d", info.IDEn)
-	mode := fmt.Sprintf("mode:%s", info.Mode)
-	modeEn := fmt.Sprintf("mode_en:%s", info.ModeEn)
-
-	txf := func(tx *redis.Tx) error {
-		err := tx.Get(ctx, info.SystemObjectID).Err()
-		if err != nil && err != redis.Nil {
-			return err
-		}
+func (r *RedisClient) AddValue(ctx context.Context, info structs.Info) error {
+	return r.upsert(ctx, structs.InfoList{info})
+}
+
+// AddValues add infos to Redis storage, stored records with the same system_object_id are replaced
+func (r *RedisClient) AddValues(ctx context.Context, infos structs.InfoList) error {
+	return r.upsert(ctx, infos)
+}
 
-		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
-			pipe.Set(ctx, info.SystemObjectID, bs, 0)
-			pipe.Set(ctx, globalID, info.SystemObjectID, 0)
-			pipe.Set(ctx, id, info.SystemObjectID, 0)
-			pipe.Set(ctx, idEn, info.SystemObjectID, 0)
-			pipe.RPush(ctx, mode, info.SystemObjectID)
-			pipe.RPush(ctx, modeEn, info.SystemObjectID)
-			return nil
-		})
-		return err
-	}
-
-	for i := 0; i < r.MaxRetries; i++ {
-		err = r.Watch(ctx, txf, info.SystemObjectID, globalID, id, idEn, mode, modeEn)
-		if !errors.Is(err, redis.TxFailedErr) {
-			// if err != redis.TxFailedErr {
-			// fmt.Printf("%v ahaha", err)
-			return err
+// FindValues is a method for searching values by searchStr, missing record is storage.ErrNotFound
+func (r *RedisClient) FindValues(ctx context.Context, searchStr string, multiple bool, paginationSize, offset int64) (infoList structs.InfoList, totalSize int64, err error) {
+	return r.FindFields(ctx, searchStr, multiple, paginationSize, offset, nil)
+}
+
+// FindFields is FindValues reading only fields of records with HMGET, all of them if fields are empty.
+// Single record is read in one round trip, page of list in two ones
+func (r *RedisClient) FindFields(ctx context.Context, searchStr string, multiple bool, paginationSize, offset int64,
+	fields []string) (infoList structs.InfoList, totalSize int64, err error) {
+	if !multiple {
+		info, exists, err := r.findRecord(ctx, searchStr, fields)
+		if err != nil {
+			return infoList, 1, err
+		}
+		if !exists {
+			return infoList, 0, storage.ErrNotFound
 		}
+		infoList = append(infoList, info)
+		return infoList, 1, nil
 	}
-	return err
-}
 
-// AddValues add infos to Redis storage
-func (r *RedisClient) AddValues(ctx context.Context, infos structs.InfoList) (err error) {
-	if len(infos) == 0 {
-		return
-	}
-	size := len(infos)
-	bss := make([][]byte, size)
-	systemIDs := make([]string, size)
-	globalIDs := make([]string, size)
-	ids := make([]string, size)
-	idEns := make([]string, size)
-	modes := make([]string, size)
-	modeEns := make([]string, size)
-	keys := make([]string, 0, size*6)
-
-	for i := range bss {
-		bss[i], _ = jsoniter.Marshal(infos[i])
-		globalID := fmt.Sprintf("global_id:%d", infos[i].GlobalID)
-		id := fmt.Sprintf("id:%d", infos[i].ID)
-		idEn := fmt.Sprintf("id_en:%d", infos[i].IDEn)
-		mode := fmt.Sprintf("mode:%s", infos[i].Mode)
-		modeEn := fmt.Sprintf("mode_en:%s", infos[i].ModeEn)
-		systemIDs[i] = infos[i].SystemObjectID
-		globalIDs[i] = globalID
-		ids[i] = id
-		idEns[i] = idEn
-		modes[i] = mode
-		modeEns[i] = modeEn
-		keys = append(keys, systemIDs[i])
-		keys = append(keys, globalID)
-		keys = append(keys, id)
-		keys = append(keys, idEn)
-		keys = append(keys, mode)
-		keys = append(keys, modeEn)
-	}
-
-	txf := func(tx *redis.Tx) error {
-		for _, systemID := range systemIDs {
-			err := tx.Get(ctx, systemID).Err()
-			if err != nil && err != redis.Nil {
-				return err
-			}
+	listKey := r.keys.key(searchStr)
+	if paginationSize <= 0 {
+		size, err := r.LLen(ctx, listKey).Result()
+		if err != nil {
+			return infoList, 0, notFound(err)
 		}
+		return infoList, size, nil
+	}
 
-		_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
-			for i := range bss {
-				pipe.Set(ctx, systemIDs[i], bss[i], 0)
-				pipe.Set(ctx, globalIDs[i], systemIDs[i], 0)
-				pipe.Set(ctx, ids[i], systemIDs[i], 0)
-				pipe.Set(ctx, idEns[i], systemIDs[i], 0)
-				pipe.RPush(ctx, modes[i], systemIDs[i])
-				pipe.RPush(ctx, modeEns[i], systemIDs[i])
-			}
-			return nil
-		})
-		return err
+	var sizeCmd *redis.IntCmd
+	var pageCmd *redis.StringSliceCmd
+	// errors are checked by commands
+	_, _ = r.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		sizeCmd = pipe.LLen(ctx, listKey)
+		// LRANGE includes both bounds
+		pageCmd = pipe.LRange(ctx, listKey, offset, offset+paginationSize-1)
+		return nil
+	})
+	size, err := sizeCmd.Result()
+	if err != nil {
+		return infoList, 0, notFound(err)
+	}
+	ids, err := pageCmd.Result()
+	if err != nil {
+		return infoList, size, err
 	}
 
-	for i := 0; i < r.MaxRetries; i++ {
-		err = r.Watch(ctx, txf, keys...)
-		if !errors.Is(err, redis.TxFailedErr) {
-			// if err != redis.TxFailedErr {
-			// fmt.Printf("%v ahaha", err)
-			return err
+	records, err := r.readRecords(ctx, r.keys.keys(ids), fields)
+	if err != nil {
+		return infoList, size, err
+	}
+	for _, record := range records {
+		// list may outlive record removed by hand
+		if record != nil {
+			infoList = append(infoList, *record)
 		}
 	}
-	return err
+	return infoList, size, nil
 }
 
-// FindValues is a method for searching values by searchStr
-func (r *RedisClient) FindValues(ctx context.Context, searchStr string, multiple bool, paginationSize, offset int64) (infoList structs.InfoList, totalSize int64, err error) {
-	if !multiple {
-		v, err := r.Get(ctx, searchStr).Result()
-		if err != nil {
-			return infoList, 0, err
-		}
-		if strings.Contains(searchStr, ":") {
-			v, err = r.Get(ctx, v).Result()
-			if err != nil {
-				return infoList, 0, err
-			}
-		}
-		var info structs.Info
-		err = jsoniter.Unmarshal([]byte(v), &info)
-		if err != nil {
-			return infoList, 1, err
+// mgetChunkSize limits number of keys inside one MGET command
+const mgetChunkSize = 500
+
+// FindValuesByKeys looks up records by primary or index keys with pipelined MGETs.
+// Result is aligned with keys and contains nil for records which were not found
+func (r *RedisClient) FindValuesByKeys(ctx context.Context, keys []string) ([]*structs.Info, error) {
+	systemIDs := make([]string, len(keys))
+	var pointers []string
+	for i, key := range keys {
+		if storage.IsPointerKey(key) {
+			pointers = append(pointers, key)
+		} else {
+			systemIDs[i] = key
 		}
-		infoList = append(infoList, info)
-		return infoList, 1, nil
 	}
 
-	size, err := r.LLen(ctx, searchStr).Result()
+	resolved, err := r.mget(ctx, r.keys.keys(pointers))
 	if err != nil {
-		return infoList, 0, err
+		return nil, err
+	}
+	j := 0
+	for i, key := range keys {
+		if storage.IsPointerKey(key) {
+			systemIDs[i] = resolved[j]
+			j++
+		}
 	}
 
-	if paginationSize <= 0 {
-		return infoList, size, nil
+	found := make([]string, 0, len(systemIDs))
+	for _, systemID := range systemIDs {
+		if systemID != "" {
+			found = append(found, systemID)
+		}
 	}
-	start := offset
-	end := offset + paginationSize
-	if start > size {
-		return infoList, size, nil
+	records, err := r.readRecords(ctx, r.keys.keys(found), nil)
+	if err != nil {
+		return nil, err
 	}
 
-	var vs []string
-	vs, err = r.LRange(ctx, searchStr, start, end).Result()
-	if err != nil {
-		return infoList, size, err
+	infos := make([]*structs.Info, len(keys))
+	j = 0
+	for i, systemID := range systemIDs {
+		if systemID == "" {
+			continue
+		}
+		infos[i] = records[j]
+		j++
 	}
+	return infos, nil
+}
 
-	for _, v := range vs {
+// readRecords reads record hashes with pipelined HGETALLs, or HMGETs of fields unless they are empty,
+// missing records are nil
+func (r *RedisClient) readRecords(ctx context.Context, keys []string, fields []string) ([]*structs.Info, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	cmds := make([]redis.Cmder, len(keys))
+	// errors are checked by commands, as records kept as JSON strings fail with WRONGTYPE
+	_, _ = r.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			if len(fields) == 0 {
+				cmds[i] = pipe.HGetAll(ctx, key)
+			} else {
+				cmds[i] = pipe.HMGet(ctx, key, fields...)
+			}
+		}
+		return nil
+	})
+	records := make([]*structs.Info, len(keys))
+	for i, cmd := range cmds {
 		var info structs.Info
-		vv, err := r.Get(ctx, v).Result()
-		if err != nil {
-			return infoList, size, err
+		var exists bool
+		var err error
+		switch cmd := cmd.(type) {
+		case *redis.StringStringMapCmd:
+			var values map[string]string
+			if values, err = cmd.Result(); err == nil {
+				info, exists, err = recordFromHash(values)
+			}
+		case *redis.SliceCmd:
+			var values []interface{}
+			if values, err = cmd.Result(); err == nil {
+				info, exists, err = recordFromFields(fields, values)
+			}
+		}
+		if isWrongType(err) {
+			info, exists, err = readLegacyRecord(ctx, r, keys[i])
 		}
-		err = easyjson.Unmarshal([]byte(vv), &info)
 		if err != nil {
-			return infoList, size, err
+			return nil, err
+		}
+		if exists {
+			records[i] = &info
 		}
-		infoList = append(infoList, info)
 	}
-	return infoList, size, nil
+	return records, nil
+}
+
+// mget gets string values of keys with pipelined MGETs, missing values are empty strings
+func (r *RedisClient) mget(ctx context.Context, keys []string) ([]string, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	cmds := make([]*redis.SliceCmd, 0, (len(keys)+mgetChunkSize-1)/mgetChunkSize)
+	_, err := r.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for start := 0; start < len(keys); start += mgetChunkSize {
+			end := start + mgetChunkSize
+			if end > len(keys) {
+				end = len(keys)
+			}
+			cmds = append(cmds, pipe.MGet(ctx, keys[start:end]...))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	values := make([]string, 0, len(keys))
+	for _, cmd := range cmds {
+		for _, v := range cmd.Val() {
+			s, _ := v.(string)
+			values = append(values, s)
+		}
+	}
+	return values, nil
+}
+
+// notFound translates redis.Nil of missing keys to storage.ErrNotFound
+func notFound(err error) error {
+	if err == redis.Nil {
+		return storage.ErrNotFound
+	}
+	return err
+}
+
+// ReplaceValue saves info instead of the stored record with the same system_object_id,
+// lookup keys of the old record are removed unless info has them or other records took them over
+func (r *RedisClient) ReplaceValue(ctx context.Context, info structs.Info) error {
+	return r.upsert(ctx, structs.InfoList{info})
+}
+
+// DeleteValue removes record with its lookup keys, storage.ErrNotFound if it does not exist
+func (r *RedisClient) DeleteValue(ctx context.Context, systemObjectID string) error {
+	return r.remove(ctx, systemObjectID)
 }
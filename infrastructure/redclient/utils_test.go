@@ -2,15 +2,24 @@ package redclient
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"golang-developer-test-task/infrastructure/storage"
+	"golang-developer-test-task/infrastructure/storage/storagetest"
 	"golang-developer-test-task/structs"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
-	"github.com/mailru/easyjson"
+	"github.com/alicebob/miniredis/v2"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/go-redis/redismock/v8"
+	"github.com/mailru/easyjson"
 )
 
 func TestAddValue(t *testing.T) {
@@ -23,27 +32,10 @@ func TestAddValue(t *testing.T) {
 		ModeEn:         "cba",
 	}
 
-	globalID := fmt.Sprintf("global_id:%d", info.GlobalID)
-	id := fmt.Sprintf("id:%d", info.ID)
-	idEn := fmt.Sprintf("id_en:%d", info.IDEn)
-	mode := fmt.Sprintf("mode:%s", info.Mode)
-	modeEn := fmt.Sprintf("mode_en:%s", info.ModeEn)
-
-	bs, _ := easyjson.Marshal(info)
-
 	db, mock := redismock.NewClientMock()
-	mock.ExpectWatch(info.SystemObjectID, globalID, id, idEn, mode, modeEn)
-	mock.ExpectGet(info.SystemObjectID).SetVal("")
-	mock.ExpectTxPipeline()
-	mock.ExpectSet(info.SystemObjectID, bs, 0).SetVal("OK")
-	mock.ExpectSet(globalID, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(id, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(idEn, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectRPush(mode, info.SystemObjectID).SetVal(0)
-	mock.ExpectRPush(modeEn, info.SystemObjectID).SetVal(0)
-	mock.ExpectTxPipelineExec()
-
-	client := &RedisClient{*db, 10}
+	expectUpsert(mock, info).SetVal(int64(1))
+
+	client := &RedisClient{UniversalClient: db, MaxRetries: 10}
 	err := client.AddValue(context.Background(), info)
 
 	if err != nil {
@@ -51,7 +43,7 @@ func TestAddValue(t *testing.T) {
 	}
 }
 
-func TestAddValueGetErrInsideWatch(t *testing.T) {
+func TestAddValueErr(t *testing.T) {
 	info := structs.Info{
 		GlobalID:       42,
 		SystemObjectID: "777",
@@ -61,19 +53,13 @@ func TestAddValueGetErrInsideWatch(t *testing.T) {
 		ModeEn:         "cba",
 	}
 
-	globalID := fmt.Sprintf("global_id:%d", info.GlobalID)
-	id := fmt.Sprintf("id:%d", info.ID)
-	idEn := fmt.Sprintf("id_en:%d", info.IDEn)
-	mode := fmt.Sprintf("mode:%s", info.Mode)
-	modeEn := fmt.Sprintf("mode_en:%s", info.ModeEn)
-
 	db, mock := redismock.NewClientMock()
-	mock.ExpectWatch(info.SystemObjectID, globalID, id, idEn, mode, modeEn).SetErr(redis.Nil)
+	expectUpsert(mock, info).SetErr(redis.ErrClosed)
 
-	client := &RedisClient{*db, 10}
+	client := &RedisClient{UniversalClient: db, MaxRetries: 10}
 	err := client.AddValue(context.Background(), info)
 
-	if err != redis.Nil {
+	if err != redis.ErrClosed {
 		t.Fatal(err)
 	}
 }
@@ -81,11 +67,11 @@ func TestAddValueGetErrInsideWatch(t *testing.T) {
 func TestFindValuesNotFoundSingle(t *testing.T) {
 	db, mock := redismock.NewClientMock()
 	key := "42"
-	mock.ExpectGet(key).SetErr(redis.Nil)
-	client := &RedisClient{*db, 10}
+	expectFindRecord(mock, key).SetErr(redis.Nil)
+	client := &RedisClient{UniversalClient: db, MaxRetries: 10}
 	_, _, err := client.FindValues(context.Background(), key, false, 5, 0)
 
-	if err != redis.Nil {
+	if err != storage.ErrNotFound {
 		t.Fatal(err)
 	}
 }
@@ -94,10 +80,10 @@ func TestFindValuesNotFoundMultiple(t *testing.T) {
 	db, mock := redismock.NewClientMock()
 	key := "42"
 	mock.ExpectLLen(key).SetErr(redis.Nil)
-	client := &RedisClient{*db, 10}
+	client := &RedisClient{UniversalClient: db, MaxRetries: 10}
 	_, _, err := client.FindValues(context.Background(), key, true, 5, 0)
 
-	if err != redis.Nil {
+	if err != storage.ErrNotFound {
 		t.Fatal(err)
 	}
 }
@@ -112,29 +98,12 @@ func TestFindValuesSingle(t *testing.T) {
 		ModeEn:         "cba",
 	}
 
-	globalID := fmt.Sprintf("global_id:%d", info.GlobalID)
-	id := fmt.Sprintf("id:%d", info.ID)
-	idEn := fmt.Sprintf("id_en:%d", info.IDEn)
-	mode := fmt.Sprintf("mode:%s", info.Mode)
-	modeEn := fmt.Sprintf("mode_en:%s", info.ModeEn)
-
-	bs, _ := easyjson.Marshal(info)
-
 	db, mock := redismock.NewClientMock()
-	mock.ExpectWatch(info.SystemObjectID, globalID, id, idEn, mode, modeEn)
-	mock.ExpectGet(info.SystemObjectID).SetVal("")
-	mock.ExpectTxPipeline()
-	mock.ExpectSet(info.SystemObjectID, bs, 0).SetVal("OK")
-	mock.ExpectSet(globalID, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(id, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(idEn, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectRPush(mode, info.SystemObjectID).SetVal(0)
-	mock.ExpectRPush(modeEn, info.SystemObjectID).SetVal(0)
-	mock.ExpectTxPipelineExec()
+	expectUpsert(mock, info).SetVal(int64(1))
 
 	key := info.SystemObjectID
-	mock.ExpectGet(key).SetVal(string(bs))
-	client := &RedisClient{*db, 10}
+	expectFindRecord(mock, key).SetVal(recordReply(info))
+	client := &RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	err := client.AddValue(context.Background(), info)
 	if err != nil {
@@ -166,30 +135,13 @@ func TestFindValuesSingleIdEn(t *testing.T) {
 		ModeEn:         "cba",
 	}
 
-	globalID := fmt.Sprintf("global_id:%d", info.GlobalID)
-	id := fmt.Sprintf("id:%d", info.ID)
 	idEn := fmt.Sprintf("id_en:%d", info.IDEn)
-	mode := fmt.Sprintf("mode:%s", info.Mode)
-	modeEn := fmt.Sprintf("mode_en:%s", info.ModeEn)
-
-	bs, _ := easyjson.Marshal(info)
 
 	db, mock := redismock.NewClientMock()
-	mock.ExpectWatch(info.SystemObjectID, globalID, id, idEn, mode, modeEn)
-	mock.ExpectGet(info.SystemObjectID).SetVal("")
-	mock.ExpectTxPipeline()
-	mock.ExpectSet(info.SystemObjectID, bs, 0).SetVal("OK")
-	mock.ExpectSet(globalID, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(id, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(idEn, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectRPush(mode, info.SystemObjectID).SetVal(0)
-	mock.ExpectRPush(modeEn, info.SystemObjectID).SetVal(0)
-	mock.ExpectTxPipelineExec()
+	expectUpsert(mock, info).SetVal(int64(1))
 
-	key := info.SystemObjectID
-	mock.ExpectGet(idEn).SetVal(key)
-	mock.ExpectGet(key).SetVal(string(bs))
-	client := &RedisClient{*db, 10}
+	expectFindRecord(mock, idEn).SetVal(recordReply(info))
+	client := &RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	err := client.AddValue(context.Background(), info)
 	if err != nil {
@@ -211,7 +163,7 @@ func TestFindValuesSingleIdEn(t *testing.T) {
 	}
 }
 
-func TestFindValuesSingleIdEnSecondGetErr(t *testing.T) {
+func TestFindValuesSingleIdEnReplyErr(t *testing.T) {
 	info := structs.Info{
 		GlobalID:       42,
 		SystemObjectID: "777",
@@ -221,29 +173,13 @@ func TestFindValuesSingleIdEnSecondGetErr(t *testing.T) {
 		ModeEn:         "cba",
 	}
 
-	globalID := fmt.Sprintf("global_id:%d", info.GlobalID)
-	id := fmt.Sprintf("id:%d", info.ID)
 	idEn := fmt.Sprintf("id_en:%d", info.IDEn)
-	mode := fmt.Sprintf("mode:%s", info.Mode)
-	modeEn := fmt.Sprintf("mode_en:%s", info.ModeEn)
-
-	bs, _ := easyjson.Marshal(info)
 
 	db, mock := redismock.NewClientMock()
-	mock.ExpectWatch(info.SystemObjectID, globalID, id, idEn, mode, modeEn)
-	mock.ExpectGet(info.SystemObjectID).SetVal("")
-	mock.ExpectTxPipeline()
-	mock.ExpectSet(info.SystemObjectID, bs, 0).SetVal("OK")
-	mock.ExpectSet(globalID, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(id, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(idEn, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectRPush(mode, info.SystemObjectID).SetVal(0)
-	mock.ExpectRPush(modeEn, info.SystemObjectID).SetVal(0)
-	mock.ExpectTxPipelineExec()
+	expectUpsert(mock, info).SetVal(int64(1))
 
-	key := info.SystemObjectID
-	mock.ExpectGet(idEn).SetVal(key)
-	client := &RedisClient{*db, 10}
+	expectFindRecord(mock, idEn).SetVal([]interface{}{"hash", "global_id"})
+	client := &RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	err := client.AddValue(context.Background(), info)
 	if err != nil {
@@ -256,7 +192,7 @@ func TestFindValuesSingleIdEnSecondGetErr(t *testing.T) {
 	}
 }
 
-func TestFindValuesSingleIdEnFirstGetErr(t *testing.T) {
+func TestFindValuesSingleIdEnErr(t *testing.T) {
 	info := structs.Info{
 		GlobalID:       42,
 		SystemObjectID: "777",
@@ -266,27 +202,12 @@ func TestFindValuesSingleIdEnFirstGetErr(t *testing.T) {
 		ModeEn:         "cba",
 	}
 
-	globalID := fmt.Sprintf("global_id:%d", info.GlobalID)
-	id := fmt.Sprintf("id:%d", info.ID)
 	idEn := fmt.Sprintf("id_en:%d", info.IDEn)
-	mode := fmt.Sprintf("mode:%s", info.Mode)
-	modeEn := fmt.Sprintf("mode_en:%s", info.ModeEn)
-
-	bs, _ := easyjson.Marshal(info)
 
 	db, mock := redismock.NewClientMock()
-	mock.ExpectWatch(info.SystemObjectID, globalID, id, idEn, mode, modeEn)
-	mock.ExpectGet(info.SystemObjectID).SetVal("")
-	mock.ExpectTxPipeline()
-	mock.ExpectSet(info.SystemObjectID, bs, 0).SetVal("OK")
-	mock.ExpectSet(globalID, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(id, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(idEn, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectRPush(mode, info.SystemObjectID).SetVal(0)
-	mock.ExpectRPush(modeEn, info.SystemObjectID).SetVal(0)
-	mock.ExpectTxPipelineExec()
-
-	client := &RedisClient{*db, 10}
+	expectUpsert(mock, info).SetVal(int64(1))
+
+	client := &RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	err := client.AddValue(context.Background(), info)
 	if err != nil {
@@ -302,12 +223,12 @@ func TestFindValuesSingleIdEnFirstGetErr(t *testing.T) {
 func TestFindValuesSingleNothing(t *testing.T) {
 	db, mock := redismock.NewClientMock()
 	key := "777"
-	mock.ExpectGet(key).SetErr(redis.Nil)
-	client := &RedisClient{*db, 10}
+	expectFindRecord(mock, key).SetErr(redis.Nil)
+	client := &RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	infoList, totalSize, err := client.FindValues(context.Background(), key, false, 0, 0)
 
-	if err != redis.Nil {
+	if err != storage.ErrNotFound {
 		t.Fatal(err)
 	}
 	if len(infoList) != 0 {
@@ -321,12 +242,12 @@ func TestFindValuesSingleNothing(t *testing.T) {
 func TestFindValuesSingleErrDuringUnmarshalAfterGet(t *testing.T) {
 	key := "777"
 	db, mock := redismock.NewClientMock()
-	mock.ExpectGet(key).SetVal(key)
-	client := &RedisClient{*db, 10}
+	expectFindRecord(mock, key).SetVal([]interface{}{"hash", "global_id", "broken"})
+	client := &RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	_, _, err := client.FindValues(context.Background(), key, false, 0, 0)
 	fmt.Println(err)
-	if !strings.Contains(err.Error(), "parse error:") {
+	if err == nil || !strings.Contains(err.Error(), "must be an integer") {
 		t.Fatal(err)
 	}
 }
@@ -337,15 +258,15 @@ func TestFindValuesMultipleErrDuringUnmarshalAfterGet(t *testing.T) {
 	var start, end, paginationSize int64
 	paginationSize = 5
 	start = 0
-	end = start + paginationSize
+	end = start + paginationSize - 1
 	mock.ExpectLLen(key).SetVal(1)
 	mock.ExpectLRange(key, start, end).SetVal([]string{key})
-	mock.ExpectGet(key).SetVal(key)
-	client := &RedisClient{*db, 10}
+	mock.ExpectHGetAll(key).SetVal(map[string]string{"global_id": "broken"})
+	client := &RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	_, _, err := client.FindValues(context.Background(), key, true, paginationSize, start)
 	fmt.Println(err)
-	if !strings.Contains(err.Error(), "parse error:") {
+	if err == nil || !strings.Contains(err.Error(), "must be an integer") {
 		t.Fatal(err)
 	}
 }
@@ -354,7 +275,7 @@ func TestFindValuesMultipleZeroPaginationSize(t *testing.T) {
 	db, mock := redismock.NewClientMock()
 	key := "777"
 	mock.ExpectLLen(key).SetVal(0)
-	client := &RedisClient{*db, 10}
+	client := &RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	infoList, _, err := client.FindValues(context.Background(), key, true, 0, 0)
 
@@ -370,7 +291,8 @@ func TestFindValuesMultipleStartIsMoreThanSize(t *testing.T) {
 	db, mock := redismock.NewClientMock()
 	key := "777"
 	mock.ExpectLLen(key).SetVal(0)
-	client := &RedisClient{*db, 10}
+	mock.ExpectLRange(key, 1, 1).SetVal([]string{})
+	client := &RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	infoList, _, err := client.FindValues(context.Background(), key, true, 1, 1)
 
@@ -392,32 +314,17 @@ func TestFindValuesMultiple(t *testing.T) {
 		ModeEn:         "cba",
 	}
 
-	globalID := fmt.Sprintf("global_id:%d", info.GlobalID)
-	id := fmt.Sprintf("id:%d", info.ID)
-	idEn := fmt.Sprintf("id_en:%d", info.IDEn)
 	mode := fmt.Sprintf("mode:%s", info.Mode)
-	modeEn := fmt.Sprintf("mode_en:%s", info.ModeEn)
-
-	bs, _ := easyjson.Marshal(info)
 
 	db, mock := redismock.NewClientMock()
-	mock.ExpectWatch(info.SystemObjectID, globalID, id, idEn, mode, modeEn)
-	mock.ExpectGet(info.SystemObjectID).SetVal("")
-	mock.ExpectTxPipeline()
-	mock.ExpectSet(info.SystemObjectID, bs, 0).SetVal("OK")
-	mock.ExpectSet(globalID, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(id, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(idEn, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectRPush(mode, info.SystemObjectID).SetVal(0)
-	mock.ExpectRPush(modeEn, info.SystemObjectID).SetVal(0)
-	mock.ExpectTxPipelineExec()
+	expectUpsert(mock, info).SetVal(int64(1))
 
 	key := info.SystemObjectID
 	var paginationSize int64 = 5
 	mock.ExpectLLen(mode).SetVal(1)
-	mock.ExpectLRange(mode, 0, paginationSize).SetVal([]string{info.SystemObjectID})
-	mock.ExpectGet(key).SetVal(string(bs))
-	client := &RedisClient{*db, 10}
+	mock.ExpectLRange(mode, 0, paginationSize-1).SetVal([]string{info.SystemObjectID})
+	mock.ExpectHGetAll(key).SetVal(recordHash(info))
+	client := &RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	err := client.AddValue(context.Background(), info)
 	if err != nil {
@@ -449,29 +356,14 @@ func TestFindValuesMultipleLRangeErr(t *testing.T) {
 		ModeEn:         "cba",
 	}
 
-	globalID := fmt.Sprintf("global_id:%d", info.GlobalID)
-	id := fmt.Sprintf("id:%d", info.ID)
-	idEn := fmt.Sprintf("id_en:%d", info.IDEn)
 	mode := fmt.Sprintf("mode:%s", info.Mode)
-	modeEn := fmt.Sprintf("mode_en:%s", info.ModeEn)
-
-	bs, _ := easyjson.Marshal(info)
 
 	db, mock := redismock.NewClientMock()
-	mock.ExpectWatch(info.SystemObjectID, globalID, id, idEn, mode, modeEn)
-	mock.ExpectGet(info.SystemObjectID).SetVal("")
-	mock.ExpectTxPipeline()
-	mock.ExpectSet(info.SystemObjectID, bs, 0).SetVal("OK")
-	mock.ExpectSet(globalID, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(id, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(idEn, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectRPush(mode, info.SystemObjectID).SetVal(0)
-	mock.ExpectRPush(modeEn, info.SystemObjectID).SetVal(0)
-	mock.ExpectTxPipelineExec()
+	expectUpsert(mock, info).SetVal(int64(1))
 
 	var paginationSize int64 = 5
 	mock.ExpectLLen(mode).SetVal(1)
-	client := &RedisClient{*db, 10}
+	client := &RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	err := client.AddValue(context.Background(), info)
 	if err != nil {
@@ -494,32 +386,17 @@ func TestFindValuesMultipleGetErrInTheLoop(t *testing.T) {
 		ModeEn:         "cba",
 	}
 
-	globalID := fmt.Sprintf("global_id:%d", info.GlobalID)
-	id := fmt.Sprintf("id:%d", info.ID)
-	idEn := fmt.Sprintf("id_en:%d", info.IDEn)
 	mode := fmt.Sprintf("mode:%s", info.Mode)
-	modeEn := fmt.Sprintf("mode_en:%s", info.ModeEn)
-
-	bs, _ := easyjson.Marshal(info)
 
 	db, mock := redismock.NewClientMock()
-	mock.ExpectWatch(info.SystemObjectID, globalID, id, idEn, mode, modeEn)
-	mock.ExpectGet(info.SystemObjectID).SetVal("")
-	mock.ExpectTxPipeline()
-	mock.ExpectSet(info.SystemObjectID, bs, 0).SetVal("OK")
-	mock.ExpectSet(globalID, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(id, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectSet(idEn, info.SystemObjectID, 0).SetVal("OK")
-	mock.ExpectRPush(mode, info.SystemObjectID).SetVal(0)
-	mock.ExpectRPush(modeEn, info.SystemObjectID).SetVal(0)
-	mock.ExpectTxPipelineExec()
+	expectUpsert(mock, info).SetVal(int64(1))
 
 	// key := info.SystemObjectID
 	var paginationSize int64 = 5
 	mock.ExpectLLen(mode).SetVal(1)
-	mock.ExpectLRange(mode, 0, paginationSize).SetVal([]string{info.SystemObjectID})
-	// mock.ExpectGet(key).SetVal(string(bs))
-	client := &RedisClient{*db, 10}
+	mock.ExpectLRange(mode, 0, paginationSize-1).SetVal([]string{info.SystemObjectID})
+	// mock.ExpectHGetAll(key).SetVal(recordHash(info))
+	client := &RedisClient{UniversalClient: db, MaxRetries: 10}
 
 	err := client.AddValue(context.Background(), info)
 	if err != nil {
@@ -532,90 +409,263 @@ func TestFindValuesMultipleGetErrInTheLoop(t *testing.T) {
 	}
 }
 
-func TestAddValueRetriesFailed(t *testing.T) {
-	info := structs.Info{
-		GlobalID:       42,
-		SystemObjectID: "777",
-		ID:             1,
-		IDEn:           9,
-		Mode:           "abc",
-		ModeEn:         "cba",
+// expectUpsert expects upsertScript saving info without key prefix
+func expectUpsert(mock redismock.ClientMock, info structs.Info) *redismock.ExpectedCmd {
+	keys := []string{datasetKey, info.SystemObjectID}
+	keys = append(keys, storage.PointerKeys(info)...)
+	keys = append(keys, storage.ListKeys(info)...)
+	args := []interface{}{datasetRecordsField, "", 2 * len(structs.InfoFieldNames()), info.SystemObjectID}
+	args = append(args, recordArgs(info)...)
+	return mock.ExpectEvalSha(upsertScript.Hash(), keys, args...)
+}
+
+// expectFindRecord expects findRecordScript reading fields of record by key without key prefix
+func expectFindRecord(mock redismock.ClientMock, key string, fields ...string) *redismock.ExpectedCmd {
+	pointer := "0"
+	if storage.IsPointerKey(key) {
+		pointer = "1"
 	}
+	args := []interface{}{pointer, ""}
+	for _, field := range fields {
+		args = append(args, field)
+	}
+	return mock.ExpectEvalSha(findRecordScript.Hash(), []string{key}, args...)
+}
 
-	globalID := fmt.Sprintf("global_id:%d", info.GlobalID)
-	id := fmt.Sprintf("id:%d", info.ID)
-	idEn := fmt.Sprintf("id_en:%d", info.IDEn)
-	mode := fmt.Sprintf("mode:%s", info.Mode)
-	modeEn := fmt.Sprintf("mode_en:%s", info.ModeEn)
+// recordReply returns reply of findRecordScript reading all fields of record
+func recordReply(info structs.Info) []interface{} {
+	return append([]interface{}{"hash"}, recordArgs(info)...)
+}
 
-	maxRetries := 2
-	db, mock := redismock.NewClientMock()
-	for i := 0; i < maxRetries; i++ {
-		mock.ExpectWatch(info.SystemObjectID, globalID, id, idEn, mode, modeEn).SetErr(redis.TxFailedErr)
+// recordHash returns record hash as HGETALL does
+func recordHash(info structs.Info) map[string]string {
+	hash := make(map[string]string)
+	args := recordArgs(info)
+	for i := 0; i < len(args); i += 2 {
+		hash[args[i].(string)] = args[i+1].(string)
 	}
-	client := &RedisClient{Client: *db, MaxRetries: maxRetries}
+	return hash
+}
 
-	err := client.AddValue(context.Background(), info)
+func TestFindValuesByKeys(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := NewRedisClient(context.Background(), RedisConfig{Addr: mr.Addr()})
+	ctx := context.Background()
+
+	infos := make(structs.InfoList, 0, mgetChunkSize+1)
+	for i := 0; i < mgetChunkSize+1; i++ {
+		infos = append(infos, structs.Info{
+			GlobalID:       1000 + i,
+			SystemObjectID: strconv.Itoa(i),
+			ID:             i,
+			IDEn:           i,
+			Mode:           "abc",
+			ModeEn:         "cba",
+		})
+	}
+	err := client.AddValues(ctx, infos)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []string{"0", "global_id:1001", "missing", "id:2", "id_en:999999"}
+	for i := range infos {
+		keys = append(keys, fmt.Sprintf("id_en:%d", infos[i].IDEn))
+	}
+	result, err := client.FindValuesByKeys(ctx, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != len(keys) {
+		t.Fatalf("got %d results but wanted %d", len(result), len(keys))
+	}
+	for i, systemID := range []string{"0", "1", "", "2", ""} {
+		switch {
+		case systemID == "" && result[i] != nil:
+			t.Errorf("key %q: got %v but wanted nil", keys[i], result[i])
+		case systemID != "" && (result[i] == nil || result[i].SystemObjectID != systemID):
+			t.Errorf("key %q: got %v but wanted record %s", keys[i], result[i], systemID)
+		}
+	}
+	for i := range infos {
+		if result[i+5] == nil || *result[i+5] != infos[i] {
+			t.Errorf("key %q: got %v but wanted %v", keys[i+5], result[i+5], infos[i])
+		}
+	}
+}
+
+func TestFindValuesByKeysErr(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	mock.ExpectMGet("global_id:1").SetErr(errors.New("test error"))
+	client := &RedisClient{UniversalClient: db, MaxRetries: 10}
+
+	_, err := client.FindValuesByKeys(context.Background(), []string{"global_id:1"})
 	if err == nil {
 		t.Fatal(err)
 	}
 }
 
-func TestAddValueRetries(t *testing.T) {
-	info := structs.Info{
-		GlobalID:       42,
-		SystemObjectID: "777",
-		ID:             1,
-		IDEn:           9,
-		Mode:           "abc",
-		ModeEn:         "cba",
+func TestFindFields(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := NewRedisClient(context.Background(), RedisConfig{Addr: mr.Addr()})
+	ctx := context.Background()
+	infos := structs.InfoList{
+		{GlobalID: 1, SystemObjectID: "1", Name: "first", Mode: "abc", CarCapacity: 5},
+		{GlobalID: 2, SystemObjectID: "2", Name: "second", Mode: "abc", CarCapacity: 7},
+	}
+	if err := client.AddValues(ctx, infos); err != nil {
+		t.Fatal(err)
 	}
 
-	globalID := fmt.Sprintf("global_id:%d", info.GlobalID)
-	id := fmt.Sprintf("id:%d", info.ID)
-	idEn := fmt.Sprintf("id_en:%d", info.IDEn)
-	mode := fmt.Sprintf("mode:%s", info.Mode)
-	modeEn := fmt.Sprintf("mode_en:%s", info.ModeEn)
+	fields := []string{"Name", "CarCapacity"}
+	infoList, size, err := client.FindFields(ctx, "global_id:2", false, 0, 0, fields)
+	want := structs.InfoList{{Name: "second", CarCapacity: 7}}
+	if err != nil || size != 1 || !reflect.DeepEqual(infoList, want) {
+		t.Errorf("got %v of size %d and error %v but wanted %v", infoList, size, err, want)
+	}
+	infoList, size, err = client.FindFields(ctx, "mode:abc", true, 5, 0, fields)
+	want = structs.InfoList{{Name: "first", CarCapacity: 5}, {Name: "second", CarCapacity: 7}}
+	if err != nil || size != 2 || !reflect.DeepEqual(infoList, want) {
+		t.Errorf("got %v of size %d and error %v but wanted %v", infoList, size, err, want)
+	}
+	if _, _, err = client.FindFields(ctx, "3", false, 0, 0, fields); err != storage.ErrNotFound {
+		t.Errorf("got error %v but wanted %v", err, storage.ErrNotFound)
+	}
+	mr.HSet("1", "CarCapacity", "many")
+	if _, _, err = client.FindFields(ctx, "1", false, 0, 0, fields); err == nil {
+		t.Errorf("got no error of broken field")
+	}
+}
 
-	maxRetries := 1
-	db, mock := redismock.NewClientMock()
-	for i := 0; i < maxRetries; i++ {
-		mock.ExpectWatch(info.SystemObjectID, globalID, id,
-			idEn, mode, modeEn).SetErr(redis.TxFailedErr)
+func TestRecordFromReply(t *testing.T) {
+	info := structs.Info{SystemObjectID: "A0", GlobalID: 1}
+	legacy, _ := easyjson.Marshal(info)
+	for _, reply := range [][]interface{}{recordReply(info), {"string", string(legacy)}} {
+		got, exists, err := recordFromReply(reply)
+		if err != nil || !exists || got != info {
+			t.Errorf("got %v, %t and error %v of reply %v", got, exists, err, reply)
+		}
 	}
-	client := &RedisClient{Client: *db, MaxRetries: maxRetries}
+	if _, exists, err := recordFromReply([]interface{}{"hash"}); err != nil || exists {
+		t.Errorf("got record %t and error %v of empty hash", exists, err)
+	}
+	for _, broken := range [][]interface{}{nil, {"list"}, {"hash", "global_id"}, {"string", "{"}} {
+		if _, _, err := recordFromReply(broken); err == nil {
+			t.Errorf("got no error of reply %v", broken)
+		}
+	}
+}
 
-	err := client.AddValue(context.Background(), info)
-	if err != redis.TxFailedErr {
-		t.Fatal(err)
+// latencyProxy forwards connections to addr delaying every chunk written by client,
+// so commands sent at once, e.g. by pipeline, wait once as they do on the way to remote Redis
+func latencyProxy(tb testing.TB, addr string, delay time.Duration) string {
+	tb.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			upstream, err := net.Dial("tcp", addr)
+			if err != nil {
+				_ = conn.Close()
+				continue
+			}
+			go func() {
+				_, _ = io.Copy(conn, upstream)
+				_ = conn.Close()
+			}()
+			go func() {
+				defer upstream.Close()
+				buf := make([]byte, 64<<10)
+				for {
+					n, err := conn.Read(buf)
+					if n > 0 {
+						time.Sleep(delay)
+						if _, err := upstream.Write(buf[:n]); err != nil {
+							return
+						}
+					}
+					if err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// findValuesLooping is FindValues reading pointer and record, or every record of page, one by one
+// as it was before findRecordScript and pipelines, it is kept to compare them by benchmarks
+func (r *RedisClient) findValuesLooping(ctx context.Context, searchStr string, multiple bool,
+	paginationSize, offset int64) (infoList structs.InfoList, totalSize int64, err error) {
+	if !multiple {
+		key := searchStr
+		if storage.IsPointerKey(searchStr) {
+			if key, err = r.Get(ctx, r.keys.key(searchStr)).Result(); err != nil {
+				return nil, 0, notFound(err)
+			}
+		}
+		info, _, err := readRecord(ctx, r, r.keys.key(key), nil)
+		return structs.InfoList{info}, 1, err
+	}
+	size, err := r.LLen(ctx, r.keys.key(searchStr)).Result()
+	if err != nil {
+		return nil, 0, err
 	}
+	ids, err := r.LRange(ctx, r.keys.key(searchStr), offset, offset+paginationSize-1).Result()
+	if err != nil {
+		return nil, size, err
+	}
+	for _, id := range ids {
+		info, _, err := readRecord(ctx, r, r.keys.key(id), nil)
+		if err != nil {
+			return nil, size, err
+		}
+		infoList = append(infoList, info)
+	}
+	return infoList, size, nil
 }
 
-// func TestAddValueErrInsideWatchFirstGet(t *testing.T) {
-//	info := structs.Info{
-//		GlobalID:       42,
-//		SystemObjectID: "777",
-//		ID:             1,
-//		IDEn:           9,
-//		Mode:           "abc",
-//		ModeEn:         "cba",
-//	}
-//
-//	globalID := fmt.Sprintf("global_id:%d", info.GlobalID)
-//	id := fmt.Sprintf("id:%d", info.ID)
-//	idEn := fmt.Sprintf("id_en:%d", info.IDEn)
-//	mode := fmt.Sprintf("mode:%s", info.Mode)
-//	modeEn := fmt.Sprintf("mode_en:%s", info.ModeEn)
-//
-//	maxRetries := 1
-//	db, mock := redismock.NewClientMock()
-//	mock.ExpectWatch(info.SystemObjectID, globalID, id,
-//		idEn, mode, modeEn)
-//	mock.ExpectGet(info.SystemObjectID).SetErr(redis.Nil)
-//	client := &RedisClient{Client: *db, MaxRetries: maxRetries}
-//
-//	err := client.AddValue(context.Background(), info)
-//	if err != redis.Nil {
-//		t.Fatal(err)
-//	}
-//}
+// BenchmarkFindValues reads records through proxy adding 200µs to every round trip,
+// time of operation is mostly number of round trips then
+func BenchmarkFindValues(b *testing.B) {
+	mr := miniredis.RunT(b)
+	client := newTestLazyClient(b, RedisConfig{Addr: latencyProxy(b, mr.Addr(), 200*time.Microsecond)})
+	defer client.Close()
+	ctx := context.Background()
+	if err := client.AddValues(ctx, storagetest.Infos(1000)); err != nil {
+		b.Fatal(err)
+	}
+	if err := client.LoadScripts(ctx); err != nil {
+		b.Fatal(err)
+	}
+
+	type find func(r *RedisClient, ctx context.Context, searchStr string, multiple bool,
+		paginationSize, offset int64) (structs.InfoList, int64, error)
+	for _, bm := range []struct {
+		name      string
+		searchStr string
+		multiple  bool
+	}{
+		{"Single", "global_id:1500", false},
+		{"Page", "mode:a", true},
+	} {
+		for name, f := range map[string]find{"Batched": (*RedisClient).FindValues, "Loop": (*RedisClient).findValuesLooping} {
+			b.Run(bm.name+"/"+name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					infoList, _, err := f(client, ctx, bm.searchStr, bm.multiple, 20, 100)
+					if err != nil || len(infoList) == 0 {
+						b.Fatalf("got %v and error %v", infoList, err)
+					}
+				}
+			})
+		}
+	}
+}
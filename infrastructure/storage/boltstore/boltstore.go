@@ -0,0 +1,437 @@
+package boltstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"golang-developer-test-task/infrastructure/storage"
+	"golang-developer-test-task/structs"
+	"time"
+
+	"github.com/mailru/easyjson"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// recordsBucket maps system_object_id onto JSON of record
+	recordsBucket = []byte("records")
+	// pointersBucket maps pointer keys onto system_object_id
+	pointersBucket = []byte("pointers")
+	// listsBucket has bucket of every list key with system_object_ids by sequence numbers of adding
+	listsBucket = []byte("lists")
+	// sizesBucket maps list keys onto their lengths
+	sizesBucket = []byte("sizes")
+	// metaBucket keeps dataset version and number of records
+	metaBucket = []byte("meta")
+	// apiKeysBucket maps API key IDs onto JSON of keys
+	apiKeysBucket = []byte("apikeys")
+
+	versionKey      = []byte("version")
+	lastModifiedKey = []byte("last_modified")
+	recordsKey      = []byte("records")
+)
+
+// openTimeout limits waiting for lock of data file held by another process
+const openTimeout = time.Second
+
+// Store keeps dataset and API keys in BoltDB file, so the service runs without Redis.
+// Rate limit counters are kept in memory: data file is used by one process, so they are not shared anyway,
+// and writing them would sync the file on every request
+type Store struct {
+	storage.Windows
+	db *bolt.DB
+}
+
+// Open opens or creates data file at path
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		buckets := [][]byte{recordsBucket, pointersBucket, listsBucket, sizesBucket, metaBucket, apiKeysBucket}
+		for _, name := range buckets {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes data file, it waits for running transactions
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// AddValues saves records instead of stored ones with the same system_object_id in one transaction
+func (s *Store) AddValues(ctx context.Context, infos structs.InfoList) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		var added int64
+		for _, info := range infos {
+			exists, err := replace(tx, info)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				added++
+			}
+		}
+		return addInt(tx.Bucket(metaBucket), recordsKey, added)
+	})
+}
+
+// ReplaceValue saves record instead of the stored one with the same system_object_id
+func (s *Store) ReplaceValue(ctx context.Context, info structs.Info) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		exists, err := replace(tx, info)
+		if err != nil || exists {
+			return err
+		}
+		return addInt(tx.Bucket(metaBucket), recordsKey, 1)
+	})
+}
+
+// replace saves record instead of the stored one, exists reports whether there was one,
+// record keeps its place in lists which it stays in
+func replace(tx *bolt.Tx, info structs.Info) (exists bool, err error) {
+	old, exists, err := getRecord(tx, info.SystemObjectID)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		if err = unlink(tx, old, &info); err != nil {
+			return false, err
+		}
+	}
+	if err = putRecord(tx, info); err != nil {
+		return false, err
+	}
+	oldLists := storage.ListKeys(old)
+	for i, key := range storage.ListKeys(info) {
+		if !exists || key != oldLists[i] {
+			if err = appendToList(tx, key, info.SystemObjectID); err != nil {
+				return false, err
+			}
+		}
+	}
+	return exists, nil
+}
+
+// DeleteValue removes record with its lookup keys
+func (s *Store) DeleteValue(ctx context.Context, systemObjectID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		old, exists, err := getRecord(tx, systemObjectID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return storage.ErrNotFound
+		}
+		if err = unlink(tx, old, nil); err != nil {
+			return err
+		}
+		if err = tx.Bucket(recordsBucket).Delete([]byte(systemObjectID)); err != nil {
+			return err
+		}
+		return addInt(tx.Bucket(metaBucket), recordsKey, -1)
+	})
+}
+
+// putRecord saves record with its pointers
+func putRecord(tx *bolt.Tx, info structs.Info) error {
+	bs, err := easyjson.Marshal(info)
+	if err != nil {
+		return err
+	}
+	id := []byte(info.SystemObjectID)
+	if err = tx.Bucket(recordsBucket).Put(id, bs); err != nil {
+		return err
+	}
+	pointers := tx.Bucket(pointersBucket)
+	for _, key := range storage.PointerKeys(info) {
+		if err = pointers.Put([]byte(key), id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unlink removes old record from lookup keys which are not keys of next version of it,
+// from all of them if next is nil, pointers taken over by other records are kept
+func unlink(tx *bolt.Tx, old structs.Info, next *structs.Info) error {
+	var nextPointers, nextLists []string
+	if next != nil {
+		nextPointers, nextLists = storage.PointerKeys(*next), storage.ListKeys(*next)
+	}
+	id := []byte(old.SystemObjectID)
+	pointers := tx.Bucket(pointersBucket)
+	for i, key := range storage.PointerKeys(old) {
+		if nextPointers != nil && key == nextPointers[i] {
+			continue
+		}
+		if bytes.Equal(pointers.Get([]byte(key)), id) {
+			if err := pointers.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+	}
+	for i, key := range storage.ListKeys(old) {
+		if nextLists != nil && key == nextLists[i] {
+			continue
+		}
+		if err := removeFromList(tx, key, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getRecord(tx *bolt.Tx, systemObjectID string) (info structs.Info, exists bool, err error) {
+	bs := tx.Bucket(recordsBucket).Get([]byte(systemObjectID))
+	if bs == nil {
+		return info, false, nil
+	}
+	err = easyjson.Unmarshal(bs, &info)
+	return info, err == nil, err
+}
+
+func appendToList(tx *bolt.Tx, key, systemObjectID string) error {
+	list, err := tx.Bucket(listsBucket).CreateBucketIfNotExists([]byte(key))
+	if err != nil {
+		return err
+	}
+	seq, err := list.NextSequence()
+	if err != nil {
+		return err
+	}
+	if err = list.Put(uint64Bytes(seq), []byte(systemObjectID)); err != nil {
+		return err
+	}
+	return addInt(tx.Bucket(sizesBucket), []byte(key), 1)
+}
+
+// removeFromList removes all entries of id from list, it scans the whole list
+func removeFromList(tx *bolt.Tx, key string, id []byte) error {
+	list := tx.Bucket(listsBucket).Bucket([]byte(key))
+	if list == nil {
+		return nil
+	}
+	var removed int64
+	c := list.Cursor()
+	for k, v := c.First(); k != nil; {
+		if !bytes.Equal(v, id) {
+			k, v = c.Next()
+			continue
+		}
+		// cursor is moved to the next item by Delete
+		if err := c.Delete(); err != nil {
+			return err
+		}
+		removed++
+		k, v = c.Seek(k)
+	}
+	if removed == 0 {
+		return nil
+	}
+	sizes := tx.Bucket(sizesBucket)
+	if readInt(sizes, []byte(key)) == removed {
+		if err := sizes.Delete([]byte(key)); err != nil {
+			return err
+		}
+		return tx.Bucket(listsBucket).DeleteBucket([]byte(key))
+	}
+	return addInt(sizes, []byte(key), -removed)
+}
+
+// FindValues looks up one record by system_object_id or pointer key or a page of list key
+func (s *Store) FindValues(ctx context.Context, searchStr string, multiple bool,
+	paginationSize, offset int64) (infoList structs.InfoList, totalSize int64, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		if !multiple {
+			info, ok, err := find(tx, searchStr)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return storage.ErrNotFound
+			}
+			infoList = append(infoList, info)
+			totalSize = 1
+			return nil
+		}
+
+		totalSize = readInt(tx.Bucket(sizesBucket), []byte(searchStr))
+		list := tx.Bucket(listsBucket).Bucket([]byte(searchStr))
+		if list == nil || paginationSize <= 0 || offset >= totalSize {
+			return nil
+		}
+		c := list.Cursor()
+		k, v := c.First()
+		for i := int64(0); i < offset && k != nil; i++ {
+			k, v = c.Next()
+		}
+		for ; k != nil && int64(len(infoList)) < paginationSize; k, v = c.Next() {
+			info, ok, err := getRecord(tx, string(v))
+			if err != nil {
+				return err
+			}
+			if ok {
+				infoList = append(infoList, info)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return infoList, totalSize, nil
+}
+
+// FindValuesByKeys looks up records by system_object_ids or pointer keys
+func (s *Store) FindValuesByKeys(ctx context.Context, keys []string) ([]*structs.Info, error) {
+	infos := make([]*structs.Info, len(keys))
+	err := s.db.View(func(tx *bolt.Tx) error {
+		for i, key := range keys {
+			info, ok, err := find(tx, key)
+			if err != nil {
+				return err
+			}
+			if ok {
+				infos[i] = &info
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+func find(tx *bolt.Tx, key string) (structs.Info, bool, error) {
+	if storage.IsPointerKey(key) {
+		key = string(tx.Bucket(pointersBucket).Get([]byte(key)))
+		if key == "" {
+			return structs.Info{}, false, nil
+		}
+	}
+	return getRecord(tx, key)
+}
+
+// BumpDatasetVersion increments dataset version and sets its modification time
+func (s *Store) BumpDatasetVersion(ctx context.Context, modified time.Time) (version storage.DatasetVersion, err error) {
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+		if err := addInt(meta, versionKey, 1); err != nil {
+			return err
+		}
+		version.Version = readInt(meta, versionKey)
+		version.LastModified = modified.UTC()
+		return meta.Put(lastModifiedKey, []byte(version.LastModified.Format(time.RFC3339Nano)))
+	})
+	return version, err
+}
+
+// GetDatasetVersion returns current dataset version
+func (s *Store) GetDatasetVersion(ctx context.Context) (version storage.DatasetVersion, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		version, err = readVersion(tx.Bucket(metaBucket))
+		return err
+	})
+	return version, err
+}
+
+// Stats returns number of records and dataset version
+func (s *Store) Stats(ctx context.Context) (stats storage.Stats, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+		stats.Records = readInt(meta, recordsKey)
+		stats.Dataset, err = readVersion(meta)
+		if err == storage.ErrNotFound {
+			return nil
+		}
+		return err
+	})
+	return stats, err
+}
+
+func readVersion(meta *bolt.Bucket) (version storage.DatasetVersion, err error) {
+	version.Version = readInt(meta, versionKey)
+	if version.Version == 0 {
+		return version, storage.ErrNotFound
+	}
+	if modified := meta.Get(lastModifiedKey); modified != nil {
+		version.LastModified, err = time.Parse(time.RFC3339Nano, string(modified))
+	}
+	return version, err
+}
+
+// SaveAPIKey stores API key by its ID
+func (s *Store) SaveAPIKey(ctx context.Context, key storage.APIKey) error {
+	bs, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(apiKeysBucket).Put([]byte(key.ID), bs)
+	})
+}
+
+// GetAPIKey returns API key by its ID
+func (s *Store) GetAPIKey(ctx context.Context, id string) (key storage.APIKey, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		bs := tx.Bucket(apiKeysBucket).Get([]byte(id))
+		if bs == nil {
+			return storage.ErrNotFound
+		}
+		return json.Unmarshal(bs, &key)
+	})
+	return key, err
+}
+
+// DeleteAPIKey revokes API key by its ID
+func (s *Store) DeleteAPIKey(ctx context.Context, id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		keys := tx.Bucket(apiKeysBucket)
+		if keys.Get([]byte(id)) == nil {
+			return storage.ErrNotFound
+		}
+		return keys.Delete([]byte(id))
+	})
+}
+
+// Check returns error if data file is closed
+func (s *Store) Check(ctx context.Context) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return nil
+	})
+}
+
+func uint64Bytes(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+// readInt reads counter of bucket, missing counter is zero
+func readInt(b *bolt.Bucket, key []byte) int64 {
+	v := b.Get(key)
+	if len(v) != 8 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(v))
+}
+
+func addInt(b *bolt.Bucket, key []byte, delta int64) error {
+	if delta == 0 {
+		return nil
+	}
+	return b.Put(key, uint64Bytes(uint64(readInt(b, key)+delta)))
+}
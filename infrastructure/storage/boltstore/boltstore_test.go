@@ -0,0 +1,67 @@
+package boltstore
+
+import (
+	"context"
+	"golang-developer-test-task/infrastructure/storage"
+	"golang-developer-test-task/infrastructure/storage/storagetest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T, path string) *Store {
+	t.Helper()
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestStore(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Store {
+		s := openTestStore(t, filepath.Join(t.TempDir(), "parkings.db"))
+		t.Cleanup(func() { _ = s.Close() })
+		return s
+	})
+}
+
+func TestStoreReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "parkings.db")
+	ctx := context.Background()
+	s := openTestStore(t, path)
+	infos := storagetest.Infos(3)
+	if err := s.AddValues(ctx, infos); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.BumpDatasetVersion(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	// data file is locked while it is open
+	if _, err := Open(path); err == nil {
+		t.Errorf("expected error of locked data file")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Check(ctx); err == nil {
+		t.Errorf("expected error of closed store")
+	}
+
+	s = openTestStore(t, path)
+	defer s.Close()
+	stats, err := s.Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Records != 3 || stats.Dataset.Version != 1 {
+		t.Errorf("got stats %+v after reopening", stats)
+	}
+	infoList, size, err := s.FindValues(ctx, "mode:a", true, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 2 || len(infoList) != 2 || infoList[1] != infos[2] {
+		t.Errorf("got %v of size %d", infoList, size)
+	}
+}
@@ -0,0 +1,222 @@
+package memstore
+
+import (
+	"context"
+	"golang-developer-test-task/infrastructure/storage"
+	"golang-developer-test-task/structs"
+	"sync"
+	"time"
+)
+
+// Store keeps dataset, API keys and rate limit counters in memory of the process, they are lost on restart
+type Store struct {
+	storage.Windows
+	mu       sync.RWMutex
+	records  map[string]structs.Info
+	pointers map[string]string
+	lists    map[string][]string
+	version  storage.DatasetVersion
+	apiKeys  map[string]storage.APIKey
+}
+
+// New is constructor for empty Store
+func New() *Store {
+	return &Store{
+		records:  make(map[string]structs.Info),
+		pointers: make(map[string]string),
+		lists:    make(map[string][]string),
+		apiKeys:  make(map[string]storage.APIKey),
+	}
+}
+
+// AddValues saves records instead of stored ones with the same system_object_id
+func (s *Store) AddValues(ctx context.Context, infos structs.InfoList) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, info := range infos {
+		s.replace(info)
+	}
+	return nil
+}
+
+// ReplaceValue saves record instead of the stored one with the same system_object_id
+func (s *Store) ReplaceValue(ctx context.Context, info structs.Info) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replace(info)
+	return nil
+}
+
+// replace saves record instead of the stored one, record keeps its place in lists which it stays in
+func (s *Store) replace(info structs.Info) {
+	old, exists := s.records[info.SystemObjectID]
+	if exists {
+		s.unlink(old, &info)
+	}
+	s.records[info.SystemObjectID] = info
+	for _, key := range storage.PointerKeys(info) {
+		s.pointers[key] = info.SystemObjectID
+	}
+	oldLists := storage.ListKeys(old)
+	for i, key := range storage.ListKeys(info) {
+		if !exists || oldLists[i] != key {
+			s.lists[key] = append(s.lists[key], info.SystemObjectID)
+		}
+	}
+}
+
+// DeleteValue removes record with its lookup keys
+func (s *Store) DeleteValue(ctx context.Context, systemObjectID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, exists := s.records[systemObjectID]
+	if !exists {
+		return storage.ErrNotFound
+	}
+	s.unlink(old, nil)
+	delete(s.records, systemObjectID)
+	return nil
+}
+
+// unlink removes old record from lookup keys which are not keys of next version of it,
+// from all of them if next is nil, pointers taken over by other records are kept
+func (s *Store) unlink(old structs.Info, next *structs.Info) {
+	var pointers, lists []string
+	if next != nil {
+		pointers, lists = storage.PointerKeys(*next), storage.ListKeys(*next)
+	}
+	for i, key := range storage.PointerKeys(old) {
+		if pointers != nil && key == pointers[i] {
+			continue
+		}
+		if s.pointers[key] == old.SystemObjectID {
+			delete(s.pointers, key)
+		}
+	}
+	for i, key := range storage.ListKeys(old) {
+		if lists != nil && key == lists[i] {
+			continue
+		}
+		kept := s.lists[key][:0]
+		for _, id := range s.lists[key] {
+			if id != old.SystemObjectID {
+				kept = append(kept, id)
+			}
+		}
+		if len(kept) == 0 {
+			delete(s.lists, key)
+		} else {
+			s.lists[key] = kept
+		}
+	}
+}
+
+// FindValues looks up one record by system_object_id or pointer key or a page of list key
+func (s *Store) FindValues(ctx context.Context, searchStr string, multiple bool,
+	paginationSize, offset int64) (infoList structs.InfoList, totalSize int64, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !multiple {
+		info, ok := s.find(searchStr)
+		if !ok {
+			return infoList, 0, storage.ErrNotFound
+		}
+		return append(infoList, info), 1, nil
+	}
+
+	list := s.lists[searchStr]
+	size := int64(len(list))
+	if paginationSize <= 0 || offset >= size {
+		return infoList, size, nil
+	}
+	end := offset + paginationSize
+	if end > size {
+		end = size
+	}
+	for _, id := range list[offset:end] {
+		infoList = append(infoList, s.records[id])
+	}
+	return infoList, size, nil
+}
+
+// FindValuesByKeys looks up records by system_object_ids or pointer keys
+func (s *Store) FindValuesByKeys(ctx context.Context, keys []string) ([]*structs.Info, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	infos := make([]*structs.Info, len(keys))
+	for i, key := range keys {
+		if info, ok := s.find(key); ok {
+			infos[i] = &info
+		}
+	}
+	return infos, nil
+}
+
+func (s *Store) find(key string) (structs.Info, bool) {
+	if storage.IsPointerKey(key) {
+		key = s.pointers[key]
+	}
+	info, ok := s.records[key]
+	return info, ok
+}
+
+// BumpDatasetVersion increments dataset version and sets its modification time
+func (s *Store) BumpDatasetVersion(ctx context.Context, modified time.Time) (storage.DatasetVersion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version.Version++
+	s.version.LastModified = modified.UTC()
+	return s.version, nil
+}
+
+// GetDatasetVersion returns current dataset version
+func (s *Store) GetDatasetVersion(ctx context.Context) (storage.DatasetVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.version.Version == 0 {
+		return s.version, storage.ErrNotFound
+	}
+	return s.version, nil
+}
+
+// Stats returns number of records and dataset version
+func (s *Store) Stats(ctx context.Context) (storage.Stats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return storage.Stats{Records: int64(len(s.records)), Dataset: s.version}, nil
+}
+
+// SaveAPIKey stores API key by its ID
+func (s *Store) SaveAPIKey(ctx context.Context, key storage.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apiKeys[key.ID] = key
+	return nil
+}
+
+// GetAPIKey returns API key by its ID
+func (s *Store) GetAPIKey(ctx context.Context, id string) (storage.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.apiKeys[id]
+	if !ok {
+		return key, storage.ErrNotFound
+	}
+	return key, nil
+}
+
+// DeleteAPIKey revokes API key by its ID
+func (s *Store) DeleteAPIKey(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.apiKeys[id]; !ok {
+		return storage.ErrNotFound
+	}
+	delete(s.apiKeys, id)
+	return nil
+}
+
+// Check always succeeds as memory is always available
+func (s *Store) Check(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepSize is number of counted keys after which expired ones are removed
+const sweepSize = 1024
+
+// Windows is RateLimitStore counting requests in memory of the process,
+// it is used by stores which are not shared by replicas. Zero value is ready to use
+type Windows struct {
+	mu      sync.Mutex
+	windows map[string]window
+	sweepAt int
+}
+
+// window is count of requests of key in current and previous windows
+type window struct {
+	index    int64
+	current  int64
+	previous int64
+}
+
+// AllowRequest counts request of key in sliding window of given size if there are
+// less than limit requests in it, previous window is weighted by its overlap with the sliding one
+func (w *Windows) AllowRequest(ctx context.Context, key string, limit int64, size time.Duration,
+	now time.Time) (result RateLimitResult, err error) {
+	sizeMs := size.Milliseconds()
+	nowMs := now.UnixMilli()
+	index := nowMs / sizeMs
+	elapsed := nowMs % sizeMs
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.windows == nil {
+		w.windows = make(map[string]window)
+	}
+	if len(w.windows) >= w.sweepAt {
+		w.sweep(index)
+	}
+	counts := w.windows[key].at(index)
+	if float64(counts.previous)*float64(sizeMs-elapsed)/float64(sizeMs)+float64(counts.current)+1 > float64(limit) {
+		result.RetryAfter = RetryAfter(counts.previous, counts.current, limit, sizeMs, elapsed)
+		return result, nil
+	}
+	counts.current++
+	w.windows[key] = counts
+	result.Allowed = true
+	return result, nil
+}
+
+// at returns counts moved to window of index
+func (c window) at(index int64) window {
+	switch c.index {
+	case index:
+		return c
+	case index - 1:
+		return window{index: index, previous: c.current}
+	default:
+		return window{index: index}
+	}
+}
+
+// sweep removes keys without requests in current and previous windows
+func (w *Windows) sweep(index int64) {
+	for key, counts := range w.windows {
+		if counts.index < index-1 {
+			delete(w.windows, key)
+		}
+	}
+	w.sweepAt = 2 * len(w.windows)
+	if w.sweepAt < sweepSize {
+		w.sweepAt = sweepSize
+	}
+}
+
+// RetryAfter returns time until the estimated count of sliding window leaves room for one more request
+func RetryAfter(previous, current, limit, windowMs, elapsed int64) time.Duration {
+	w := float64(windowMs)
+	var waitMs float64
+	if current+1 <= limit && previous > 0 {
+		// weight of previous window decreases until the request fits into current one
+		fits := w * (1 - float64(limit-current-1)/float64(previous))
+		waitMs = fits - float64(elapsed)
+	} else {
+		// current window becomes the previous one and has to decrease in its turn
+		waitMs = w - float64(elapsed)
+		if current > 0 {
+			waitMs += math.Max(0, w*(1-float64(limit-1)/float64(current)))
+		}
+	}
+	return time.Duration(math.Ceil(math.Max(waitMs, 1))) * time.Millisecond
+}
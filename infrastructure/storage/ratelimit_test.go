@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		previous, current, limit, elapsed int64
+		want                              time.Duration
+	}{
+		// previous window has to lose weight of one request
+		{10, 0, 10, 0, 6 * time.Second},
+		{10, 5, 10, 30000, 6 * time.Second},
+		// current window is full
+		{0, 10, 10, 30000, 36 * time.Second},
+		{0, 1, 1, 59999, time.Minute + time.Millisecond},
+	}
+	for _, tt := range tests {
+		got := RetryAfter(tt.previous, tt.current, tt.limit, time.Minute.Milliseconds(), tt.elapsed)
+		if got != tt.want {
+			t.Errorf("%v: got %s but wanted %s", tt, got, tt.want)
+		}
+	}
+}
+
+func TestWindowsSweep(t *testing.T) {
+	var w Windows
+	start := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < sweepSize; i++ {
+		if _, err := w.AllowRequest(context.Background(), strconv.Itoa(i), 1, time.Minute, start); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := w.AllowRequest(context.Background(), "late", 1, time.Minute, start.Add(2*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.windows) != 1 {
+		t.Errorf("got %d counted keys but wanted only the late one", len(w.windows))
+	}
+}
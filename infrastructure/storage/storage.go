@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-developer-test-task/structs"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when record or dataset version does not exist
+var ErrNotFound = errors.New("not found in storage")
+
+// DatasetVersion describes the last successful import to storage
+type DatasetVersion struct {
+	Version      int64
+	LastModified time.Time
+}
+
+// Stats describes stored dataset
+type Stats struct {
+	// Records is number of stored records
+	Records int64
+	// Dataset is version of the last import, it is zero if nothing was imported
+	Dataset DatasetVersion
+}
+
+// Store keeps records by system_object_id with lookup keys of them:
+// "global_id:<id>", "id:<id>" and "id_en:<id>" point to one record,
+// "mode:<mode>" and "mode_en:<mode>" list records in order of adding.
+// API keys and rate limit counters of the service are kept by it as well
+type Store interface {
+	// AddValues saves records and adds them to lookup keys, stored records with the same
+	// system_object_id are replaced as ReplaceValue does
+	AddValues(ctx context.Context, infos structs.InfoList) error
+	// ReplaceValue saves record instead of the stored one with the same system_object_id,
+	// lookup keys of the old record which do not match the new one are removed
+	ReplaceValue(ctx context.Context, info structs.Info) error
+	// DeleteValue removes record with its lookup keys, ErrNotFound if it does not exist
+	DeleteValue(ctx context.Context, systemObjectID string) error
+	// FindValues looks up one record by system_object_id or pointer key, ErrNotFound if it does not exist,
+	// or a page of list key if multiple is set, totalSize is length of the whole list then
+	FindValues(ctx context.Context, searchStr string, multiple bool,
+		paginationSize, offset int64) (infoList structs.InfoList, totalSize int64, err error)
+	// FindValuesByKeys looks up records by system_object_ids or pointer keys,
+	// result is aligned with keys and contains nil for records which were not found
+	FindValuesByKeys(ctx context.Context, keys []string) ([]*structs.Info, error)
+	// BumpDatasetVersion increments dataset version and sets its modification time
+	BumpDatasetVersion(ctx context.Context, modified time.Time) (DatasetVersion, error)
+	// GetDatasetVersion returns current dataset version, ErrNotFound if nothing was imported yet
+	GetDatasetVersion(ctx context.Context) (DatasetVersion, error)
+	// Stats returns number of records and dataset version
+	Stats(ctx context.Context) (Stats, error)
+	// Check returns error if storage is unavailable
+	Check(ctx context.Context) error
+
+	APIKeyStore
+	RateLimitStore
+}
+
+// APIKey is stored API key, only hash of its secret is kept
+type APIKey struct {
+	ID        string
+	Hash      string
+	Role      string
+	CreatedAt time.Time
+}
+
+// APIKeyStore keeps API keys by their IDs
+type APIKeyStore interface {
+	// SaveAPIKey stores API key by its ID
+	SaveAPIKey(ctx context.Context, key APIKey) error
+	// GetAPIKey returns API key by its ID, ErrNotFound if it does not exist
+	GetAPIKey(ctx context.Context, id string) (APIKey, error)
+	// DeleteAPIKey revokes API key by its ID, ErrNotFound if it does not exist
+	DeleteAPIKey(ctx context.Context, id string) error
+}
+
+// RateLimitResult is outcome of counting request in sliding window
+type RateLimitResult struct {
+	Allowed bool
+	// RetryAfter is time after which request would be allowed, it is zero for allowed requests
+	RetryAfter time.Duration
+}
+
+// RateLimitStore counts requests of clients
+type RateLimitStore interface {
+	// AllowRequest counts request of key in sliding window of given size if there are
+	// less than limit requests in it, rejected requests are not counted
+	AllowRequest(ctx context.Context, key string, limit int64, window time.Duration,
+		now time.Time) (RateLimitResult, error)
+}
+
+// FieldFinder is implemented by stores which read only requested fields of records
+type FieldFinder interface {
+	// FindFields is FindValues filling only fields of records named by JSON names of structs.Info,
+	// all of them if fields are empty
+	FindFields(ctx context.Context, searchStr string, multiple bool, paginationSize, offset int64,
+		fields []string) (infoList structs.InfoList, totalSize int64, err error)
+}
+
+// ErrFilterUnsupported is returned by FilterFinder which could not filter records now,
+// e.g. RediSearch backend without the module
+var ErrFilterUnsupported = errors.New("filters are not supported by storage")
+
+// GeoRadius is circle around point given by WGS84 coordinates, Radius is in meters
+type GeoRadius struct {
+	Longitude float64
+	Latitude  float64
+	Radius    float64
+}
+
+// Filter narrows lookups down by fields of records which are not lookup keys, nil fields do not filter
+type Filter struct {
+	AdmArea    *string
+	AdmAreaEn  *string
+	District   *string
+	DistrictEn *string
+	// Name and NameEn match records having all words of them in names
+	Name   *string
+	NameEn *string
+	// CarCapacityMin and CarCapacityMax bound capacity inclusively
+	CarCapacityMin *int
+	CarCapacityMax *int
+	// Near matches records located in the circle
+	Near *GeoRadius
+}
+
+// IsZero reports whether filter matches all records
+func (f Filter) IsZero() bool {
+	return f == Filter{}
+}
+
+// String returns canonical form of filter, equal filters have equal forms
+func (f Filter) String() string {
+	var parts []string
+	for _, field := range []struct {
+		name  string
+		value *string
+	}{
+		{"adm_area", f.AdmArea}, {"adm_area_en", f.AdmAreaEn},
+		{"district", f.District}, {"district_en", f.DistrictEn},
+		{"name", f.Name}, {"name_en", f.NameEn},
+	} {
+		if field.value != nil {
+			parts = append(parts, field.name+"="+strconv.Quote(*field.value))
+		}
+	}
+	if f.CarCapacityMin != nil {
+		parts = append(parts, "car_capacity_min="+strconv.Itoa(*f.CarCapacityMin))
+	}
+	if f.CarCapacityMax != nil {
+		parts = append(parts, "car_capacity_max="+strconv.Itoa(*f.CarCapacityMax))
+	}
+	if f.Near != nil {
+		parts = append(parts, fmt.Sprintf("near=%g,%g,%g", f.Near.Longitude, f.Near.Latitude, f.Near.Radius))
+	}
+	return strings.Join(parts, "&")
+}
+
+// FilterFinder is implemented by stores which filter records by fields which are not lookup keys
+type FilterFinder interface {
+	// FindFiltered returns page of records of list key, or of all records if it is empty, matching filter,
+	// totalSize is number of all matching records. ErrFilterUnsupported is returned if store could not filter now
+	FindFiltered(ctx context.Context, listKey string, filter Filter,
+		paginationSize, offset int64) (infoList structs.InfoList, totalSize int64, err error)
+}
+
+// PointerKeys returns keys pointing to info
+func PointerKeys(info structs.Info) []string {
+	return []string{
+		fmt.Sprintf("global_id:%d", info.GlobalID),
+		fmt.Sprintf("id:%d", info.ID),
+		fmt.Sprintf("id_en:%d", info.IDEn),
+	}
+}
+
+// ListKeys returns keys of lists containing info
+func ListKeys(info structs.Info) []string {
+	return []string{
+		fmt.Sprintf("mode:%s", info.Mode),
+		fmt.Sprintf("mode_en:%s", info.ModeEn),
+	}
+}
+
+// pointerPrefixes are prefixes of keys returned by PointerKeys
+var pointerPrefixes = []string{"global_id:", "id:", "id_en:"}
+
+// IsPointerKey reports whether key points to record instead of being its system_object_id,
+// that is it has prefix of pointer keys and integer after it, system_object_id may contain colons
+func IsPointerKey(key string) bool {
+	for _, prefix := range pointerPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			_, err := strconv.ParseInt(key[len(prefix):], 10, 64)
+			return err == nil
+		}
+	}
+	return false
+}
@@ -0,0 +1,422 @@
+// Package storagetest is conformance test suite of storage.Store implementations
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"golang-developer-test-task/infrastructure/storage"
+	"golang-developer-test-task/structs"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Run tests store returned by newStore, every subtest gets new empty store
+func Run(t *testing.T, newStore func(t *testing.T) storage.Store) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s storage.Store)
+	}{
+		{"Empty", testEmpty},
+		{"AddValues", testAddValues},
+		{"AddValuesAgain", testAddValuesAgain},
+		{"FindValuesPagination", testFindValuesPagination},
+		{"FindValuesByKeys", testFindValuesByKeys},
+		{"ColonInID", testColonInID},
+		{"ReplaceValue", testReplaceValue},
+		{"ReplaceValueTakenPointer", testReplaceValueTakenPointer},
+		{"DeleteValue", testDeleteValue},
+		{"DatasetVersion", testDatasetVersion},
+		{"APIKeys", testAPIKeys},
+		{"RateLimit", testRateLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStore(t))
+		})
+	}
+}
+
+// Infos returns records of test dataset, every even record has mode "a" and odd one has mode "b"
+func Infos(n int) structs.InfoList {
+	infos := make(structs.InfoList, n)
+	for i := range infos {
+		mode := "a"
+		if i%2 == 1 {
+			mode = "b"
+		}
+		infos[i] = structs.Info{
+			GlobalID:       1000 + i,
+			SystemObjectID: string(rune('A'+i%26)) + string(rune('0'+i/26)),
+			ID:             i + 1,
+			IDEn:           2000 + i,
+			Name:           "parking",
+			Mode:           mode,
+			ModeEn:         mode + "_en",
+		}
+	}
+	return infos
+}
+
+func add(t *testing.T, s storage.Store, infos structs.InfoList) {
+	t.Helper()
+	if err := s.AddValues(context.Background(), infos); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkFound checks that key points to want
+func checkFound(t *testing.T, s storage.Store, key string, want structs.Info) {
+	t.Helper()
+	infoList, size, err := s.FindValues(context.Background(), key, false, 0, 0)
+	if err != nil {
+		t.Fatalf("%s: %v", key, err)
+	}
+	if size != 1 || len(infoList) != 1 || !reflect.DeepEqual(infoList[0], want) {
+		t.Errorf("%s: got %v of size %d but wanted %v", key, infoList, size, want)
+	}
+}
+
+func checkNotFound(t *testing.T, s storage.Store, key string) {
+	t.Helper()
+	infoList, _, err := s.FindValues(context.Background(), key, false, 0, 0)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("%s: got %v and error %v but wanted %v", key, infoList, err, storage.ErrNotFound)
+	}
+}
+
+// checkList checks that list key contains records of system_object_ids in order
+func checkList(t *testing.T, s storage.Store, key string, want ...string) {
+	t.Helper()
+	infoList, size, err := s.FindValues(context.Background(), key, true, 100, 0)
+	if err != nil {
+		t.Fatalf("%s: %v", key, err)
+	}
+	got := make([]string, 0, len(infoList))
+	for _, info := range infoList {
+		got = append(got, info.SystemObjectID)
+	}
+	if size != int64(len(want)) || len(want) != len(got) || (len(want) > 0 && !reflect.DeepEqual(got, want)) {
+		t.Errorf("%s: got %v of size %d but wanted %v", key, got, size, want)
+	}
+}
+
+func checkRecords(t *testing.T, s storage.Store, want int64) {
+	t.Helper()
+	stats, err := s.Stats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Records != want {
+		t.Errorf("got %d records but wanted %d", stats.Records, want)
+	}
+}
+
+func testEmpty(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	if err := s.Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+	checkNotFound(t, s, "A0")
+	checkNotFound(t, s, "global_id:1000")
+	checkList(t, s, "mode:a")
+	if _, err := s.GetDatasetVersion(ctx); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("got error %v but wanted %v", err, storage.ErrNotFound)
+	}
+	if err := s.DeleteValue(ctx, "A0"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("got error %v but wanted %v", err, storage.ErrNotFound)
+	}
+	stats, err := s.Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats != (storage.Stats{}) {
+		t.Errorf("got stats %+v of empty store", stats)
+	}
+}
+
+func testAddValues(t *testing.T, s storage.Store) {
+	infos := Infos(3)
+	add(t, s, infos[:1])
+	add(t, s, infos[1:])
+	for _, info := range infos {
+		checkFound(t, s, info.SystemObjectID, info)
+		for _, key := range storage.PointerKeys(info) {
+			checkFound(t, s, key, info)
+		}
+	}
+	checkList(t, s, "mode:a", "A0", "C0")
+	checkList(t, s, "mode_en:b_en", "B0")
+	checkNotFound(t, s, "id:4")
+	checkRecords(t, s, 3)
+}
+
+func testAddValuesAgain(t *testing.T, s storage.Store) {
+	infos := Infos(3)
+	add(t, s, infos)
+
+	changed := infos[0]
+	changed.GlobalID = 5000
+	changed.Mode = "b"
+	// B0 is added twice in one call, the last version of it is kept
+	twice := infos[1]
+	twice.Name = "twice"
+	add(t, s, structs.InfoList{infos[1], changed, infos[2], twice})
+	checkFound(t, s, "A0", changed)
+	checkFound(t, s, "global_id:5000", changed)
+	checkNotFound(t, s, "global_id:1000")
+	checkFound(t, s, "id:2", twice)
+	checkList(t, s, "mode:a", "C0")
+	checkList(t, s, "mode:b", "B0", "A0")
+	checkList(t, s, "mode_en:a_en", "A0", "C0")
+	checkList(t, s, "mode_en:b_en", "B0")
+	checkRecords(t, s, 3)
+}
+
+func testFindValuesPagination(t *testing.T, s storage.Store) {
+	add(t, s, Infos(10))
+	ctx := context.Background()
+	tests := []struct {
+		size, offset int64
+		want         []string
+	}{
+		{2, 0, []string{"A0", "C0"}},
+		{2, 3, []string{"G0", "I0"}},
+		{10, 4, []string{"I0"}},
+		{2, 5, nil},
+		{0, 0, nil},
+	}
+	for _, tt := range tests {
+		infoList, size, err := s.FindValues(ctx, "mode:a", true, tt.size, tt.offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, info := range infoList {
+			got = append(got, info.SystemObjectID)
+		}
+		if size != 5 || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("size %d, offset %d: got %v of size %d but wanted %v of size 5",
+				tt.size, tt.offset, got, size, tt.want)
+		}
+	}
+}
+
+func testFindValuesByKeys(t *testing.T, s storage.Store) {
+	infos := Infos(3)
+	add(t, s, infos)
+	keys := []string{"C0", "global_id:1000", "missing", "id:2", "id_en:999", "mode:a"}
+	got, err := s.FindValuesByKeys(context.Background(), keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*structs.Info{&infos[2], &infos[0], nil, &infos[1], nil, nil}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v but wanted %v", got, want)
+	}
+	if got, err = s.FindValuesByKeys(context.Background(), nil); err != nil || len(got) != 0 {
+		t.Errorf("got %v and error %v of no keys", got, err)
+	}
+}
+
+// testColonInID checks that system_object_id with colon is not taken for pointer key
+func testColonInID(t *testing.T, s storage.Store) {
+	info := Infos(1)[0]
+	info.SystemObjectID = "north:7"
+	add(t, s, structs.InfoList{info})
+	checkFound(t, s, "north:7", info)
+	checkFound(t, s, "global_id:1000", info)
+	checkList(t, s, "mode:a", "north:7")
+	got, err := s.FindValuesByKeys(context.Background(), []string{"north:7", "id:1", "north:8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []*structs.Info{&info, &info, nil}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v but wanted %v", got, want)
+	}
+	if err = s.DeleteValue(context.Background(), "north:7"); err != nil {
+		t.Fatal(err)
+	}
+	checkNotFound(t, s, "north:7")
+	checkNotFound(t, s, "global_id:1000")
+}
+
+func testReplaceValue(t *testing.T, s storage.Store) {
+	infos := Infos(3)
+	add(t, s, infos)
+	ctx := context.Background()
+
+	replaced := infos[0]
+	replaced.GlobalID = 5000
+	replaced.Mode = "b"
+	replaced.Name = "replaced"
+	if err := s.ReplaceValue(ctx, replaced); err != nil {
+		t.Fatal(err)
+	}
+	checkFound(t, s, "A0", replaced)
+	checkFound(t, s, "global_id:5000", replaced)
+	checkFound(t, s, "id:1", replaced)
+	checkNotFound(t, s, "global_id:1000")
+	checkList(t, s, "mode:a", "C0")
+	checkList(t, s, "mode:b", "B0", "A0")
+	// unchanged lists keep order
+	checkList(t, s, "mode_en:a_en", "A0", "C0")
+	checkRecords(t, s, 3)
+
+	added := Infos(4)[3]
+	if err := s.ReplaceValue(ctx, added); err != nil {
+		t.Fatal(err)
+	}
+	checkFound(t, s, "id_en:2003", added)
+	checkList(t, s, "mode:b", "B0", "A0", "D0")
+	checkRecords(t, s, 4)
+}
+
+func testReplaceValueTakenPointer(t *testing.T, s storage.Store) {
+	infos := Infos(2)
+	add(t, s, infos)
+	ctx := context.Background()
+
+	// the second record takes over global_id of the first one
+	second := infos[1]
+	second.GlobalID = infos[0].GlobalID
+	if err := s.ReplaceValue(ctx, second); err != nil {
+		t.Fatal(err)
+	}
+	first := infos[0]
+	first.GlobalID = 7000
+	if err := s.ReplaceValue(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	checkFound(t, s, "global_id:1000", second)
+	checkFound(t, s, "global_id:7000", first)
+	checkNotFound(t, s, "global_id:1001")
+}
+
+func testDeleteValue(t *testing.T, s storage.Store) {
+	infos := Infos(3)
+	add(t, s, infos)
+	ctx := context.Background()
+	if err := s.DeleteValue(ctx, "A0"); err != nil {
+		t.Fatal(err)
+	}
+	checkNotFound(t, s, "A0")
+	for _, key := range storage.PointerKeys(infos[0]) {
+		checkNotFound(t, s, key)
+	}
+	checkList(t, s, "mode:a", "C0")
+	checkList(t, s, "mode_en:a_en", "C0")
+	checkFound(t, s, "C0", infos[2])
+	checkRecords(t, s, 2)
+	if err := s.DeleteValue(ctx, "A0"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("got error %v but wanted %v", err, storage.ErrNotFound)
+	}
+
+	if err := s.DeleteValue(ctx, "C0"); err != nil {
+		t.Fatal(err)
+	}
+	checkList(t, s, "mode:a")
+	checkRecords(t, s, 1)
+}
+
+func testDatasetVersion(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	modified := time.Date(2022, 5, 1, 12, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	if _, err := s.BumpDatasetVersion(ctx, modified.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	version, err := s.BumpDatasetVersion(ctx, modified)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := storage.DatasetVersion{Version: 2, LastModified: modified.UTC()}
+	if version != want {
+		t.Errorf("got version %+v but wanted %+v", version, want)
+	}
+	if version, err = s.GetDatasetVersion(ctx); err != nil || version != want {
+		t.Errorf("got version %+v and error %v but wanted %+v", version, err, want)
+	}
+	stats, err := s.Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Dataset != want {
+		t.Errorf("got stats %+v but wanted dataset %+v", stats, want)
+	}
+}
+
+func testAPIKeys(t *testing.T, keys storage.Store) {
+	ctx := context.Background()
+	if _, err := keys.GetAPIKey(ctx, "abc"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("got error %v but wanted %v", err, storage.ErrNotFound)
+	}
+
+	key := storage.APIKey{ID: "abc", Hash: "hash", Role: "admin", CreatedAt: time.Date(2022, 9, 1, 12, 0, 0, 42, time.UTC)}
+	if err := keys.SaveAPIKey(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	got, err := keys.GetAPIKey(ctx, "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != key.ID || got.Hash != key.Hash || got.Role != key.Role || !got.CreatedAt.Equal(key.CreatedAt) {
+		t.Errorf("got key %v but wanted %v", got, key)
+	}
+
+	if err = keys.DeleteAPIKey(ctx, "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = keys.GetAPIKey(ctx, "abc"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("got error %v but wanted %v", err, storage.ErrNotFound)
+	}
+	if err = keys.DeleteAPIKey(ctx, "abc"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("got error %v but wanted %v", err, storage.ErrNotFound)
+	}
+}
+
+func testRateLimit(t *testing.T, counters storage.Store) {
+	ctx := context.Background()
+	window := time.Minute
+	start := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	allow := func(key string, at time.Duration) storage.RateLimitResult {
+		t.Helper()
+		result, err := counters.AllowRequest(ctx, key, 3, window, start.Add(at))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	for i := 0; i < 3; i++ {
+		if !allow("search:ip:127.0.0.1", time.Duration(i)*time.Second).Allowed {
+			t.Fatalf("request %d is rejected", i)
+		}
+	}
+
+	result := allow("search:ip:127.0.0.1", 30*time.Second)
+	if result.Allowed {
+		t.Fatal("request over the limit is allowed")
+	}
+	// 3 requests of the window weigh less than 3 after 20 seconds of the next window
+	if result.RetryAfter != 50*time.Second {
+		t.Errorf("got retry after %s but wanted %s", result.RetryAfter, 50*time.Second)
+	}
+	if !allow("search:ip:127.0.0.2", 30*time.Second).Allowed {
+		t.Errorf("request of other client is rejected")
+	}
+
+	// rejected requests are not counted, so the client is allowed once the window slides
+	for _, tt := range []struct {
+		at      time.Duration
+		allowed bool
+	}{
+		{79 * time.Second, false},
+		{81 * time.Second, true},
+		{82 * time.Second, false},
+		// both windows of the client expired
+		{200 * time.Second, true},
+	} {
+		if got := allow("search:ip:127.0.0.1", tt.at).Allowed; got != tt.allowed {
+			t.Errorf("%s: got allowed %v but wanted %v", tt.at, got, tt.allowed)
+		}
+	}
+}
@@ -2,14 +2,19 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"golang-developer-test-task/infrastructure/redclient"
-	"golang-developer-test-task/structs"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
 	"runtime"
 	"strconv"
+	"syscall"
 	"time"
 
-	"github.com/jellydator/ttlcache/v3"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"golang.org/x/sync/singleflight"
@@ -58,7 +63,9 @@ func (r *StatusRecorder) WriteHeader(status int) {
 	r.ResponseWriter.WriteHeader(status)
 }
 
-func timeTrackingMiddleware(next http.Handler) http.Handler {
+// timeTrackingMiddleware counts requests and their timings by route pattern,
+// so that paths given by clients do not create new series
+func timeTrackingMiddleware(router *Router, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		recorder := &StatusRecorder{
@@ -68,16 +75,15 @@ func timeTrackingMiddleware(next http.Handler) http.Handler {
 
 		next.ServeHTTP(recorder, r)
 
-		// TODO
-		// r.URL.Path приходит от юзера! не делайте так в проде!
+		pattern := router.Pattern(r)
 		status := strconv.Itoa(recorder.Status)
-		statusCounter.WithLabelValues(r.URL.Path, status).
+		statusCounter.WithLabelValues(pattern, status).
 			Inc()
 		timings.
-			WithLabelValues(r.URL.Path).
+			WithLabelValues(pattern).
 			Observe(time.Since(start).Seconds())
 		counter.
-			WithLabelValues(r.URL.Path).
+			WithLabelValues(pattern).
 			Inc()
 	})
 }
@@ -113,35 +119,95 @@ func main() {
 	prometheus.MustRegister(timings)
 	prometheus.MustRegister(counter)
 
-	port := "8080"
+	conf, err := LoadConfig(os.Args[0], os.Args[1:], os.LookupEnv, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if conf != nil && conf.printConfig {
+		if printErr := conf.Print(os.Stdout); printErr != nil {
+			panic(printErr)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if conf.printConfig {
+		return
+	}
 
 	logger, err := zap.NewProduction()
 	if err != nil {
 		panic(err)
 	}
 	defer func() {
-		err = logger.Sync()
+		// syncing stderr of terminal fails with EINVAL, there is nothing to report it to anyway
+		_ = logger.Sync()
 	}()
 
-	ctx := context.Background()
-	conf := redclient.RedisConfig{}
-	conf.Load()
-
-	client := redclient.NewRedisClient(ctx, conf)
-	defer func() {
-		err = client.Close()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	// the server is started before Redis is reachable, readiness check reports it meanwhile,
+	// client connects only when it is used
+	var client *redclient.RedisClient
+	if conf.Storage.usesRedis() {
+		client, err = redclient.NewLazyRedisClient(conf.Redis)
 		if err != nil {
-			panic(err)
+			logger.Fatal("creating redis client", zap.Error(err))
+		}
+		defer func() {
+			if err := client.Close(); err != nil {
+				logger.Error("during closing redis client", zap.Error(err))
+			}
+		}()
+	}
+	store, closeStore, err := openStore(conf.Storage, client)
+	if err != nil {
+		logger.Fatal("opening storage", zap.String("backend", conf.Storage.Backend), zap.Error(err))
+	}
+	defer func() {
+		if err := closeStore(); err != nil {
+			logger.Error("during closing storage", zap.Error(err))
 		}
 	}()
+	switch conf.Storage.Backend {
+	case StorageRedis, StorageRediSearch:
+		go func() {
+			err := client.WaitConnected(ctx, redclient.DefaultBackoff(), func(attempt int, err error, delay time.Duration) {
+				logger.Warn("redis is unavailable, connection is retried",
+					zap.Int("attempt", attempt), zap.Duration("delay", delay), zap.Error(err))
+			})
+			if err != nil {
+				logger.Error("redis is not connected before shutdown", zap.Error(err))
+				return
+			}
+			logger.Info("redis is connected", zap.String("addr", conf.Redis.Addr))
+			if err = client.LoadScripts(ctx); err != nil {
+				logger.Error("during loading lua scripts", zap.Error(err))
+				return
+			}
+			if conf.Storage.Backend != StorageRedis {
+				return
+			}
+			migrated, err := client.MigrateRecords(ctx)
+			if err != nil {
+				logger.Error("during migrating records to hashes", zap.Error(err))
+				return
+			}
+			if migrated > 0 {
+				logger.Info("records are migrated to hashes", zap.Int("records", migrated))
+			}
+		}()
+	case StorageMemory:
+		logger.Warn("dataset and api keys are kept in memory and lost on restart")
+	}
 
 	s := &singleflight.Group{}
 
-	timeout := 5 * time.Minute
-
-	cache := ttlcache.New[string, structs.PaginationObject](
-		ttlcache.WithTTL[string, structs.PaginationObject](timeout))
+	cache := NewSearchCache(conf.Cache)
 	go cache.Start()
+	defer cache.Stop()
+	prometheus.MustRegister(NewCacheCollectors(cache, conf.Cache)...)
 
 	// respCache := ttlcache.New[string, string](
 	//	ttlcache.WithTTL[string, string](timeout))
@@ -160,28 +226,39 @@ func main() {
 	//}
 
 	// dbLogic := NewDBProcessor(client, logger, s, cache, pool, pool1)
-	dbLogic := NewDBProcessor(client, logger, s, cache)
-	mux := http.NewServeMux()
-
-	mux.Handle("/metrics", promhttp.Handler())
-
-	mux.HandleFunc("/api/load_file", dbLogic.HandleLoadFile)
-
-	mux.HandleFunc("/api/load_from_url", dbLogic.HandleLoadFromURL)
-
-	mux.HandleFunc("/api/load_from_json", dbLogic.HandleLoadJSON)
-
-	//https://nimblehq.co/blog/getting-started-with-redisearch
-	mux.HandleFunc("/api/search", dbLogic.HandleSearch)
-
-	mux.HandleFunc("/", dbLogic.HandleMainPage)
-
-	wrappedHandler := timeTrackingMiddleware(mux)
-	// wrappedHandler := Gzip(timeTrackingMiddleware(mux))
-	// wrappedHandler := timeTrackingMiddleware(Gzip(mux))
-
-	err = http.ListenAndServe(":"+port, wrappedHandler)
+	if conf.CSRF.Secret == "" && !conf.Auth.Enabled {
+		logger.Warn("csrf secret is not set, upload form tokens are signed with random secret")
+	}
+	dbLogic := NewDBProcessor(store, logger, s, cache,
+		WithCSRFProtector(NewCSRFProtector(conf.CSRF)),
+		WithUploadForm(!conf.Auth.Enabled),
+		WithURLFetcher(NewURLFetcher(conf.Fetch)),
+		WithBodyLimits(conf.Limits),
+		WithDatasetVersionTTL(conf.Cache.VersionTTL))
+	auth := NewAuthenticator(store, conf.Auth, dbLogic.writeError)
+	limiter := NewRateLimiter(store, conf.RateLimit, logger, dbLogic.writeError)
+	prometheus.MustRegister(limiter.Collector())
+	health := NewHealth(store, conf.Health)
+	router := NewAPIRouter(dbLogic, auth, limiter, health, promhttp.Handler())
+
+	wrappedHandler := timeTrackingMiddleware(router, requestIDMiddleware(router))
+	// wrappedHandler := Gzip(timeTrackingMiddleware(router))
+	// wrappedHandler := timeTrackingMiddleware(Gzip(router))
+
+	listener, err := net.Listen("tcp", ":"+strconv.Itoa(conf.Server.Port))
 	if err != nil {
 		panic(err)
 	}
+	server := &http.Server{
+		Handler:           wrappedHandler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	logger.Info("server is started", zap.String("addr", listener.Addr().String()))
+	err = serve(ctx, server, listener, conf.Server, health.SetShuttingDown, dbLogic.WaitImports)
+	if err != nil {
+		// deferred cleanup is still done, so the error is logged instead of panic
+		logger.Error("during server shutdown", zap.Error(err))
+		return
+	}
+	logger.Info("server is stopped")
 }
@@ -0,0 +1,17 @@
+package main
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPISpec is OpenAPI 3 document describing routes of NewAPIRouter
+//
+//go:embed static/openapi.json
+var openAPISpec []byte
+
+// HandleOpenAPI is handler for /api/openapi.json
+func (d *DBProcessor) HandleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPISpec)
+}
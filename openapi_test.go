@@ -0,0 +1,454 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"golang-developer-test-task/infrastructure/redclient"
+	"golang-developer-test-task/infrastructure/storage"
+	"golang-developer-test-task/structs"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+type (
+	openAPIDocument struct {
+		Paths      map[string]map[string]openAPIOperation `json:"paths"`
+		Components struct {
+			Schemas   map[string]openAPISchema   `json:"schemas"`
+			Responses map[string]openAPIResponse `json:"responses"`
+		} `json:"components"`
+	}
+
+	openAPIOperation struct {
+		Responses map[string]openAPIResponse `json:"responses"`
+	}
+
+	openAPIResponse struct {
+		Ref     string `json:"$ref"`
+		Content map[string]struct {
+			Schema openAPISchema `json:"schema"`
+		} `json:"content"`
+	}
+
+	openAPISchema struct {
+		Ref        string                     `json:"$ref"`
+		Type       string                     `json:"type"`
+		Required   []string                   `json:"required"`
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+)
+
+var pathParamRe = regexp.MustCompile(`\{[^}]+\}`)
+
+func loadOpenAPI(t *testing.T) openAPIDocument {
+	t.Helper()
+	var doc openAPIDocument
+	if err := json.Unmarshal(openAPISpec, &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// response resolves reference to shared response of components
+func (doc openAPIDocument) response(resp openAPIResponse) openAPIResponse {
+	if name := strings.TrimPrefix(resp.Ref, "#/components/responses/"); name != resp.Ref {
+		return doc.Components.Responses[name]
+	}
+	return resp
+}
+
+// schema resolves reference to schema of components
+func (doc openAPIDocument) schema(schema openAPISchema) openAPISchema {
+	if name := strings.TrimPrefix(schema.Ref, "#/components/schemas/"); name != schema.Ref {
+		return doc.Components.Schemas[name]
+	}
+	return schema
+}
+
+func TestHandleOpenAPI(t *testing.T) {
+	router := newTestRouter()
+	req := httptest.NewRequest("GET", "/api/openapi.json", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("got status %d but wanted %d", res.Code, http.StatusOK)
+	}
+	if got := res.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("got Content-Type %q but wanted %q", got, "application/json")
+	}
+	if !bytes.Equal(res.Body.Bytes(), openAPISpec) {
+		t.Errorf("served document differs from embedded one")
+	}
+}
+
+func TestOpenAPIRoutes(t *testing.T) {
+	doc := loadOpenAPI(t)
+	router := newTestRouter()
+
+	covered := make(map[string]bool)
+	for path, operations := range doc.Paths {
+		req := httptest.NewRequest("GET", pathParamRe.ReplaceAllString(path, "1"), nil)
+		_, pattern := router.mux.Handler(req)
+		if pattern == "" || (pattern == "/" && path != "/") {
+			t.Errorf("%s: path of specification is not routed", path)
+			continue
+		}
+		covered[pattern] = true
+
+		var route Route
+		for _, route = range router.Routes() {
+			if route.Pattern == pattern {
+				break
+			}
+		}
+		var want []string
+		for _, method := range route.Methods {
+			if method != http.MethodHead && method != http.MethodOptions {
+				want = append(want, method)
+			}
+		}
+		got := make([]string, 0, len(operations))
+		for method := range operations {
+			got = append(got, strings.ToUpper(method))
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got methods %v in specification but route accepts %v", path, got, want)
+		}
+	}
+	for _, route := range router.Routes() {
+		if !covered[route.Pattern] {
+			t.Errorf("%s: route is missing in specification", route.Pattern)
+		}
+	}
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	doc := loadOpenAPI(t)
+	types := map[string]reflect.Type{
+		"Info":              reflect.TypeOf(structs.Info{}),
+		"URLObject":         reflect.TypeOf(structs.URLObject{}),
+		"SearchObject":      reflect.TypeOf(structs.SearchObject{}),
+		"BatchSearchObject": reflect.TypeOf(structs.BatchSearchObject{}),
+		"BatchResult":       reflect.TypeOf(structs.BatchResult{}),
+		"PaginationObject":  reflect.TypeOf(structs.PaginationObject{}),
+		"ErrorObject":       reflect.TypeOf(structs.ErrorObject{}),
+		"HealthObject":      reflect.TypeOf(structs.HealthObject{}),
+	}
+	for name, typ := range types {
+		schema, ok := doc.Components.Schemas[name]
+		if !ok {
+			t.Errorf("%s: schema is missing in specification", name)
+			continue
+		}
+		var got, want []string
+		for property := range schema.Properties {
+			got = append(got, property)
+		}
+		for i := 0; i < typ.NumField(); i++ {
+			tag := strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]
+			want = append(want, tag)
+		}
+		sort.Strings(got)
+		sort.Strings(want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got properties %v but struct has fields %v", name, got, want)
+		}
+	}
+}
+
+// checkResponseAgainstSpec checks that status, Content-Type and top-level fields of JSON body
+// of response are described by operation
+func checkResponseAgainstSpec(t *testing.T, doc openAPIDocument, name, path, method string,
+	res *httptest.ResponseRecorder) {
+	t.Helper()
+	operation, ok := doc.Paths[path][strings.ToLower(method)]
+	if !ok {
+		t.Errorf("%s: operation %s %s is missing in specification", name, method, path)
+		return
+	}
+	resp, ok := operation.Responses[strconv.Itoa(res.Code)]
+	if !ok {
+		t.Errorf("%s: status %d is missing in specification of %s %s", name, res.Code, method, path)
+		return
+	}
+	resp = doc.response(resp)
+	if len(resp.Content) == 0 {
+		return
+	}
+	contentType := res.Header().Get("Content-Type")
+	media, ok := resp.Content[contentType]
+	if !ok {
+		t.Errorf("%s: Content-Type %q is missing in specification of %s %s %d",
+			name, contentType, method, path, res.Code)
+		return
+	}
+	schema := doc.schema(media.Schema)
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "application/json" || schema.Type != "object" ||
+		schema.Properties == nil {
+		return
+	}
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Errorf("%s: body is not JSON object: %v", name, err)
+		return
+	}
+	for field := range body {
+		if _, ok := schema.Properties[field]; !ok {
+			t.Errorf("%s: field %q is missing in specification", name, field)
+		}
+	}
+	for _, field := range schema.Required {
+		if _, ok := body[field]; !ok {
+			t.Errorf("%s: required field %q is missing in body", name, field)
+		}
+	}
+}
+
+func TestOpenAPIResponses(t *testing.T) {
+	doc := loadOpenAPI(t)
+
+	mr := miniredis.RunT(t)
+	client := redclient.NewRedisClient(context.Background(), redclient.RedisConfig{Addr: mr.Addr()})
+	infos := structs.InfoList{
+		{GlobalID: 100, SystemObjectID: "777", ID: 1, IDEn: 1, Mode: "abc", ModeEn: "cba"},
+		{GlobalID: 101, SystemObjectID: "778", ID: 2, IDEn: 2, Mode: "abc", ModeEn: "cba"},
+	}
+	if err := client.AddValues(context.Background(), infos); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.BumpDatasetVersion(context.Background(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	dataset, _ := json.Marshal(infos)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/data.json" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(dataset)
+	}))
+	defer upstream.Close()
+
+	multipartBody := func(field string, content []byte) (string, []byte) {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreateFormFile(field, "data.json")
+		_, _ = part.Write(content)
+		_ = writer.Close()
+		return writer.FormDataContentType(), body.Bytes()
+	}
+	fileType, fileBody := multipartBody("uploadFile", dataset)
+	wrongFileType, wrongFileBody := multipartBody("wrongFileName", dataset)
+	brokenFileType, brokenFileBody := multipartBody("uploadFile", []byte("{"))
+
+	logger, _ := zap.NewProduction()
+	defer func() {
+		_ = logger.Sync()
+	}()
+	cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+	processor := NewDBProcessor(client, logger, &singleflight.Group{}, cache,
+		WithURLFetcher(NewURLFetcher(FetchConfig{AllowPrivate: true})))
+	metrics := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	})
+	adminKey := strings.Repeat("a", minAdminKeyLength)
+	auth := NewAuthenticator(client, AuthConfig{Enabled: true, AdminKey: adminKey}, processor.writeError)
+	limiter := NewRateLimiter(client, RateLimitConfig{}, logger, processor.writeError)
+	health := NewHealth(client, HealthConfig{ReadyTimeout: time.Second})
+	handler := requestIDMiddleware(NewAPIRouter(processor, auth, limiter, health, metrics))
+
+	searchKey := storage.APIKey{ID: "0123456789abcdef", Hash: hashAPIKeySecret("secret"), Role: RoleSearch}
+	revokedKey := storage.APIKey{ID: "fedcba9876543210", Hash: hashAPIKeySecret("secret"), Role: RoleAdmin}
+	for _, key := range []storage.APIKey{searchKey, revokedKey} {
+		if err := client.SaveAPIKey(context.Background(), key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	type scenario struct {
+		name        string
+		method      string
+		path        string
+		target      string
+		contentType string
+		body        string
+		headers     map[string]string
+		status      int
+	}
+	run := func(scenarios []scenario) {
+		for _, sc := range scenarios {
+			var body io.Reader
+			if sc.body != "" {
+				body = strings.NewReader(sc.body)
+			}
+			req := httptest.NewRequest(sc.method, sc.target, body)
+			if sc.contentType != "" {
+				req.Header.Set("Content-Type", sc.contentType)
+			}
+			// scenarios are run with admin key unless they pass their own credentials
+			req.Header.Set(APIKeyHeader, adminKey)
+			for key, value := range sc.headers {
+				req.Header.Set(key, value)
+			}
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+
+			if res.Code != sc.status {
+				t.Errorf("%s: got status %d but wanted %d", sc.name, res.Code, sc.status)
+			}
+			checkResponseAgainstSpec(t, doc, sc.name, sc.path, sc.method, res)
+		}
+	}
+
+	run([]scenario{
+		{name: "main page", method: "GET", path: "/", target: "/", status: http.StatusOK},
+		{name: "metrics", method: "GET", path: "/metrics", target: "/metrics", status: http.StatusOK},
+		{name: "liveness", method: "GET", path: "/healthz", target: "/healthz", status: http.StatusOK},
+		{name: "readiness", method: "GET", path: "/readyz", target: "/readyz", status: http.StatusOK},
+		{name: "openapi", method: "GET", path: "/api/openapi.json", target: "/api/openapi.json", status: http.StatusOK},
+
+		{name: "admin create key", method: "POST", path: "/api/admin/keys", target: "/api/admin/keys",
+			body: `{"role":"search"}`, status: http.StatusCreated},
+		{name: "admin create key invalid role", method: "POST", path: "/api/admin/keys", target: "/api/admin/keys",
+			body: `{"role":"root"}`, status: http.StatusUnprocessableEntity},
+		{name: "admin create key broken json", method: "POST", path: "/api/admin/keys", target: "/api/admin/keys",
+			body: `{"role":`, status: http.StatusBadRequest},
+		{name: "admin create key wrong content type", method: "POST", path: "/api/admin/keys",
+			target: "/api/admin/keys", contentType: "text/plain", body: `{}`, status: http.StatusUnsupportedMediaType},
+		{name: "admin create key by search key", method: "POST", path: "/api/admin/keys", target: "/api/admin/keys",
+			headers: map[string]string{APIKeyHeader: searchKey.ID + ".secret"}, body: `{"role":"admin"}`,
+			status: http.StatusForbidden},
+		{name: "admin revoke key", method: "DELETE", path: "/api/admin/keys/{id}",
+			target: "/api/admin/keys/" + revokedKey.ID, status: http.StatusNoContent},
+		{name: "admin revoke missing key", method: "DELETE", path: "/api/admin/keys/{id}",
+			target: "/api/admin/keys/" + revokedKey.ID, status: http.StatusNotFound},
+		{name: "admin revoke without key", method: "DELETE", path: "/api/admin/keys/{id}",
+			target: "/api/admin/keys/" + searchKey.ID, headers: map[string]string{APIKeyHeader: ""},
+			status: http.StatusUnauthorized},
+
+		{name: "search get by search key", method: "GET", path: "/api/search", target: "/api/search?mode=abc",
+			headers: map[string]string{APIKeyHeader: "", "Authorization": "Bearer " + searchKey.ID + ".secret"},
+			status:  http.StatusOK},
+		{name: "search get by revoked key", method: "GET", path: "/api/search", target: "/api/search?mode=abc",
+			headers: map[string]string{APIKeyHeader: revokedKey.ID + ".secret"}, status: http.StatusUnauthorized},
+		{name: "search get", method: "GET", path: "/api/search", target: "/api/search?mode=abc&limit=1",
+			status: http.StatusOK},
+		{name: "search get not modified", method: "GET", path: "/api/search", target: "/api/search?mode=abc",
+			headers: map[string]string{"If-None-Match": "*"}, status: http.StatusNotModified},
+		{name: "search get bad param", method: "GET", path: "/api/search", target: "/api/search?id=abc",
+			status: http.StatusBadRequest},
+		{name: "search get repeated param", method: "GET", path: "/api/search", target: "/api/search?mode=abc&limit=0&limit=1",
+			status: http.StatusBadRequest},
+		{name: "search get no key", method: "GET", path: "/api/search", target: "/api/search?offset=1",
+			status: http.StatusUnprocessableEntity},
+		{name: "search post", method: "POST", path: "/api/search", target: "/api/search",
+			contentType: "application/json", body: `{"system_object_id":"777"}`, status: http.StatusOK},
+		{name: "search post broken json", method: "POST", path: "/api/search", target: "/api/search",
+			body: `{`, status: http.StatusBadRequest},
+		{name: "search post wrong content type", method: "POST", path: "/api/search", target: "/api/search",
+			contentType: "text/plain", body: `{}`, status: http.StatusUnsupportedMediaType},
+		{name: "search post invalid", method: "POST", path: "/api/search", target: "/api/search",
+			body: `{"mode":"abc","limit":1000}`, status: http.StatusUnprocessableEntity},
+
+		{name: "batch", method: "POST", path: "/api/search/batch", target: "/api/search/batch",
+			body: `{"system_object_id":["777","999"],"global_id":[101]}`, status: http.StatusOK},
+		{name: "batch broken json", method: "POST", path: "/api/search/batch", target: "/api/search/batch",
+			body: `[`, status: http.StatusBadRequest},
+		{name: "batch wrong content type", method: "POST", path: "/api/search/batch", target: "/api/search/batch",
+			contentType: "text/plain", body: `{}`, status: http.StatusUnsupportedMediaType},
+		{name: "batch empty", method: "POST", path: "/api/search/batch", target: "/api/search/batch",
+			body: `{}`, status: http.StatusUnprocessableEntity},
+
+		{name: "parking", method: "GET", path: "/api/parkings/{system_object_id}", target: "/api/parkings/777",
+			status: http.StatusOK},
+		{name: "parking not modified", method: "GET", path: "/api/parkings/{system_object_id}",
+			target: "/api/parkings/777", headers: map[string]string{"If-None-Match": "*"}, status: http.StatusNotModified},
+		{name: "parking missing", method: "GET", path: "/api/parkings/{system_object_id}", target: "/api/parkings/999",
+			status: http.StatusNotFound},
+		{name: "parking by global id", method: "GET", path: "/api/parkings/by-global-id/{id}",
+			target: "/api/parkings/by-global-id/100", status: http.StatusOK},
+		{name: "parking by global id bad id", method: "GET", path: "/api/parkings/by-global-id/{id}",
+			target: "/api/parkings/by-global-id/abc", status: http.StatusBadRequest},
+		{name: "parking by id", method: "GET", path: "/api/parkings/by-id/{id}", target: "/api/parkings/by-id/2",
+			status: http.StatusOK},
+		{name: "parking by id missing", method: "GET", path: "/api/parkings/by-id/{id}", target: "/api/parkings/by-id/3",
+			status: http.StatusNotFound},
+		{name: "parking by id en", method: "GET", path: "/api/parkings/by-id-en/{id}",
+			target: "/api/parkings/by-id-en/1", status: http.StatusOK},
+		{name: "parking by id en bad id", method: "GET", path: "/api/parkings/by-id-en/{id}",
+			target: "/api/parkings/by-id-en/1.5", status: http.StatusBadRequest},
+
+		{name: "load json by search key", method: "POST", path: "/api/load_json", target: "/api/load_json",
+			headers: map[string]string{APIKeyHeader: searchKey.ID + ".secret"}, body: string(dataset),
+			status: http.StatusForbidden},
+		{name: "load json without key", method: "POST", path: "/api/load_json", target: "/api/load_json",
+			headers: map[string]string{APIKeyHeader: ""}, body: string(dataset), status: http.StatusUnauthorized},
+		{name: "load json", method: "POST", path: "/api/load_json", target: "/api/load_json",
+			contentType: "application/json", body: string(dataset), status: http.StatusOK},
+		{name: "load json wrong content type", method: "POST", path: "/api/load_json", target: "/api/load_json",
+			contentType: "text/plain", body: string(dataset), status: http.StatusUnsupportedMediaType},
+		{name: "load json invalid", method: "POST", path: "/api/load_json", target: "/api/load_json",
+			body: `{"url":""}`, status: http.StatusUnprocessableEntity},
+		{name: "load from json", method: "POST", path: "/api/load_from_json", target: "/api/load_from_json",
+			body: string(dataset), status: http.StatusOK},
+
+		{name: "load from url", method: "POST", path: "/api/load_from_url", target: "/api/load_from_url",
+			body: `{"url":"` + upstream.URL + `/data.json"}`, status: http.StatusOK},
+		{name: "load from url upstream error", method: "POST", path: "/api/load_from_url", target: "/api/load_from_url",
+			body: `{"url":"` + upstream.URL + `/missing.json"}`, status: http.StatusBadGateway},
+		{name: "load from url broken json", method: "POST", path: "/api/load_from_url", target: "/api/load_from_url",
+			body: `{"url":`, status: http.StatusBadRequest},
+		{name: "load from url invalid url", method: "POST", path: "/api/load_from_url", target: "/api/load_from_url",
+			body: `{"url":"http://[::1"}`, status: http.StatusUnprocessableEntity},
+		{name: "load from url wrong content type", method: "POST", path: "/api/load_from_url",
+			target: "/api/load_from_url", contentType: "text/plain", body: `{}`, status: http.StatusUnsupportedMediaType},
+
+		{name: "load file", method: "POST", path: "/api/load_file", target: "/api/load_file",
+			contentType: fileType, body: string(fileBody), status: http.StatusOK},
+		{name: "load file wrong field", method: "POST", path: "/api/load_file", target: "/api/load_file",
+			contentType: wrongFileType, body: string(wrongFileBody), status: http.StatusBadRequest},
+		{name: "load file broken dataset", method: "POST", path: "/api/load_file", target: "/api/load_file",
+			contentType: brokenFileType, body: string(brokenFileBody), status: http.StatusUnprocessableEntity},
+		{name: "load file not multipart", method: "POST", path: "/api/load_file", target: "/api/load_file",
+			contentType: "application/json", body: string(dataset), status: http.StatusUnsupportedMediaType},
+	})
+
+	mr.Close()
+	run([]scenario{
+		{name: "liveness storage error", method: "GET", path: "/healthz", target: "/healthz", status: http.StatusOK},
+		{name: "readiness storage error", method: "GET", path: "/readyz", target: "/readyz",
+			status: http.StatusServiceUnavailable},
+		{name: "search storage error", method: "GET", path: "/api/search", target: "/api/search?mode_en=cba",
+			status: http.StatusServiceUnavailable},
+		{name: "batch storage error", method: "POST", path: "/api/search/batch", target: "/api/search/batch",
+			body: `{"id":[1]}`, status: http.StatusServiceUnavailable},
+		{name: "parking storage error", method: "GET", path: "/api/parkings/by-id/{id}",
+			target: "/api/parkings/by-id/1", status: http.StatusServiceUnavailable},
+		{name: "auth storage error", method: "POST", path: "/api/load_json", target: "/api/load_json",
+			headers: map[string]string{APIKeyHeader: searchKey.ID + ".secret"}, body: string(dataset),
+			status: http.StatusServiceUnavailable},
+		{name: "admin create key storage error", method: "POST", path: "/api/admin/keys", target: "/api/admin/keys",
+			body: `{"role":"search"}`, status: http.StatusServiceUnavailable},
+		{name: "admin revoke key storage error", method: "DELETE", path: "/api/admin/keys/{id}",
+			target: "/api/admin/keys/" + searchKey.ID, status: http.StatusServiceUnavailable},
+	})
+}
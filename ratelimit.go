@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"golang-developer-test-task/infrastructure/config"
+	"golang-developer-test-task/infrastructure/storage"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	// RateLimitSearch is scope of search routes
+	RateLimitSearch = "search"
+	// RateLimitIngest is scope of loading and admin routes
+	RateLimitIngest = "ingest"
+
+	defaultSearchRateLimit = 600
+	defaultIngestRateLimit = 10
+	defaultRateLimitWindow = time.Minute
+)
+
+// RateLimitConfig is struct for storing rate limiting settings,
+// limits are numbers of requests of one client per Window
+type RateLimitConfig struct {
+	Enabled     bool
+	SearchLimit int64
+	IngestLimit int64
+	Window      time.Duration
+	// TrustForwarded identifies clients by the last address of X-Forwarded-For set by reverse proxy
+	TrustForwarded bool
+}
+
+// settings binds RateLimitConfig fields to config file keys, environment variables and flags
+func (c *RateLimitConfig) settings() []config.Setting {
+	return []config.Setting{
+		{Key: "rate_limit.enabled", Env: []string{"RATE_LIMIT_ENABLED"}, Usage: "limit requests per client",
+			Default: "true", Value: config.Bool(&c.Enabled)},
+		{Key: "rate_limit.search", Env: []string{"RATE_LIMIT_SEARCH"}, Usage: "search requests of one client per window",
+			Default: strconv.Itoa(defaultSearchRateLimit), Value: config.Int64(&c.SearchLimit)},
+		{Key: "rate_limit.ingest", Env: []string{"RATE_LIMIT_INGEST"},
+			Usage:   "loading and admin requests of one client per window",
+			Default: strconv.Itoa(defaultIngestRateLimit), Value: config.Int64(&c.IngestLimit)},
+		{Key: "rate_limit.window", Env: []string{"RATE_LIMIT_WINDOW"}, Usage: "rate limit window",
+			Default: defaultRateLimitWindow.String(), Value: config.Duration(&c.Window)},
+		{Key: "rate_limit.trust_forwarded", Env: []string{"RATE_LIMIT_TRUST_FORWARDED"},
+			Usage:   "identify clients by the last X-Forwarded-For address, only behind reverse proxy",
+			Default: "false", Value: config.Bool(&c.TrustForwarded)},
+	}
+}
+
+// Load is useful for loading RateLimitConfig data from environment
+func (c *RateLimitConfig) Load() error {
+	if err := config.LoadEnv(c.settings()); err != nil {
+		return err
+	}
+	return c.Validate()
+}
+
+// Validate checks that RateLimitConfig values are usable
+func (c *RateLimitConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.SearchLimit <= 0 || c.IngestLimit <= 0 {
+		return fmt.Errorf("rate limits must be positive, got search %d and ingest %d", c.SearchLimit, c.IngestLimit)
+	}
+	if c.Window < time.Millisecond {
+		return fmt.Errorf("rate limit window must be at least 1ms, got %s", c.Window)
+	}
+	return nil
+}
+
+// RateLimiter limits requests per API key or client IP with counters of storage,
+// they are shared by replicas using the same Redis
+type RateLimiter struct {
+	counters   storage.RateLimitStore
+	config     RateLimitConfig
+	logger     *zap.Logger
+	writeError func(http.ResponseWriter, *http.Request, error)
+	rejected   *prometheus.CounterVec
+	now        func() time.Time
+}
+
+// NewRateLimiter is constructor for RateLimiter
+func NewRateLimiter(counters storage.RateLimitStore, config RateLimitConfig, logger *zap.Logger,
+	writeError func(http.ResponseWriter, *http.Request, error)) *RateLimiter {
+	return &RateLimiter{
+		counters:   counters,
+		config:     config,
+		logger:     logger,
+		writeError: writeError,
+		rejected: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rate_limit_rejected_counter",
+				Help: "Requests rejected by rate limiter per scope",
+			},
+			[]string{"scope"}),
+		now: time.Now,
+	}
+}
+
+// Collector returns prometheus collector of rejected requests
+func (l *RateLimiter) Collector() prometheus.Collector {
+	return l.rejected
+}
+
+// Limit rejects requests of clients which exceeded limit of scope with 429,
+// requests are passed if the storage is unavailable
+func (l *RateLimiter) Limit(scope string, next http.Handler) http.Handler {
+	if !l.config.Enabled {
+		return next
+	}
+	limit := l.config.SearchLimit
+	if scope == RateLimitIngest {
+		limit = l.config.IngestLimit
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result, err := l.counters.AllowRequest(r.Context(), scope+":"+l.clientKey(r), limit, l.config.Window, l.now())
+		if err != nil {
+			l.logger.Warn("during rate limiting, request is passed", zap.String("scope", scope), zap.Error(err))
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !result.Allowed {
+			l.rejected.WithLabelValues(scope).Inc()
+			w.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(result.RetryAfter.Seconds())), 10))
+			l.writeError(w, r, newAPIError(KindRateLimited,
+				fmt.Sprintf("rate limit of %d requests per %s is exceeded", limit, l.config.Window), nil))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientKey identifies client by authenticated API key or by IP address
+func (l *RateLimiter) clientKey(r *http.Request) string {
+	if id := APIKeyIDFromContext(r.Context()); id != "" {
+		return "key:" + id
+	}
+	return "ip:" + l.clientIP(r)
+}
+
+func (l *RateLimiter) clientIP(r *http.Request) string {
+	if l.config.TrustForwarded {
+		// the last address is added by our proxy, the previous ones are sent by client
+		forwarded := r.Header.Values("X-Forwarded-For")
+		if len(forwarded) > 0 {
+			addrs := strings.Split(forwarded[len(forwarded)-1], ",")
+			if ip := net.ParseIP(strings.TrimSpace(addrs[len(addrs)-1])); ip != nil {
+				return ip.String()
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
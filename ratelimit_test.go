@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"golang-developer-test-task/infrastructure/redclient"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+)
+
+func TestRateLimitConfigLoad(t *testing.T) {
+	t.Setenv("RATE_LIMIT_ENABLED", "")
+	t.Setenv("RATE_LIMIT_SEARCH", "100")
+	t.Setenv("RATE_LIMIT_INGEST", "")
+	t.Setenv("RATE_LIMIT_WINDOW", "10s")
+	t.Setenv("RATE_LIMIT_TRUST_FORWARDED", "true")
+	var config RateLimitConfig
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	want := RateLimitConfig{Enabled: true, SearchLimit: 100, IngestLimit: defaultIngestRateLimit,
+		Window: 10 * time.Second, TrustForwarded: true}
+	if config != want {
+		t.Errorf("got config %v but wanted %v", config, want)
+	}
+
+	for name, v := range map[string]string{
+		"RATE_LIMIT_SEARCH":  "0",
+		"RATE_LIMIT_INGEST":  "ten",
+		"RATE_LIMIT_WINDOW":  "0s",
+		"RATE_LIMIT_ENABLED": "maybe",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, v)
+			if err := config.Load(); err == nil {
+				t.Errorf("%s=%q: expected error", name, v)
+			}
+		})
+	}
+}
+
+func newTestRateLimiter(t *testing.T, client *redclient.RedisClient, config RateLimitConfig) *RateLimiter {
+	t.Helper()
+	processor := newLimitedTestProcessor(t, DefaultBodyLimits())
+	limiter := NewRateLimiter(client, config, zap.NewNop(), processor.writeError)
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time {
+		return now
+	}
+	return limiter
+}
+
+func TestRateLimiter(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redclient.NewRedisClient(context.Background(), redclient.RedisConfig{Addr: mr.Addr()})
+	limiter := newTestRateLimiter(t, client,
+		RateLimitConfig{Enabled: true, SearchLimit: 2, IngestLimit: 1, Window: time.Minute})
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	search := requestIDMiddleware(limiter.Limit(RateLimitSearch, ok))
+	ingest := requestIDMiddleware(limiter.Limit(RateLimitIngest, ok))
+	serve := func(handler http.Handler, remoteAddr, keyID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/search", nil)
+		req.RemoteAddr = remoteAddr
+		if keyID != "" {
+			req = req.WithContext(context.WithValue(req.Context(), apiKeyIDKey{}, keyID))
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	for i := 0; i < 2; i++ {
+		if res := serve(search, "192.0.2.1:1234", ""); res.Code != http.StatusOK {
+			t.Fatalf("got status %d but wanted %d", res.Code, http.StatusOK)
+		}
+	}
+	res := serve(search, "192.0.2.1:4321", "")
+	checkErrorResponse(t, res, http.StatusTooManyRequests, "rate_limited")
+	// two requests of the window are weighted by half after 90 seconds
+	if got := res.Header().Get("Retry-After"); got != "90" {
+		t.Errorf("got Retry-After %q but wanted %q", got, "90")
+	}
+	if got := testutil.ToFloat64(limiter.rejected.WithLabelValues(RateLimitSearch)); got != 1 {
+		t.Errorf("got %v rejected requests but wanted 1", got)
+	}
+
+	tests := []struct {
+		name       string
+		handler    http.Handler
+		remoteAddr string
+		keyID      string
+		status     int
+	}{
+		{"ingest scope is separate", ingest, "192.0.2.1:1234", "", http.StatusOK},
+		{"ingest limit", ingest, "192.0.2.1:1234", "", http.StatusTooManyRequests},
+		{"other address", search, "192.0.2.2:1234", "", http.StatusOK},
+		{"api key", search, "192.0.2.1:1234", "0123456789abcdef", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if res := serve(tt.handler, tt.remoteAddr, tt.keyID); res.Code != tt.status {
+			t.Errorf("%s: got status %d but wanted %d", tt.name, res.Code, tt.status)
+		}
+	}
+
+	// the previous window is still counted in the sliding one
+	limiter.now = func() time.Time {
+		return time.Date(2022, 1, 1, 0, 1, 30, 0, time.UTC)
+	}
+	if res := serve(search, "192.0.2.1:1234", ""); res.Code != http.StatusOK {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusOK)
+	}
+	if res := serve(search, "192.0.2.1:1234", ""); res.Code != http.StatusTooManyRequests {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusTooManyRequests)
+	}
+
+	// requests are passed when counters are unavailable
+	mr.Close()
+	if res := serve(search, "192.0.2.1:1234", ""); res.Code != http.StatusOK {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusOK)
+	}
+}
+
+func TestRateLimiterClientIP(t *testing.T) {
+	tests := []struct {
+		trustForwarded bool
+		forwarded      []string
+		want           string
+	}{
+		{false, nil, "192.0.2.1"},
+		{false, []string{"198.51.100.1"}, "192.0.2.1"},
+		{true, nil, "192.0.2.1"},
+		{true, []string{"203.0.113.1, 198.51.100.1"}, "198.51.100.1"},
+		{true, []string{"203.0.113.1", "198.51.100.1"}, "198.51.100.1"},
+		{true, []string{"unknown"}, "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		limiter := &RateLimiter{config: RateLimitConfig{TrustForwarded: tt.trustForwarded}}
+		req := httptest.NewRequest("GET", "/api/search", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		for _, v := range tt.forwarded {
+			req.Header.Add("X-Forwarded-For", v)
+		}
+		if got := limiter.clientIP(req); got != tt.want {
+			t.Errorf("%v, %v: got address %q but wanted %q", tt.trustForwarded, tt.forwarded, got, tt.want)
+		}
+	}
+}
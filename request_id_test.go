@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var got string
+	h := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = RequestIDFromContext(r.Context())
+	}))
+
+	tests := map[string]bool{
+		"":                         false,
+		"abc-123":                  true,
+		"with space":               false,
+		"line\nbreak":              false,
+		strings.Repeat("a", 129):   false,
+		strings.Repeat("a", 128):   true,
+		"0123456789abcdef01234567": true,
+	}
+	for id, kept := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		if id != "" {
+			req.Header.Set(RequestIDHeader, id)
+		}
+		res := httptest.NewRecorder()
+		h.ServeHTTP(res, req)
+
+		if got == "" || got != res.Header().Get(RequestIDHeader) {
+			t.Errorf("id %q: context id %q does not match header %q", id, got, res.Header().Get(RequestIDHeader))
+		}
+		if (got == id) != kept {
+			t.Errorf("id %q: got %q, kept = %v", id, got, kept)
+		}
+	}
+}
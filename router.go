@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// PatternUnmatched is pattern of requests to paths without routes
+const PatternUnmatched = "unmatched"
+
+type (
+	// Route describes handler registered in Router with its allowed methods
+	Route struct {
+		Pattern string
+		Methods []string
+		Handler http.Handler
+	}
+
+	// Router is http.ServeMux which enforces allowed methods of every route
+	Router struct {
+		mux        *http.ServeMux
+		routes     []Route
+		writeError func(http.ResponseWriter, *http.Request, error)
+	}
+)
+
+// NewRouter is constructor for Router, writeError renders 404 and 405 errors
+func NewRouter(writeError func(http.ResponseWriter, *http.Request, error)) *Router {
+	return &Router{
+		mux:        http.NewServeMux(),
+		writeError: writeError,
+	}
+}
+
+// Handle registers handler for pattern which accepts only given methods,
+// HEAD is allowed for GET routes and OPTIONS is answered for every route
+func (rt *Router) Handle(pattern string, handler http.Handler, methods ...string) {
+	route := Route{
+		Pattern: pattern,
+		Methods: allowedMethods(methods),
+		Handler: handler,
+	}
+	rt.routes = append(rt.routes, route)
+	rt.mux.Handle(pattern, methodsMiddleware(handler, route.Methods, rt.writeError))
+}
+
+// HandleFunc registers handler function for pattern, see Handle
+func (rt *Router) HandleFunc(pattern string, handler http.HandlerFunc, methods ...string) {
+	rt.Handle(pattern, handler, methods...)
+}
+
+// Routes returns registered routes in order of registration
+func (rt *Router) Routes() []Route {
+	return rt.routes
+}
+
+// Pattern returns pattern of the route serving request, or PatternUnmatched if it is answered with 404,
+// it labels metrics instead of the path which is given by clients
+func (rt *Router) Pattern(r *http.Request) string {
+	// "/" pattern of http.ServeMux matches every path, only the root itself is served by it
+	if _, pattern := rt.mux.Handler(r); pattern != "" && (pattern != "/" || r.URL.Path == "/") {
+		return pattern
+	}
+	return PatternUnmatched
+}
+
+// ServeHTTP implements http.Handler
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if rt.Pattern(r) == PatternUnmatched {
+		rt.writeError(w, r, newAPIError(KindNotFound, fmt.Sprintf("path %q not found", r.URL.Path), nil))
+		return
+	}
+	rt.mux.ServeHTTP(w, r)
+}
+
+// allowedMethods adds implicit HEAD and OPTIONS methods and sorts the result
+func allowedMethods(methods []string) []string {
+	set := map[string]bool{http.MethodOptions: true}
+	for _, method := range methods {
+		set[method] = true
+		if method == http.MethodGet {
+			set[http.MethodHead] = true
+		}
+	}
+	allowed := make([]string, 0, len(set))
+	for method := range set {
+		allowed = append(allowed, method)
+	}
+	sort.Strings(allowed)
+	return allowed
+}
+
+// methodsMiddleware answers OPTIONS with Allow header and rejects not allowed methods with 405
+func methodsMiddleware(handler http.Handler, allowed []string,
+	writeError func(http.ResponseWriter, *http.Request, error)) http.Handler {
+	allow := strings.Join(allowed, ", ")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Allow", allow)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		for _, method := range allowed {
+			if r.Method == method {
+				handler.ServeHTTP(w, r)
+				return
+			}
+		}
+		w.Header().Set("Allow", allow)
+		writeError(w, r, newAPIError(KindMethodNotAllowed,
+			fmt.Sprintf("method %s is not allowed, allowed methods: %s", r.Method, allow), nil))
+	})
+}
+
+// NewAPIRouter registers all routes of the service,
+// loading the dataset requires admin API key and searching requires search API key,
+// request bodies are limited per route and requests are rate limited per client after authentication,
+// health checks and metrics are open for probes and scrapers
+func NewAPIRouter(d *DBProcessor, auth *Authenticator, limiter *RateLimiter, health *Health,
+	metrics http.Handler) *Router {
+	router := NewRouter(d.writeError)
+	admin := func(handler http.HandlerFunc) http.Handler {
+		return auth.Require(RoleAdmin, limiter.Limit(RateLimitIngest, handler))
+	}
+	search := func(handler http.HandlerFunc) http.Handler {
+		return auth.Require(RoleSearch, limiter.Limit(RateLimitSearch, handler))
+	}
+	upload := func(handler http.Handler) http.Handler {
+		return d.limitBody(d.limits.Upload, handler)
+	}
+	query := func(handler http.Handler) http.Handler {
+		return d.limitBody(d.limits.Query, handler)
+	}
+
+	router.Handle("/metrics", metrics, http.MethodGet)
+
+	router.HandleFunc("/healthz", health.HandleLiveness, http.MethodGet)
+	router.HandleFunc("/readyz", health.HandleReadiness, http.MethodGet)
+
+	router.HandleFunc("/api/openapi.json", d.HandleOpenAPI, http.MethodGet)
+
+	router.Handle("/api/admin/keys", query(admin(auth.HandleCreateKey)), http.MethodPost)
+	router.Handle("/api/admin/keys/", admin(auth.HandleRevokeKey), http.MethodDelete)
+
+	router.Handle("/api/load_file", upload(admin(d.HandleLoadFile)), http.MethodPost)
+
+	router.Handle("/api/load_from_url", query(admin(d.HandleLoadFromURL)), http.MethodPost)
+
+	router.Handle("/api/load_json", upload(admin(d.HandleLoadJSON)), http.MethodPost)
+	// deprecated name of /api/load_json
+	router.Handle("/api/load_from_json", upload(admin(d.HandleLoadJSON)), http.MethodPost)
+
+	//https://nimblehq.co/blog/getting-started-with-redisearch
+	router.Handle("/api/search", query(search(d.HandleSearch)), http.MethodGet, http.MethodPost)
+
+	router.Handle("/api/search/batch", query(search(d.HandleSearchBatch)), http.MethodPost)
+
+	router.Handle("/api/parkings/", search(d.HandleParking), http.MethodGet)
+
+	router.HandleFunc("/", d.HandleMainPage, http.MethodGet)
+
+	return router
+}
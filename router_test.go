@@ -0,0 +1,144 @@
+package main
+
+import (
+	"golang-developer-test-task/infrastructure/redclient"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v8"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+func newTestRouter() *Router {
+	db, _ := redismock.NewClientMock()
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
+	logger := zap.NewNop()
+	cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+	processor := NewDBProcessor(client, logger, &singleflight.Group{}, cache)
+	metrics := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	auth := NewAuthenticator(client, AuthConfig{}, processor.writeError)
+	limiter := NewRateLimiter(client, RateLimitConfig{}, logger, processor.writeError)
+	health := NewHealth(client, HealthConfig{ReadyTimeout: time.Second})
+	return NewAPIRouter(processor, auth, limiter, health, metrics)
+}
+
+func TestRouterMethodNotAllowed(t *testing.T) {
+	router := newTestRouter()
+	tests := map[string]string{
+		"GET /api/load_json":         "OPTIONS, POST",
+		"GET /api/load_from_json":    "OPTIONS, POST",
+		"GET /api/load_file":         "OPTIONS, POST",
+		"PUT /api/load_from_url":     "OPTIONS, POST",
+		"DELETE /api/search":         "GET, HEAD, OPTIONS, POST",
+		"GET /api/search/batch":      "OPTIONS, POST",
+		"POST /api/parkings/777":     "GET, HEAD, OPTIONS",
+		"POST /metrics":              "GET, HEAD, OPTIONS",
+		"DELETE /":                   "GET, HEAD, OPTIONS",
+		"PATCH /api/search?mode=abc": "GET, HEAD, OPTIONS, POST",
+	}
+	for request, allow := range tests {
+		var method, target string
+		for i := range request {
+			if request[i] == ' ' {
+				method, target = request[:i], request[i+1:]
+				break
+			}
+		}
+		req := httptest.NewRequest(method, target, nil)
+		res := httptest.NewRecorder()
+		requestIDMiddleware(router).ServeHTTP(res, req)
+
+		checkErrorResponse(t, res, http.StatusMethodNotAllowed, "method_not_allowed")
+		if got := res.Header().Get("Allow"); got != allow {
+			t.Errorf("%s: got Allow %q but wanted %q", request, got, allow)
+		}
+	}
+}
+
+func TestRouterOptions(t *testing.T) {
+	router := newTestRouter()
+	for _, route := range router.Routes() {
+		req := httptest.NewRequest(http.MethodOptions, route.Pattern, nil)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+
+		if res.Code != http.StatusNoContent {
+			t.Errorf("%s: got status %d but wanted %d", route.Pattern, res.Code, http.StatusNoContent)
+		}
+		if res.Header().Get("Allow") == "" {
+			t.Errorf("%s: Allow header is empty", route.Pattern)
+		}
+	}
+}
+
+func TestRouterAllowedMethod(t *testing.T) {
+	router := newTestRouter()
+	for _, target := range []string{"/", "/metrics"} {
+		for _, method := range []string{http.MethodGet, http.MethodHead} {
+			req := httptest.NewRequest(method, target, nil)
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+
+			if res.Code != http.StatusOK {
+				t.Errorf("%s %s: got status %d but wanted %d", method, target, res.Code, http.StatusOK)
+			}
+		}
+	}
+}
+
+func TestRouterNotFound(t *testing.T) {
+	router := newTestRouter()
+	for _, target := range []string{"/api/unknown", "/index.html", "/api"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		res := httptest.NewRecorder()
+		requestIDMiddleware(router).ServeHTTP(res, req)
+
+		checkErrorResponse(t, res, http.StatusNotFound, "not_found")
+	}
+}
+
+func TestRouterPattern(t *testing.T) {
+	router := newTestRouter()
+	tests := map[string]string{
+		"/api/parkings/777":     "/api/parkings/",
+		"/api/parkings/by-id/1": "/api/parkings/",
+		"/api/search?mode=abc":  "/api/search",
+		"/api/admin/keys/k1":    "/api/admin/keys/",
+		"/":                     "/",
+		"/api/unknown":          PatternUnmatched,
+		"/index.html":           PatternUnmatched,
+	}
+	for target, want := range tests {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if got := router.Pattern(req); got != want {
+			t.Errorf("%s: got pattern %q but wanted %q", target, got, want)
+		}
+	}
+}
+
+func TestTimeTrackingMiddlewareLabels(t *testing.T) {
+	router := newTestRouter()
+	handler := timeTrackingMiddleware(router, router)
+	for _, target := range []string{"/api/parkings/1", "/api/parkings/2", "/api/unknown-1", "/api/unknown-2"} {
+		before := map[string]float64{
+			"/api/parkings/": testutil.ToFloat64(counter.WithLabelValues("/api/parkings/")),
+			PatternUnmatched: testutil.ToFloat64(counter.WithLabelValues(PatternUnmatched)),
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodOptions, target, nil))
+		want := PatternUnmatched
+		if strings.HasPrefix(target, "/api/parkings/") {
+			want = "/api/parkings/"
+		}
+		if got := testutil.ToFloat64(counter.WithLabelValues(want)); got != before[want]+1 {
+			t.Errorf("%s: got %v requests of %s but wanted %v", target, got, want, before[want]+1)
+		}
+	}
+	if n := testutil.CollectAndCount(counter); n != 2 {
+		t.Errorf("got %d series of requests but wanted 2", n)
+	}
+}
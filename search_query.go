@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"golang-developer-test-task/infrastructure/storage"
+	"golang-developer-test-task/structs"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultSearchLimit = 5
+	maxSearchLimit     = 100
+)
+
+// parseSearchQuery maps query string parameters of GET /api/search onto SearchObject
+func parseSearchQuery(values url.Values) (searchObj structs.SearchObject, err error) {
+	for key, vs := range values {
+		if len(vs) != 1 {
+			return searchObj, fmt.Errorf("parameter %q must be passed exactly once", key)
+		}
+		v := vs[0]
+		switch key {
+		case "system_object_id":
+			searchObj.SystemObjectID = &v
+		case "mode":
+			searchObj.Mode = &v
+		case "mode_en":
+			searchObj.ModeEn = &v
+		case "global_id":
+			searchObj.GlobalID, err = parseIntParam(key, v)
+		case "id":
+			searchObj.ID, err = parseIntParam(key, v)
+		case "id_en":
+			searchObj.IDEn, err = parseIntParam(key, v)
+		case "adm_area":
+			searchObj.AdmArea = &v
+		case "adm_area_en":
+			searchObj.AdmAreaEn = &v
+		case "district":
+			searchObj.District = &v
+		case "district_en":
+			searchObj.DistrictEn = &v
+		case "name":
+			searchObj.Name = &v
+		case "name_en":
+			searchObj.NameEn = &v
+		case "car_capacity_min":
+			searchObj.CarCapacityMin, err = parseIntParam(key, v)
+		case "car_capacity_max":
+			searchObj.CarCapacityMax, err = parseIntParam(key, v)
+		case "longitude":
+			searchObj.Longitude, err = parseFloatParam(key, v)
+		case "latitude":
+			searchObj.Latitude, err = parseFloatParam(key, v)
+		case "radius":
+			searchObj.Radius, err = parseFloatParam(key, v)
+		case "offset":
+			var offset *int
+			if offset, err = parseIntParam(key, v); err == nil {
+				searchObj.Offset = *offset
+			}
+		case "limit":
+			var limit *int
+			if limit, err = parseIntParam(key, v); err == nil {
+				searchObj.Limit = *limit
+			}
+		case "fields":
+			searchObj.Fields = strings.Split(v, ",")
+		default:
+			err = fmt.Errorf("unknown parameter %q", key)
+		}
+		if err != nil {
+			return searchObj, err
+		}
+	}
+	return searchObj, nil
+}
+
+func parseIntParam(key, value string) (*int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("parameter %q must be an integer, got %q", key, value)
+	}
+	return &n, nil
+}
+
+func parseFloatParam(key, value string) (*float64, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("parameter %q must be a number, got %q", key, value)
+	}
+	return &f, nil
+}
+
+// validatePagination checks offset and limit of SearchObject and sets default limit
+func validatePagination(searchObj *structs.SearchObject) error {
+	if searchObj.Offset < 0 {
+		return fmt.Errorf("offset must not be negative, got %d", searchObj.Offset)
+	}
+	if searchObj.Limit == 0 {
+		searchObj.Limit = defaultSearchLimit
+	}
+	if searchObj.Limit < 0 || searchObj.Limit > maxSearchLimit {
+		return fmt.Errorf("limit must be between 1 and %d, got %d", maxSearchLimit, searchObj.Limit)
+	}
+	return nil
+}
+
+// validateFields checks that fields of SearchObject are JSON names of Info fields
+func validateFields(searchObj *structs.SearchObject) error {
+	for _, field := range searchObj.Fields {
+		if !structs.IsInfoField(field) {
+			return fmt.Errorf("unknown field %q, expected one of %s",
+				field, strings.Join(structs.InfoFieldNames(), ", "))
+		}
+	}
+	return nil
+}
+
+// searchFilter returns filter of SearchObject checking its values
+func searchFilter(searchObj *structs.SearchObject) (storage.Filter, error) {
+	filter := storage.Filter{
+		AdmArea:        searchObj.AdmArea,
+		AdmAreaEn:      searchObj.AdmAreaEn,
+		District:       searchObj.District,
+		DistrictEn:     searchObj.DistrictEn,
+		Name:           searchObj.Name,
+		NameEn:         searchObj.NameEn,
+		CarCapacityMin: searchObj.CarCapacityMin,
+		CarCapacityMax: searchObj.CarCapacityMax,
+	}
+	for name, value := range map[string]*string{"name": searchObj.Name, "name_en": searchObj.NameEn} {
+		if value != nil && strings.TrimSpace(*value) == "" {
+			return filter, fmt.Errorf("%s must contain words", name)
+		}
+	}
+	if filter.CarCapacityMin != nil && filter.CarCapacityMax != nil && *filter.CarCapacityMin > *filter.CarCapacityMax {
+		return filter, fmt.Errorf("car_capacity_min must not exceed car_capacity_max, got %d and %d",
+			*filter.CarCapacityMin, *filter.CarCapacityMax)
+	}
+
+	lon, lat, radius := searchObj.Longitude, searchObj.Latitude, searchObj.Radius
+	if lon == nil && lat == nil && radius == nil {
+		return filter, nil
+	}
+	switch {
+	case lon == nil || lat == nil || radius == nil:
+		return filter, errors.New("longitude, latitude and radius are required together")
+	case math.Abs(*lon) > 180:
+		return filter, fmt.Errorf("longitude must be between -180 and 180, got %g", *lon)
+	case math.Abs(*lat) > 85:
+		return filter, fmt.Errorf("latitude must be between -85 and 85, got %g", *lat)
+	case *radius <= 0:
+		return filter, fmt.Errorf("radius must be positive, got %g", *radius)
+	}
+	filter.Near = &storage.GeoRadius{Longitude: *lon, Latitude: *lat, Radius: *radius}
+	return filter, nil
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"golang-developer-test-task/infrastructure/redclient"
+	"golang-developer-test-task/infrastructure/storage"
+	"golang-developer-test-task/infrastructure/storage/memstore"
+	"golang-developer-test-task/structs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/mailru/easyjson"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	values, _ := url.ParseQuery("global_id=1&id=2&id_en=3&system_object_id=777&mode=abc&mode_en=cba&offset=10&limit=20" +
+		"&fields=Name,global_id")
+	searchObj, err := parseSearchQuery(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *searchObj.GlobalID != 1 || *searchObj.ID != 2 || *searchObj.IDEn != 3 {
+		t.Errorf("wrong int params: %v", searchObj)
+	}
+	if *searchObj.SystemObjectID != "777" || *searchObj.Mode != "abc" || *searchObj.ModeEn != "cba" {
+		t.Errorf("wrong string params: %v", searchObj)
+	}
+	if searchObj.Offset != 10 || searchObj.Limit != 20 {
+		t.Errorf("wrong pagination params: %v", searchObj)
+	}
+	if !reflect.DeepEqual(searchObj.Fields, []string{"Name", "global_id"}) {
+		t.Errorf("wrong fields param: %v", searchObj.Fields)
+	}
+}
+
+func TestParseSearchQueryFilters(t *testing.T) {
+	values, _ := url.ParseQuery("adm_area=Центральный&adm_area_en=Central&district=Арбат&district_en=Arbat" +
+		"&name=у Арбата&name_en=Arbat parking&car_capacity_min=10&car_capacity_max=20" +
+		"&longitude=37.6&latitude=55.75&radius=500")
+	searchObj, err := parseSearchQuery(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *searchObj.AdmArea != "Центральный" || *searchObj.AdmAreaEn != "Central" || *searchObj.District != "Арбат" ||
+		*searchObj.DistrictEn != "Arbat" || *searchObj.Name != "у Арбата" || *searchObj.NameEn != "Arbat parking" {
+		t.Errorf("wrong string filters: %v", searchObj)
+	}
+	if *searchObj.CarCapacityMin != 10 || *searchObj.CarCapacityMax != 20 {
+		t.Errorf("wrong capacity filters: %v", searchObj)
+	}
+	if *searchObj.Longitude != 37.6 || *searchObj.Latitude != 55.75 || *searchObj.Radius != 500 {
+		t.Errorf("wrong location filter: %v", searchObj)
+	}
+}
+
+func TestParseSearchQueryErr(t *testing.T) {
+	for _, query := range []string{
+		"global_id=abc",
+		"id=1.5",
+		"id_en=",
+		"offset=ten",
+		"limit=1e3",
+		"mode=a&mode=b",
+		"car_capacity_min=many",
+		"longitude=east",
+		"radius=NaN",
+		"latitude=Inf",
+		"unknown=1",
+	} {
+		values, _ := url.ParseQuery(query)
+		if _, err := parseSearchQuery(values); err == nil {
+			t.Errorf("expected error for query %q", query)
+		}
+	}
+}
+
+func TestValidatePagination(t *testing.T) {
+	searchObj := structs.SearchObject{}
+	if err := validatePagination(&searchObj); err != nil {
+		t.Fatal(err)
+	}
+	if searchObj.Limit != defaultSearchLimit {
+		t.Errorf("got limit %d but wanted %d", searchObj.Limit, defaultSearchLimit)
+	}
+	for _, searchObj := range []structs.SearchObject{
+		{Offset: -1},
+		{Limit: -1},
+		{Limit: maxSearchLimit + 1},
+	} {
+		searchObj := searchObj
+		if err := validatePagination(&searchObj); err == nil {
+			t.Errorf("expected error for %v", searchObj)
+		}
+	}
+}
+
+func TestValidateFields(t *testing.T) {
+	if err := validateFields(&structs.SearchObject{Fields: []string{"Name", "Longitude_WGS84", "ID_en"}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, fields := range [][]string{{"name"}, {"Name", ""}, {"json"}} {
+		if err := validateFields(&structs.SearchObject{Fields: fields}); err == nil {
+			t.Errorf("expected error for fields %v", fields)
+		}
+	}
+}
+
+func TestSearchFilter(t *testing.T) {
+	str := func(s string) *string { return &s }
+	num := func(n int) *int { return &n }
+	float := func(f float64) *float64 { return &f }
+	filter, err := searchFilter(&structs.SearchObject{AdmArea: str("a"), CarCapacityMin: num(1), CarCapacityMax: num(1),
+		Longitude: float(37.6), Latitude: float(55.75), Radius: float(500)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := storage.Filter{AdmArea: filter.AdmArea, CarCapacityMin: filter.CarCapacityMin,
+		CarCapacityMax: filter.CarCapacityMax, Near: &storage.GeoRadius{Longitude: 37.6, Latitude: 55.75, Radius: 500}}
+	if !reflect.DeepEqual(filter, want) || *filter.AdmArea != "a" {
+		t.Errorf("got filter %v but wanted %v", filter, want)
+	}
+	if filter, err = searchFilter(&structs.SearchObject{Mode: str("a")}); err != nil || !filter.IsZero() {
+		t.Errorf("got filter %v and error %v of search without filters", filter, err)
+	}
+
+	for _, searchObj := range []structs.SearchObject{
+		{Name: str(" ")},
+		{NameEn: str("")},
+		{CarCapacityMin: num(2), CarCapacityMax: num(1)},
+		{Longitude: float(37.6), Latitude: float(55.75)},
+		{Radius: float(500)},
+		{Longitude: float(181), Latitude: float(55.75), Radius: float(500)},
+		{Longitude: float(37.6), Latitude: float(-86), Radius: float(500)},
+		{Longitude: float(37.6), Latitude: float(55.75), Radius: float(0)},
+	} {
+		searchObj := searchObj
+		if _, err := searchFilter(&searchObj); err == nil {
+			t.Errorf("expected error for %v", searchObj)
+		}
+	}
+}
+
+// filterStore is memory store which answers filtered searches with result, they are kept in calls
+type filterStore struct {
+	*memstore.Store
+	result structs.InfoList
+	err    error
+	calls  []string
+}
+
+func (s *filterStore) FindFiltered(ctx context.Context, listKey string, filter storage.Filter,
+	paginationSize, offset int64) (structs.InfoList, int64, error) {
+	s.calls = append(s.calls, fmt.Sprintf("%s|%s|%d|%d", listKey, filter, paginationSize, offset))
+	return s.result, int64(len(s.result)), s.err
+}
+
+func TestHandleSearchFilters(t *testing.T) {
+	logger, _ := zap.NewProduction()
+	defer func() {
+		_ = logger.Sync()
+	}()
+	store := &filterStore{Store: memstore.New(), result: structs.InfoList{{SystemObjectID: "A0", Name: "first"}}}
+	cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+	processor := NewDBProcessor(store, logger, &singleflight.Group{}, cache)
+
+	for _, target := range []string{
+		"/api/search?adm_area=Центральный&car_capacity_min=10",
+		"/api/search?mode=abc&name=Арбат&fields=Name",
+		"/api/search?longitude=37.6&latitude=55.75&radius=500&offset=1&limit=2",
+		// cached
+		"/api/search?adm_area=Центральный&car_capacity_min=10",
+	} {
+		req := httptest.NewRequest("GET", target, nil)
+		res := httptest.NewRecorder()
+		processor.HandleSearch(res, req)
+		if res.Code != http.StatusOK {
+			t.Fatalf("%s: got status %d but wanted %d", target, res.Code, http.StatusOK)
+		}
+		var result struct {
+			Size int64                    `json:"size"`
+			Data []map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(res.Body.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+		if result.Size != 1 || len(result.Data) != 1 || result.Data[0]["Name"] != "first" {
+			t.Errorf("%s: got %+v", target, result)
+		}
+	}
+	want := []string{
+		`|adm_area="Центральный"&car_capacity_min=10|5|0`,
+		`mode:abc|name="Арбат"|5|0`,
+		`|near=37.6,55.75,500|2|1`,
+	}
+	if !reflect.DeepEqual(store.calls, want) {
+		t.Errorf("got calls %q but wanted %q", store.calls, want)
+	}
+
+	store.err = storage.ErrFilterUnsupported
+	for target, status := range map[string]int{
+		"/api/search?district=Арбат":                        http.StatusUnprocessableEntity,
+		"/api/search?global_id=1&district=Арбат":            http.StatusUnprocessableEntity,
+		"/api/search?car_capacity_min=2&car_capacity_max=1": http.StatusUnprocessableEntity,
+		"/api/search?radius=500":                            http.StatusUnprocessableEntity,
+	} {
+		req := httptest.NewRequest("GET", target, nil)
+		res := httptest.NewRecorder()
+		processor.HandleSearch(res, req)
+		if res.Code != status {
+			t.Errorf("%s: got status %d but wanted %d", target, res.Code, status)
+		}
+	}
+
+	// stores without FilterFinder reject filters
+	processor = NewDBProcessor(memstore.New(), logger, &singleflight.Group{}, cache)
+	req := httptest.NewRequest("GET", "/api/search?mode=abc&district=Арбат", nil)
+	res := httptest.NewRecorder()
+	processor.HandleSearch(res, req)
+	if res.Code != http.StatusUnprocessableEntity {
+		t.Errorf("got status %d of filters without FilterFinder but wanted %d", res.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestHandleSearchFields(t *testing.T) {
+	infos := structs.InfoList{
+		{GlobalID: 100, SystemObjectID: "0", Name: "first", Mode: "abc", LongitudeWGS84: "37.6"},
+		{GlobalID: 101, SystemObjectID: "1", Name: "second", Mode: "abc", LongitudeWGS84: "37.7"},
+	}
+	mr := miniredis.RunT(t)
+	client := redclient.NewRedisClient(context.Background(), redclient.RedisConfig{Addr: mr.Addr()})
+	stores := map[string]storage.Store{"redis": client, "memory": memstore.New()}
+
+	logger, _ := zap.NewProduction()
+	defer func() {
+		_ = logger.Sync()
+	}()
+	for name, store := range stores {
+		if err := store.AddValues(context.Background(), infos); err != nil {
+			t.Fatal(err)
+		}
+		cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+		processor := NewDBProcessor(store, logger, &singleflight.Group{}, cache)
+
+		req := httptest.NewRequest("GET", "/api/search?mode=abc&fields=Name,global_id", nil)
+		res := httptest.NewRecorder()
+		processor.HandleSearch(res, req)
+		if res.Code != http.StatusOK {
+			t.Fatalf("%s: got status %d but wanted %d", name, res.Code, http.StatusOK)
+		}
+		var result struct {
+			Size int64                    `json:"size"`
+			Data []map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(res.Body.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+		want := []map[string]interface{}{
+			{"Name": "first", "global_id": float64(100)},
+			{"Name": "second", "global_id": float64(101)},
+		}
+		if result.Size != 2 || !reflect.DeepEqual(result.Data, want) {
+			t.Errorf("%s: got %+v but wanted data %v", name, result, want)
+		}
+
+		req = httptest.NewRequest("GET", "/api/search?mode=abc&fields=Name,json", nil)
+		res = httptest.NewRecorder()
+		processor.HandleSearch(res, req)
+		if res.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: got status %d but wanted %d", name, res.Code, http.StatusUnprocessableEntity)
+		}
+	}
+}
+
+func TestHandleSearchGet(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redclient.NewRedisClient(context.Background(), redclient.RedisConfig{Addr: mr.Addr()})
+	infos := make(structs.InfoList, 0, 5)
+	for i := 0; i < 5; i++ {
+		infos = append(infos, structs.Info{
+			GlobalID:       100 + i,
+			SystemObjectID: strconv.Itoa(i),
+			ID:             i,
+			IDEn:           i,
+			Mode:           "abc",
+			ModeEn:         "cba",
+		})
+	}
+	if err := client.AddValues(context.Background(), infos); err != nil {
+		t.Fatal(err)
+	}
+
+	logger, _ := zap.NewProduction()
+	defer func() {
+		_ = logger.Sync()
+	}()
+	cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+	processor := NewDBProcessor(client, logger, &singleflight.Group{}, cache)
+
+	req := httptest.NewRequest("GET", "/api/search?mode=abc&offset=1&limit=2", nil)
+	res := httptest.NewRecorder()
+	processor.HandleSearch(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("got status %d but wanted %d", res.Code, http.StatusOK)
+	}
+	var result structs.PaginationObject
+	if err := easyjson.Unmarshal(res.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Size != 5 || result.Offset != 1 || !result.HasNext || !result.HasPrevious {
+		t.Errorf("wrong pagination: %v", result)
+	}
+	if len(result.Data) != 2 || result.Data[0] != infos[1] || result.Data[1] != infos[2] {
+		t.Errorf("got data %v but wanted %v", result.Data, infos[1:3])
+	}
+
+	req = httptest.NewRequest("GET", "/api/search?global_id=104", nil)
+	res = httptest.NewRecorder()
+	processor.HandleSearch(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("got status %d but wanted %d", res.Code, http.StatusOK)
+	}
+	result = structs.PaginationObject{}
+	if err := easyjson.Unmarshal(res.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Data) != 1 || result.Data[0] != infos[4] {
+		t.Errorf("got data %v but wanted %v", result.Data, infos[4])
+	}
+}
+
+func TestHandleSearchGetBadRequest(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redclient.NewRedisClient(context.Background(), redclient.RedisConfig{Addr: mr.Addr()})
+
+	logger, _ := zap.NewProduction()
+	defer func() {
+		_ = logger.Sync()
+	}()
+	cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+	processor := NewDBProcessor(client, logger, &singleflight.Group{}, cache)
+
+	tests := map[string]int{
+		"/api/search?global_id=abc":       http.StatusBadRequest,
+		"/api/search?mode=abc&mode=cba":   http.StatusBadRequest,
+		"/api/search?mode=abc&limit=1000": http.StatusUnprocessableEntity,
+		"/api/search?mode=abc&offset=-1":  http.StatusUnprocessableEntity,
+		"/api/search?offset=1":            http.StatusUnprocessableEntity,
+	}
+	for target, status := range tests {
+		req := httptest.NewRequest("GET", target, nil)
+		res := httptest.NewRecorder()
+		processor.HandleSearch(res, req)
+
+		if res.Code != status {
+			t.Errorf("%s: got status %d but wanted %d", target, res.Code, status)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"golang-developer-test-task/infrastructure/config"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultPort = 8080
+	// defaultShutdownDelay and defaultShutdownTimeout together fit into default termination grace period of Kubernetes
+	defaultShutdownDelay   = 5 * time.Second
+	defaultShutdownTimeout = 20 * time.Second
+)
+
+// ServerConfig is struct for storing HTTP server settings
+type ServerConfig struct {
+	Port int
+	// ShutdownDelay is time between reporting not ready and closing listener after stop signal,
+	// load balancer stops sending requests meanwhile
+	ShutdownDelay time.Duration
+	// ShutdownTimeout limits waiting for in-flight requests and running imports after stop signal
+	ShutdownTimeout time.Duration
+}
+
+// settings binds ServerConfig fields to config file keys, environment variables and flags
+func (c *ServerConfig) settings() []config.Setting {
+	return []config.Setting{
+		{Key: "server.port", Env: []string{"PORT"}, Usage: "port of HTTP server",
+			Default: strconv.Itoa(defaultPort), Value: config.Int(&c.Port)},
+		{Key: "server.shutdown_delay", Env: []string{"SHUTDOWN_DELAY"},
+			Usage:   "time to serve requests while reporting not ready after stop signal",
+			Default: defaultShutdownDelay.String(), Value: config.Duration(&c.ShutdownDelay)},
+		{Key: "server.shutdown_timeout", Env: []string{"SHUTDOWN_TIMEOUT"},
+			Usage:   "time to finish in-flight requests and imports after stop signal",
+			Default: defaultShutdownTimeout.String(), Value: config.Duration(&c.ShutdownTimeout)},
+	}
+}
+
+// Load is useful for loading ServerConfig data from environment
+func (c *ServerConfig) Load() error {
+	if err := config.LoadEnv(c.settings()); err != nil {
+		return err
+	}
+	return c.Validate()
+}
+
+// Validate checks that ServerConfig values are usable
+func (c *ServerConfig) Validate() error {
+	if c.Port < 0 || c.Port > 65535 {
+		return fmt.Errorf("port must be between 0 and 65535, got %d", c.Port)
+	}
+	if c.ShutdownDelay < 0 {
+		return fmt.Errorf("shutdown delay must not be negative, got %s", c.ShutdownDelay)
+	}
+	if c.ShutdownTimeout <= 0 {
+		return fmt.Errorf("shutdown timeout must be positive, got %s", c.ShutdownTimeout)
+	}
+	return nil
+}
+
+// serve handles connections of listener until ctx is done, then calls shuttingDown and keeps serving
+// for ShutdownDelay, so readiness check fails while load balancer still sends requests.
+// After it server stops accepting new connections and waits for in-flight requests
+// and after them for drain, both within ShutdownTimeout
+func serve(ctx context.Context, server *http.Server, listener net.Listener, conf ServerConfig,
+	shuttingDown func(), drain func(context.Context) error) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	shuttingDown()
+	delay := time.NewTimer(conf.ShutdownDelay)
+	defer delay.Stop()
+	select {
+	case err := <-serveErr:
+		return err
+	case <-delay.C:
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), conf.ShutdownTimeout)
+	defer cancel()
+	err := server.Shutdown(shutdownCtx)
+	if err != nil {
+		err = fmt.Errorf("in-flight requests are not finished: %w", err)
+	}
+	// drain is called anyway, its context may be already expired
+	if drainErr := drain(shutdownCtx); err == nil {
+		err = drainErr
+	}
+	return err
+}
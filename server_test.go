@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"golang-developer-test-task/infrastructure/redclient"
+	"golang-developer-test-task/infrastructure/storage/memstore"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+func TestServerConfigLoad(t *testing.T) {
+	t.Setenv("PORT", "")
+	t.Setenv("SHUTDOWN_DELAY", "")
+	t.Setenv("SHUTDOWN_TIMEOUT", "")
+	var config ServerConfig
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	want := ServerConfig{Port: defaultPort, ShutdownDelay: defaultShutdownDelay, ShutdownTimeout: defaultShutdownTimeout}
+	if config != want {
+		t.Errorf("got config %v but wanted %v", config, want)
+	}
+
+	for _, tt := range []struct{ name, value string }{
+		{"PORT", "http"},
+		{"PORT", "65536"},
+		{"SHUTDOWN_DELAY", "-1s"},
+		{"SHUTDOWN_TIMEOUT", "0s"},
+		{"SHUTDOWN_TIMEOUT", "10"},
+	} {
+		t.Run(tt.name+"="+tt.value, func(t *testing.T) {
+			t.Setenv(tt.name, tt.value)
+			if err := config.Load(); err == nil {
+				t.Errorf("%s=%q: expected error", tt.name, tt.value)
+			}
+		})
+	}
+}
+
+func TestServeDrainsRequests(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		_, _ = w.Write([]byte("done"))
+	})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	drained := false
+	served := make(chan error, 1)
+	go func() {
+		served <- serve(ctx, server, listener, ServerConfig{ShutdownTimeout: time.Minute}, func() {}, func(context.Context) error {
+			drained = true
+			return nil
+		})
+	}()
+
+	responses := make(chan *http.Response, 1)
+	go func() {
+		res, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			t.Error(err)
+			close(responses)
+			return
+		}
+		responses <- res
+	}()
+	<-started
+	cancel()
+
+	// server stops accepting connections while the request is in flight
+	for i := 0; ; i++ {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			break
+		}
+		_ = conn.Close()
+		if i == 100 {
+			t.Fatal("server accepts connections after shutdown")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(release)
+
+	res, ok := <-responses
+	if !ok {
+		t.FailNow()
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("got status %d but wanted %d", res.StatusCode, http.StatusOK)
+	}
+	if err = <-served; err != nil {
+		t.Errorf("got error %v", err)
+	}
+	if !drained {
+		t.Errorf("drain is not called")
+	}
+}
+
+func TestServeShutdownTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		conf := ServerConfig{ShutdownTimeout: 50 * time.Millisecond}
+		served <- serve(ctx, server, listener, conf, func() {}, func(ctx context.Context) error {
+			return ctx.Err()
+		})
+	}()
+	go func() {
+		res, err := http.Get("http://" + listener.Addr().String())
+		if err == nil {
+			_ = res.Body.Close()
+		}
+	}()
+	<-started
+	cancel()
+
+	if err = <-served; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v but wanted %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestServeShutdownDelay(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	health := NewHealth(memstore.New(), HealthConfig{ReadyTimeout: time.Second})
+	server := &http.Server{Handler: http.HandlerFunc(health.HandleReadiness)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		conf := ServerConfig{ShutdownDelay: 200 * time.Millisecond, ShutdownTimeout: time.Minute}
+		served <- serve(ctx, server, listener, conf, health.SetShuttingDown, func(context.Context) error {
+			return nil
+		})
+	}()
+	cancel()
+
+	// readiness fails while requests are still served
+	for i := 0; ; i++ {
+		res, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			t.Fatalf("request during shutdown delay: %v", err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		if strings.Contains(string(body), "in progress") {
+			break
+		}
+		if i == 10 {
+			t.Fatal("service is ready after stop signal")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err = <-served; err != nil {
+		t.Errorf("got error %v", err)
+	}
+	if _, err = http.Get("http://" + listener.Addr().String()); err == nil {
+		t.Error("server accepts connections after shutdown delay")
+	}
+}
+
+func TestWaitImports(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redclient.NewRedisClient(context.Background(), redclient.RedisConfig{Addr: mr.Addr()})
+	cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+	processor := NewDBProcessor(client, zap.NewNop(), &singleflight.Group{}, cache)
+
+	err := processor.processJSONArray(strings.NewReader(`[{"global_id":42,"ID":1,"Mode":"abc"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err = processor.WaitImports(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// dataset version is bumped after all values are saved
+	if _, err = client.GetDatasetVersion(context.Background()); err != nil {
+		t.Errorf("import is not finished: %v", err)
+	}
+
+	processor.imports.Add(1)
+	defer processor.imports.Done()
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err = processor.WaitImports(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v but wanted %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"golang-developer-test-task/infrastructure/config"
+	"golang-developer-test-task/infrastructure/redclient"
+	"golang-developer-test-task/infrastructure/storage"
+	"golang-developer-test-task/infrastructure/storage/boltstore"
+	"golang-developer-test-task/infrastructure/storage/memstore"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// StorageRedis keeps dataset in Redis shared by all replicas
+	StorageRedis = "redis"
+	// StorageRediSearch keeps dataset in Redis as hashes indexed by RediSearch module,
+	// it falls back to StorageRedis keys if the module is not loaded
+	StorageRediSearch = "redisearch"
+	// StorageMemory keeps dataset in memory of the process, it is lost on restart
+	StorageMemory = "memory"
+	// StorageBolt keeps dataset in BoltDB file inside DataDir, so the service runs standalone
+	StorageBolt = "bolt"
+
+	boltFileName = "parkings.db"
+)
+
+// StorageConfig is struct for storing settings of dataset storage,
+// API keys and rate limits are kept by the same backend
+type StorageConfig struct {
+	Backend string
+	// DataDir is directory of data file of StorageBolt backend
+	DataDir string
+}
+
+// settings binds StorageConfig fields to config file keys, environment variables and flags
+func (c *StorageConfig) settings() []config.Setting {
+	return []config.Setting{
+		{Key: "storage.backend", Env: []string{"STORAGE_BACKEND"}, Usage: "dataset storage: redis, redisearch, memory or bolt",
+			Default: StorageRedis, Value: config.String(&c.Backend)},
+		{Key: "storage.data_dir", Env: []string{"STORAGE_DATA_DIR"}, Usage: "directory of data file of bolt storage",
+			Default: "data", Value: config.String(&c.DataDir)},
+	}
+}
+
+// Load is useful for loading StorageConfig data from environment
+func (c *StorageConfig) Load() error {
+	if err := config.LoadEnv(c.settings()); err != nil {
+		return err
+	}
+	return c.Validate()
+}
+
+// Validate checks that StorageConfig values are usable
+func (c *StorageConfig) Validate() error {
+	switch c.Backend {
+	case StorageRedis, StorageRediSearch, StorageMemory:
+		return nil
+	case StorageBolt:
+		if c.DataDir == "" {
+			return fmt.Errorf("data dir is required by %s storage", StorageBolt)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown storage backend %q, expected %q, %q, %q or %q",
+			c.Backend, StorageRedis, StorageRediSearch, StorageMemory, StorageBolt)
+	}
+}
+
+// usesRedis reports whether Redis client is used with the backend
+func (c *StorageConfig) usesRedis() bool {
+	return c.Backend == StorageRedis || c.Backend == StorageRediSearch
+}
+
+// openStore returns storage of configured backend and function closing it,
+// Redis storage is client itself which is closed by its owner, client is nil if the backend does not use it
+func openStore(c StorageConfig, client *redclient.RedisClient) (storage.Store, func() error, error) {
+	switch c.Backend {
+	case StorageRediSearch:
+		return redclient.NewSearchStore(client), func() error { return nil }, nil
+	case StorageMemory:
+		return memstore.New(), func() error { return nil }, nil
+	case StorageBolt:
+		if err := os.MkdirAll(c.DataDir, 0o750); err != nil {
+			return nil, nil, err
+		}
+		store, err := boltstore.Open(filepath.Join(c.DataDir, boltFileName))
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening data file: %w", err)
+		}
+		return store, store.Close, nil
+	default:
+		return client, func() error { return nil }, nil
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"golang-developer-test-task/infrastructure/redclient"
+	"golang-developer-test-task/infrastructure/storage/boltstore"
+	"golang-developer-test-task/infrastructure/storage/memstore"
+	"path/filepath"
+	"testing"
+)
+
+func TestStorageConfigLoad(t *testing.T) {
+	t.Setenv("STORAGE_BACKEND", "")
+	t.Setenv("STORAGE_DATA_DIR", "")
+	var config StorageConfig
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if config.Backend != StorageRedis || config.DataDir != "data" {
+		t.Errorf("got config %+v", config)
+	}
+
+	for _, c := range []StorageConfig{{Backend: "sqlite"}, {Backend: StorageBolt}} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("config %+v: expected error", c)
+		}
+	}
+}
+
+func TestOpenStore(t *testing.T) {
+	client, err := redclient.NewLazyRedisClient(redclient.RedisConfig{Addr: "localhost:0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	store, closeStore, err := openStore(StorageConfig{Backend: StorageRedis}, client)
+	if err != nil || store != client {
+		t.Errorf("got store %T and error %v but wanted redis client", store, err)
+	}
+	_ = closeStore()
+	store, closeStore, err = openStore(StorageConfig{Backend: StorageRediSearch}, client)
+	if search, ok := store.(*redclient.SearchStore); !ok || err != nil || search.RedisClient != client {
+		t.Errorf("got store %T and error %v but wanted search store of redis client", store, err)
+	}
+	_ = closeStore()
+	store, closeStore, err = openStore(StorageConfig{Backend: StorageMemory}, client)
+	if _, ok := store.(*memstore.Store); !ok || err != nil {
+		t.Errorf("got store %T and error %v but wanted memory store", store, err)
+	}
+	_ = closeStore()
+
+	dir := filepath.Join(t.TempDir(), "data")
+	store, closeStore, err = openStore(StorageConfig{Backend: StorageBolt, DataDir: dir}, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.(*boltstore.Store); !ok {
+		t.Errorf("got store %T but wanted bolt store", store)
+	}
+	if err = closeStore(); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.Check(context.Background()); err == nil {
+		t.Errorf("expected error of closed store")
+	}
+}
+
+func TestStorageUsesRedis(t *testing.T) {
+	for backend, want := range map[string]bool{
+		StorageRedis: true, StorageRediSearch: true, StorageMemory: false, StorageBolt: false,
+	} {
+		c := StorageConfig{Backend: backend}
+		if got := c.usesRedis(); got != want {
+			t.Errorf("%s: got uses redis %v but wanted %v", backend, got, want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package structs
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// infoField binds JSON name of Info field to the field, one of str and num is set
+type infoField struct {
+	name string
+	str  func(info *Info) *string
+	num  func(info *Info) *int
+}
+
+var infoFields = []infoField{
+	{name: "global_id", num: func(info *Info) *int { return &info.GlobalID }},
+	{name: "system_object_id", str: func(info *Info) *string { return &info.SystemObjectID }},
+	{name: "ID", num: func(info *Info) *int { return &info.ID }},
+	{name: "Name", str: func(info *Info) *string { return &info.Name }},
+	{name: "AdmArea", str: func(info *Info) *string { return &info.AdmArea }},
+	{name: "District", str: func(info *Info) *string { return &info.District }},
+	{name: "Address", str: func(info *Info) *string { return &info.Address }},
+	{name: "Longitude_WGS84", str: func(info *Info) *string { return &info.LongitudeWGS84 }},
+	{name: "Latitude_WGS84", str: func(info *Info) *string { return &info.LatitudeWGS84 }},
+	{name: "CarCapacity", num: func(info *Info) *int { return &info.CarCapacity }},
+	{name: "Mode", str: func(info *Info) *string { return &info.Mode }},
+	{name: "ID_en", num: func(info *Info) *int { return &info.IDEn }},
+	{name: "Name_en", str: func(info *Info) *string { return &info.NameEn }},
+	{name: "AdmArea_en", str: func(info *Info) *string { return &info.AdmAreaEn }},
+	{name: "District_en", str: func(info *Info) *string { return &info.DistrictEn }},
+	{name: "Address_en", str: func(info *Info) *string { return &info.AddressEn }},
+	{name: "Longitude_WGS84_en", str: func(info *Info) *string { return &info.LongitudeWGS84En }},
+	{name: "Latitude_WGS84_en", str: func(info *Info) *string { return &info.LatitudeWGS84En }},
+	{name: "CarCapacity_en", num: func(info *Info) *int { return &info.CarCapacityEn }},
+	{name: "Mode_en", str: func(info *Info) *string { return &info.ModeEn }},
+}
+
+var infoFieldsByName = func() map[string]infoField {
+	byName := make(map[string]infoField, len(infoFields))
+	for _, f := range infoFields {
+		byName[f.name] = f
+	}
+	return byName
+}()
+
+// InfoFieldNames returns JSON names of all Info fields in order of declaration
+func InfoFieldNames() []string {
+	names := make([]string, len(infoFields))
+	for i, f := range infoFields {
+		names[i] = f.name
+	}
+	return names
+}
+
+// IsInfoField reports whether name is JSON name of Info field
+func IsInfoField(name string) bool {
+	_, ok := infoFieldsByName[name]
+	return ok
+}
+
+// FieldValues returns values of all fields formatted as strings, aligned with InfoFieldNames
+func (info *Info) FieldValues() []string {
+	values := make([]string, len(infoFields))
+	for i, f := range infoFields {
+		if f.str != nil {
+			values[i] = *f.str(info)
+		} else {
+			values[i] = strconv.Itoa(*f.num(info))
+		}
+	}
+	return values
+}
+
+// SetField parses value of field by its JSON name
+func (info *Info) SetField(name, value string) error {
+	f, ok := infoFieldsByName[name]
+	switch {
+	case !ok:
+		return fmt.Errorf("unknown field %q", name)
+	case f.str != nil:
+		*f.str(info) = value
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("field %q must be an integer, got %q", name, value)
+	}
+	*f.num(info) = n
+	return nil
+}
+
+// Project returns values of fields by JSON names, unknown names are skipped
+func (info *Info) Project(fields []string) map[string]interface{} {
+	projected := make(map[string]interface{}, len(fields))
+	for _, name := range fields {
+		f, ok := infoFieldsByName[name]
+		switch {
+		case !ok:
+			continue
+		case f.str != nil:
+			projected[name] = *f.str(info)
+		default:
+			projected[name] = *f.num(info)
+		}
+	}
+	return projected
+}
@@ -0,0 +1,45 @@
+package structs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInfoFieldNames(t *testing.T) {
+	typ := reflect.TypeOf(Info{})
+	var want []string
+	for i := 0; i < typ.NumField(); i++ {
+		want = append(want, strings.Split(typ.Field(i).Tag.Get("json"), ",")[0])
+	}
+	if got := InfoFieldNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got fields %v but Info has %v", got, want)
+	}
+}
+
+func TestInfoFields(t *testing.T) {
+	info := Info{GlobalID: 1, SystemObjectID: "A0", Name: "parking", CarCapacityEn: 5, ModeEn: "24/7"}
+	var parsed Info
+	values := info.FieldValues()
+	for i, name := range InfoFieldNames() {
+		if err := parsed.SetField(name, values[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if parsed != info {
+		t.Errorf("got %+v but wanted %+v", parsed, info)
+	}
+
+	projected := info.Project([]string{"Name", "CarCapacity_en", "unknown"})
+	want := map[string]interface{}{"Name": "parking", "CarCapacity_en": 5}
+	if !reflect.DeepEqual(projected, want) {
+		t.Errorf("got projection %v but wanted %v", projected, want)
+	}
+
+	if err := parsed.SetField("global_id", "one"); err == nil {
+		t.Errorf("got no error of broken number")
+	}
+	if err := parsed.SetField("json", "{}"); err == nil {
+		t.Errorf("got no error of unknown field")
+	}
+}
@@ -43,7 +43,57 @@ type (
 		Mode           *string `json:"mode,omitempty"`
 		IDEn           *int    `json:"id_en,omitempty"`
 		ModeEn         *string `json:"mode_en,omitempty"`
-		Offset         int     `json:"offset,omitempty"`
+		// filters narrow down mode lists or all records, they need RediSearch backend,
+		// Radius is in meters around Longitude and Latitude
+		AdmArea        *string  `json:"adm_area,omitempty"`
+		AdmAreaEn      *string  `json:"adm_area_en,omitempty"`
+		District       *string  `json:"district,omitempty"`
+		DistrictEn     *string  `json:"district_en,omitempty"`
+		CarCapacityMin *int     `json:"car_capacity_min,omitempty"`
+		CarCapacityMax *int     `json:"car_capacity_max,omitempty"`
+		Longitude      *float64 `json:"longitude,omitempty"`
+		Latitude       *float64 `json:"latitude,omitempty"`
+		Radius         *float64 `json:"radius,omitempty"`
+		Name           *string  `json:"name,omitempty"`
+		NameEn         *string  `json:"name_en,omitempty"`
+		Offset         int      `json:"offset,omitempty"`
+		Limit          int      `json:"limit,omitempty"`
+		// Fields are JSON names of Info fields to return, all of them if empty
+		Fields []string `json:"fields,omitempty"`
+	}
+
+	// BatchSearchObject is struct for looking up many records in one query
+	BatchSearchObject struct {
+		GlobalIDs       []int    `json:"global_id,omitempty"`
+		SystemObjectIDs []string `json:"system_object_id,omitempty"`
+		IDs             []int    `json:"id,omitempty"`
+		IDEns           []int    `json:"id_en,omitempty"`
+	}
+
+	// BatchResult contains found records and keys of BatchSearchObject which were not found
+	BatchResult struct {
+		Data    InfoList          `json:"data"`
+		Missing BatchSearchObject `json:"missing"`
+	}
+
+	// ErrorObject is JSON body of error responses
+	ErrorObject struct {
+		Code      string `json:"code"`
+		Message   string `json:"message"`
+		RequestID string `json:"request_id,omitempty"`
+	}
+
+	// APIKeyObject describes API key, Key is returned only once, when key is created
+	APIKeyObject struct {
+		ID   string `json:"id,omitempty"`
+		Key  string `json:"key,omitempty"`
+		Role string `json:"role"`
+	}
+
+	// HealthObject is JSON body of health checks, Checks are results of readiness checks by name
+	HealthObject struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks,omitempty"`
 	}
 
 	// PaginationObject contains info about data by query which is contained in DB
@@ -54,4 +104,13 @@ type (
 		Offset      int64    `json:"offset"`
 		Data        InfoList `json:"data"`
 	}
+
+	// FieldsPaginationObject is PaginationObject with records reduced to requested fields
+	FieldsPaginationObject struct {
+		HasNext     bool                     `json:"hasNext"`
+		HasPrevious bool                     `json:"hasPrevious"`
+		Size        int64                    `json:"size"`
+		Offset      int64                    `json:"offset"`
+		Data        []map[string]interface{} `json:"data"`
+	}
 )
@@ -162,8 +162,143 @@ func easyjsonD0c14475DecodeGolangDeveloperTestTaskStructs1(in *jlexer.Lexer, out
 				}
 				*out.ModeEn = string(in.String())
 			}
+		case "adm_area":
+			if in.IsNull() {
+				in.Skip()
+				out.AdmArea = nil
+			} else {
+				if out.AdmArea == nil {
+					out.AdmArea = new(string)
+				}
+				*out.AdmArea = string(in.String())
+			}
+		case "adm_area_en":
+			if in.IsNull() {
+				in.Skip()
+				out.AdmAreaEn = nil
+			} else {
+				if out.AdmAreaEn == nil {
+					out.AdmAreaEn = new(string)
+				}
+				*out.AdmAreaEn = string(in.String())
+			}
+		case "district":
+			if in.IsNull() {
+				in.Skip()
+				out.District = nil
+			} else {
+				if out.District == nil {
+					out.District = new(string)
+				}
+				*out.District = string(in.String())
+			}
+		case "district_en":
+			if in.IsNull() {
+				in.Skip()
+				out.DistrictEn = nil
+			} else {
+				if out.DistrictEn == nil {
+					out.DistrictEn = new(string)
+				}
+				*out.DistrictEn = string(in.String())
+			}
+		case "car_capacity_min":
+			if in.IsNull() {
+				in.Skip()
+				out.CarCapacityMin = nil
+			} else {
+				if out.CarCapacityMin == nil {
+					out.CarCapacityMin = new(int)
+				}
+				*out.CarCapacityMin = int(in.Int())
+			}
+		case "car_capacity_max":
+			if in.IsNull() {
+				in.Skip()
+				out.CarCapacityMax = nil
+			} else {
+				if out.CarCapacityMax == nil {
+					out.CarCapacityMax = new(int)
+				}
+				*out.CarCapacityMax = int(in.Int())
+			}
+		case "longitude":
+			if in.IsNull() {
+				in.Skip()
+				out.Longitude = nil
+			} else {
+				if out.Longitude == nil {
+					out.Longitude = new(float64)
+				}
+				*out.Longitude = float64(in.Float64())
+			}
+		case "latitude":
+			if in.IsNull() {
+				in.Skip()
+				out.Latitude = nil
+			} else {
+				if out.Latitude == nil {
+					out.Latitude = new(float64)
+				}
+				*out.Latitude = float64(in.Float64())
+			}
+		case "radius":
+			if in.IsNull() {
+				in.Skip()
+				out.Radius = nil
+			} else {
+				if out.Radius == nil {
+					out.Radius = new(float64)
+				}
+				*out.Radius = float64(in.Float64())
+			}
+		case "name":
+			if in.IsNull() {
+				in.Skip()
+				out.Name = nil
+			} else {
+				if out.Name == nil {
+					out.Name = new(string)
+				}
+				*out.Name = string(in.String())
+			}
+		case "name_en":
+			if in.IsNull() {
+				in.Skip()
+				out.NameEn = nil
+			} else {
+				if out.NameEn == nil {
+					out.NameEn = new(string)
+				}
+				*out.NameEn = string(in.String())
+			}
 		case "offset":
 			out.Offset = int(in.Int())
+		case "limit":
+			out.Limit = int(in.Int())
+		case "fields":
+			if in.IsNull() {
+				in.Skip()
+				out.Fields = nil
+			} else {
+				in.Delim('[')
+				if out.Fields == nil {
+					if !in.IsDelim(']') {
+						out.Fields = make([]string, 0, 4)
+					} else {
+						out.Fields = []string{}
+					}
+				} else {
+					out.Fields = (out.Fields)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v1 string
+					v1 = string(in.String())
+					out.Fields = append(out.Fields, v1)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
 		default:
 			in.SkipRecursive()
 		}
@@ -234,6 +369,116 @@ func easyjsonD0c14475EncodeGolangDeveloperTestTaskStructs1(out *jwriter.Writer,
 		}
 		out.String(string(*in.ModeEn))
 	}
+	if in.AdmArea != nil {
+		const prefix string = ",\"adm_area\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(*in.AdmArea))
+	}
+	if in.AdmAreaEn != nil {
+		const prefix string = ",\"adm_area_en\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(*in.AdmAreaEn))
+	}
+	if in.District != nil {
+		const prefix string = ",\"district\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(*in.District))
+	}
+	if in.DistrictEn != nil {
+		const prefix string = ",\"district_en\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(*in.DistrictEn))
+	}
+	if in.CarCapacityMin != nil {
+		const prefix string = ",\"car_capacity_min\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int(int(*in.CarCapacityMin))
+	}
+	if in.CarCapacityMax != nil {
+		const prefix string = ",\"car_capacity_max\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int(int(*in.CarCapacityMax))
+	}
+	if in.Longitude != nil {
+		const prefix string = ",\"longitude\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Float64(float64(*in.Longitude))
+	}
+	if in.Latitude != nil {
+		const prefix string = ",\"latitude\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Float64(float64(*in.Latitude))
+	}
+	if in.Radius != nil {
+		const prefix string = ",\"radius\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Float64(float64(*in.Radius))
+	}
+	if in.Name != nil {
+		const prefix string = ",\"name\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(*in.Name))
+	}
+	if in.NameEn != nil {
+		const prefix string = ",\"name_en\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(*in.NameEn))
+	}
 	if in.Offset != 0 {
 		const prefix string = ",\"offset\":"
 		if first {
@@ -244,6 +489,35 @@ func easyjsonD0c14475EncodeGolangDeveloperTestTaskStructs1(out *jwriter.Writer,
 		}
 		out.Int(int(in.Offset))
 	}
+	if in.Limit != 0 {
+		const prefix string = ",\"limit\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int(int(in.Limit))
+	}
+	if len(in.Fields) != 0 {
+		const prefix string = ",\"fields\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		{
+			out.RawByte('[')
+			for v2, v3 := range in.Fields {
+				if v2 > 0 {
+					out.RawByte(',')
+				}
+				out.String(string(v3))
+			}
+			out.RawByte(']')
+		}
+	}
 	out.RawByte('}')
 }
 
@@ -289,14 +563,14 @@ func easyjsonD0c14475DecodeGolangDeveloperTestTaskStructs2(in *jlexer.Lexer, out
 			continue
 		}
 		switch key {
-		case "size":
-			out.Size = int64(in.Int64())
-		case "offset":
-			out.Offset = int64(in.Int64())
 		case "hasNext":
 			out.HasNext = bool(in.Bool())
 		case "hasPrevious":
 			out.HasPrevious = bool(in.Bool())
+		case "size":
+			out.Size = int64(in.Int64())
+		case "offset":
+			out.Offset = int64(in.Int64())
 		case "data":
 			(out.Data).UnmarshalEasyJSON(in)
 		default:
@@ -314,24 +588,24 @@ func easyjsonD0c14475EncodeGolangDeveloperTestTaskStructs2(out *jwriter.Writer,
 	first := true
 	_ = first
 	{
-		const prefix string = ",\"size\":"
+		const prefix string = ",\"hasNext\":"
 		out.RawString(prefix[1:])
-		out.Int64(int64(in.Size))
+		out.Bool(bool(in.HasNext))
 	}
 	{
-		const prefix string = ",\"offset\":"
+		const prefix string = ",\"hasPrevious\":"
 		out.RawString(prefix)
-		out.Int64(int64(in.Offset))
+		out.Bool(bool(in.HasPrevious))
 	}
 	{
-		const prefix string = ",\"hasNext\":"
+		const prefix string = ",\"size\":"
 		out.RawString(prefix)
-		out.Bool(bool(in.HasNext))
+		out.Int64(int64(in.Size))
 	}
 	{
-		const prefix string = ",\"hasPrevious\":"
+		const prefix string = ",\"offset\":"
 		out.RawString(prefix)
-		out.Bool(bool(in.HasPrevious))
+		out.Int64(int64(in.Offset))
 	}
 	{
 		const prefix string = ",\"data\":"
@@ -381,9 +655,9 @@ func easyjsonD0c14475DecodeGolangDeveloperTestTaskStructs3(in *jlexer.Lexer, out
 			*out = (*out)[:0]
 		}
 		for !in.IsDelim(']') {
-			var v1 Info
-			(v1).UnmarshalEasyJSON(in)
-			*out = append(*out, v1)
+			var v4 Info
+			(v4).UnmarshalEasyJSON(in)
+			*out = append(*out, v4)
 			in.WantComma()
 		}
 		in.Delim(']')
@@ -397,11 +671,11 @@ func easyjsonD0c14475EncodeGolangDeveloperTestTaskStructs3(out *jwriter.Writer,
 		out.RawString("null")
 	} else {
 		out.RawByte('[')
-		for v2, v3 := range in {
-			if v2 > 0 {
+		for v5, v6 := range in {
+			if v5 > 0 {
 				out.RawByte(',')
 			}
-			(v3).MarshalEasyJSON(out)
+			(v6).MarshalEasyJSON(out)
 		}
 		out.RawByte(']')
 	}
@@ -629,3 +903,743 @@ func (v *Info) UnmarshalJSON(data []byte) error {
 func (v *Info) UnmarshalEasyJSON(l *jlexer.Lexer) {
 	easyjsonD0c14475DecodeGolangDeveloperTestTaskStructs4(l, v)
 }
+func easyjsonD0c14475DecodeGolangDeveloperTestTaskStructs5(in *jlexer.Lexer, out *HealthObject) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "status":
+			out.Status = string(in.String())
+		case "checks":
+			if in.IsNull() {
+				in.Skip()
+			} else {
+				in.Delim('{')
+				if !in.IsDelim('}') {
+					out.Checks = make(map[string]string)
+				} else {
+					out.Checks = nil
+				}
+				for !in.IsDelim('}') {
+					key := string(in.String())
+					in.WantColon()
+					var v7 string
+					v7 = string(in.String())
+					(out.Checks)[key] = v7
+					in.WantComma()
+				}
+				in.Delim('}')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD0c14475EncodeGolangDeveloperTestTaskStructs5(out *jwriter.Writer, in HealthObject) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"status\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Status))
+	}
+	if len(in.Checks) != 0 {
+		const prefix string = ",\"checks\":"
+		out.RawString(prefix)
+		{
+			out.RawByte('{')
+			v8First := true
+			for v8Name, v8Value := range in.Checks {
+				if v8First {
+					v8First = false
+				} else {
+					out.RawByte(',')
+				}
+				out.String(string(v8Name))
+				out.RawByte(':')
+				out.String(string(v8Value))
+			}
+			out.RawByte('}')
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v HealthObject) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD0c14475EncodeGolangDeveloperTestTaskStructs5(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v HealthObject) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD0c14475EncodeGolangDeveloperTestTaskStructs5(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *HealthObject) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD0c14475DecodeGolangDeveloperTestTaskStructs5(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *HealthObject) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD0c14475DecodeGolangDeveloperTestTaskStructs5(l, v)
+}
+func easyjsonD0c14475DecodeGolangDeveloperTestTaskStructs6(in *jlexer.Lexer, out *FieldsPaginationObject) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "hasNext":
+			out.HasNext = bool(in.Bool())
+		case "hasPrevious":
+			out.HasPrevious = bool(in.Bool())
+		case "size":
+			out.Size = int64(in.Int64())
+		case "offset":
+			out.Offset = int64(in.Int64())
+		case "data":
+			if in.IsNull() {
+				in.Skip()
+				out.Data = nil
+			} else {
+				in.Delim('[')
+				if out.Data == nil {
+					if !in.IsDelim(']') {
+						out.Data = make([]map[string]interface{}, 0, 8)
+					} else {
+						out.Data = []map[string]interface{}{}
+					}
+				} else {
+					out.Data = (out.Data)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v9 map[string]interface{}
+					if in.IsNull() {
+						in.Skip()
+					} else {
+						in.Delim('{')
+						v9 = make(map[string]interface{})
+						for !in.IsDelim('}') {
+							key := string(in.String())
+							in.WantColon()
+							var v10 interface{}
+							if m, ok := v10.(easyjson.Unmarshaler); ok {
+								m.UnmarshalEasyJSON(in)
+							} else if m, ok := v10.(json.Unmarshaler); ok {
+								_ = m.UnmarshalJSON(in.Raw())
+							} else {
+								v10 = in.Interface()
+							}
+							(v9)[key] = v10
+							in.WantComma()
+						}
+						in.Delim('}')
+					}
+					out.Data = append(out.Data, v9)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD0c14475EncodeGolangDeveloperTestTaskStructs6(out *jwriter.Writer, in FieldsPaginationObject) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"hasNext\":"
+		out.RawString(prefix[1:])
+		out.Bool(bool(in.HasNext))
+	}
+	{
+		const prefix string = ",\"hasPrevious\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.HasPrevious))
+	}
+	{
+		const prefix string = ",\"size\":"
+		out.RawString(prefix)
+		out.Int64(int64(in.Size))
+	}
+	{
+		const prefix string = ",\"offset\":"
+		out.RawString(prefix)
+		out.Int64(int64(in.Offset))
+	}
+	{
+		const prefix string = ",\"data\":"
+		out.RawString(prefix)
+		if in.Data == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v11, v12 := range in.Data {
+				if v11 > 0 {
+					out.RawByte(',')
+				}
+				if v12 == nil && (out.Flags&jwriter.NilMapAsEmpty) == 0 {
+					out.RawString(`null`)
+				} else {
+					out.RawByte('{')
+					v13First := true
+					for v13Name, v13Value := range v12 {
+						if v13First {
+							v13First = false
+						} else {
+							out.RawByte(',')
+						}
+						out.String(string(v13Name))
+						out.RawByte(':')
+						if m, ok := v13Value.(easyjson.Marshaler); ok {
+							m.MarshalEasyJSON(out)
+						} else if m, ok := v13Value.(json.Marshaler); ok {
+							out.Raw(m.MarshalJSON())
+						} else {
+							out.Raw(json.Marshal(v13Value))
+						}
+					}
+					out.RawByte('}')
+				}
+			}
+			out.RawByte(']')
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v FieldsPaginationObject) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD0c14475EncodeGolangDeveloperTestTaskStructs6(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v FieldsPaginationObject) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD0c14475EncodeGolangDeveloperTestTaskStructs6(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *FieldsPaginationObject) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD0c14475DecodeGolangDeveloperTestTaskStructs6(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *FieldsPaginationObject) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD0c14475DecodeGolangDeveloperTestTaskStructs6(l, v)
+}
+func easyjsonD0c14475DecodeGolangDeveloperTestTaskStructs7(in *jlexer.Lexer, out *ErrorObject) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "code":
+			out.Code = string(in.String())
+		case "message":
+			out.Message = string(in.String())
+		case "request_id":
+			out.RequestID = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD0c14475EncodeGolangDeveloperTestTaskStructs7(out *jwriter.Writer, in ErrorObject) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"code\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Code))
+	}
+	{
+		const prefix string = ",\"message\":"
+		out.RawString(prefix)
+		out.String(string(in.Message))
+	}
+	if in.RequestID != "" {
+		const prefix string = ",\"request_id\":"
+		out.RawString(prefix)
+		out.String(string(in.RequestID))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v ErrorObject) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD0c14475EncodeGolangDeveloperTestTaskStructs7(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ErrorObject) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD0c14475EncodeGolangDeveloperTestTaskStructs7(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *ErrorObject) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD0c14475DecodeGolangDeveloperTestTaskStructs7(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ErrorObject) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD0c14475DecodeGolangDeveloperTestTaskStructs7(l, v)
+}
+func easyjsonD0c14475DecodeGolangDeveloperTestTaskStructs8(in *jlexer.Lexer, out *BatchSearchObject) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "global_id":
+			if in.IsNull() {
+				in.Skip()
+				out.GlobalIDs = nil
+			} else {
+				in.Delim('[')
+				if out.GlobalIDs == nil {
+					if !in.IsDelim(']') {
+						out.GlobalIDs = make([]int, 0, 8)
+					} else {
+						out.GlobalIDs = []int{}
+					}
+				} else {
+					out.GlobalIDs = (out.GlobalIDs)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v14 int
+					v14 = int(in.Int())
+					out.GlobalIDs = append(out.GlobalIDs, v14)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "system_object_id":
+			if in.IsNull() {
+				in.Skip()
+				out.SystemObjectIDs = nil
+			} else {
+				in.Delim('[')
+				if out.SystemObjectIDs == nil {
+					if !in.IsDelim(']') {
+						out.SystemObjectIDs = make([]string, 0, 4)
+					} else {
+						out.SystemObjectIDs = []string{}
+					}
+				} else {
+					out.SystemObjectIDs = (out.SystemObjectIDs)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v15 string
+					v15 = string(in.String())
+					out.SystemObjectIDs = append(out.SystemObjectIDs, v15)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "id":
+			if in.IsNull() {
+				in.Skip()
+				out.IDs = nil
+			} else {
+				in.Delim('[')
+				if out.IDs == nil {
+					if !in.IsDelim(']') {
+						out.IDs = make([]int, 0, 8)
+					} else {
+						out.IDs = []int{}
+					}
+				} else {
+					out.IDs = (out.IDs)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v16 int
+					v16 = int(in.Int())
+					out.IDs = append(out.IDs, v16)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "id_en":
+			if in.IsNull() {
+				in.Skip()
+				out.IDEns = nil
+			} else {
+				in.Delim('[')
+				if out.IDEns == nil {
+					if !in.IsDelim(']') {
+						out.IDEns = make([]int, 0, 8)
+					} else {
+						out.IDEns = []int{}
+					}
+				} else {
+					out.IDEns = (out.IDEns)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v17 int
+					v17 = int(in.Int())
+					out.IDEns = append(out.IDEns, v17)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD0c14475EncodeGolangDeveloperTestTaskStructs8(out *jwriter.Writer, in BatchSearchObject) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if len(in.GlobalIDs) != 0 {
+		const prefix string = ",\"global_id\":"
+		first = false
+		out.RawString(prefix[1:])
+		{
+			out.RawByte('[')
+			for v18, v19 := range in.GlobalIDs {
+				if v18 > 0 {
+					out.RawByte(',')
+				}
+				out.Int(int(v19))
+			}
+			out.RawByte(']')
+		}
+	}
+	if len(in.SystemObjectIDs) != 0 {
+		const prefix string = ",\"system_object_id\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		{
+			out.RawByte('[')
+			for v20, v21 := range in.SystemObjectIDs {
+				if v20 > 0 {
+					out.RawByte(',')
+				}
+				out.String(string(v21))
+			}
+			out.RawByte(']')
+		}
+	}
+	if len(in.IDs) != 0 {
+		const prefix string = ",\"id\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		{
+			out.RawByte('[')
+			for v22, v23 := range in.IDs {
+				if v22 > 0 {
+					out.RawByte(',')
+				}
+				out.Int(int(v23))
+			}
+			out.RawByte(']')
+		}
+	}
+	if len(in.IDEns) != 0 {
+		const prefix string = ",\"id_en\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		{
+			out.RawByte('[')
+			for v24, v25 := range in.IDEns {
+				if v24 > 0 {
+					out.RawByte(',')
+				}
+				out.Int(int(v25))
+			}
+			out.RawByte(']')
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v BatchSearchObject) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD0c14475EncodeGolangDeveloperTestTaskStructs8(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v BatchSearchObject) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD0c14475EncodeGolangDeveloperTestTaskStructs8(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *BatchSearchObject) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD0c14475DecodeGolangDeveloperTestTaskStructs8(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *BatchSearchObject) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD0c14475DecodeGolangDeveloperTestTaskStructs8(l, v)
+}
+func easyjsonD0c14475DecodeGolangDeveloperTestTaskStructs9(in *jlexer.Lexer, out *BatchResult) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "data":
+			(out.Data).UnmarshalEasyJSON(in)
+		case "missing":
+			(out.Missing).UnmarshalEasyJSON(in)
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD0c14475EncodeGolangDeveloperTestTaskStructs9(out *jwriter.Writer, in BatchResult) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"data\":"
+		out.RawString(prefix[1:])
+		(in.Data).MarshalEasyJSON(out)
+	}
+	{
+		const prefix string = ",\"missing\":"
+		out.RawString(prefix)
+		(in.Missing).MarshalEasyJSON(out)
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v BatchResult) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD0c14475EncodeGolangDeveloperTestTaskStructs9(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v BatchResult) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD0c14475EncodeGolangDeveloperTestTaskStructs9(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *BatchResult) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD0c14475DecodeGolangDeveloperTestTaskStructs9(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *BatchResult) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD0c14475DecodeGolangDeveloperTestTaskStructs9(l, v)
+}
+func easyjsonD0c14475DecodeGolangDeveloperTestTaskStructs10(in *jlexer.Lexer, out *APIKeyObject) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.ID = string(in.String())
+		case "key":
+			out.Key = string(in.String())
+		case "role":
+			out.Role = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD0c14475EncodeGolangDeveloperTestTaskStructs10(out *jwriter.Writer, in APIKeyObject) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if in.ID != "" {
+		const prefix string = ",\"id\":"
+		first = false
+		out.RawString(prefix[1:])
+		out.String(string(in.ID))
+	}
+	if in.Key != "" {
+		const prefix string = ",\"key\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Key))
+	}
+	{
+		const prefix string = ",\"role\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Role))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v APIKeyObject) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD0c14475EncodeGolangDeveloperTestTaskStructs10(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v APIKeyObject) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD0c14475EncodeGolangDeveloperTestTaskStructs10(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *APIKeyObject) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD0c14475DecodeGolangDeveloperTestTaskStructs10(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *APIKeyObject) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD0c14475DecodeGolangDeveloperTestTaskStructs10(l, v)
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-developer-test-task/infrastructure/config"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	fetchTimeout      = 30 * time.Second
+	maxFetchRedirects = 10
+)
+
+// blockedNetworks are special-purpose ranges which are not covered by methods of net.IP
+var blockedNetworks = func() []*net.IPNet {
+	cidrs := []string{
+		"0.0.0.0/8",      // "this" network
+		"100.64.0.0/10",  // carrier-grade NAT
+		"192.0.0.0/24",   // IETF protocol assignments
+		"198.18.0.0/15",  // benchmarking
+		"240.0.0.0/4",    // reserved
+		"64:ff9b::/96",   // NAT64, can point to private IPv4
+		"64:ff9b:1::/48", // local-use NAT64
+		"2001:db8::/32",  // documentation
+		"2002::/16",      // 6to4, can point to private IPv4
+		"2001::/32",      // Teredo
+		"100::/64",       // discard-only
+		"fec0::/10",      // deprecated site-local
+		"ff00::/8",       // multicast
+		"255.255.255.255/32",
+	}
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// FetchConfig is struct for storing restrictions of fetching dataset from URL
+type FetchConfig struct {
+	// AllowedSchemes are allowed URL schemes, http and https if empty
+	AllowedSchemes []string
+	// AllowedHosts are allowed hosts, "*.example.com" allows subdomains, any host is allowed if empty
+	AllowedHosts []string
+	// AllowPrivate allows addresses of loopback, private and link-local networks
+	AllowPrivate bool
+}
+
+// settings binds FetchConfig fields to config file keys, environment variables and flags
+func (c *FetchConfig) settings() []config.Setting {
+	return []config.Setting{
+		{Key: "fetch.allowed_schemes", Env: []string{"FETCH_ALLOWED_SCHEMES"},
+			Usage: "comma-separated URL schemes allowed for load_from_url, http and https if empty",
+			Value: config.LowerList(&c.AllowedSchemes)},
+		{Key: "fetch.allowed_hosts", Env: []string{"FETCH_ALLOWED_HOSTS"},
+			Usage: "comma-separated hosts allowed for load_from_url, *.example.com allows subdomains, any if empty",
+			Value: config.LowerList(&c.AllowedHosts)},
+		{Key: "fetch.allow_private", Env: []string{"FETCH_ALLOW_PRIVATE"},
+			Usage: "allow loopback, private and link-local addresses", Default: "false", Value: config.Bool(&c.AllowPrivate)},
+	}
+}
+
+// Load is useful for loading FetchConfig data from environment
+func (c *FetchConfig) Load() error {
+	if err := config.LoadEnv(c.settings()); err != nil {
+		return err
+	}
+	return c.Validate()
+}
+
+// Validate checks that FetchConfig values are usable
+func (c *FetchConfig) Validate() error {
+	for _, scheme := range c.AllowedSchemes {
+		if scheme != "http" && scheme != "https" {
+			return fmt.Errorf("unsupported fetch scheme %q, expected http or https", scheme)
+		}
+	}
+	return nil
+}
+
+// URLRefusedError is returned for URLs which are forbidden to fetch
+type URLRefusedError struct {
+	Reason string
+}
+
+// Error implements error interface
+func (e *URLRefusedError) Error() string {
+	return "url is refused: " + e.Reason
+}
+
+// URLFetcher fetches URLs passing FetchConfig restrictions,
+// resolved addresses are checked on every connection, so redirects and DNS rebinding are covered
+type URLFetcher struct {
+	config FetchConfig
+	client *http.Client
+}
+
+// NewURLFetcher is constructor for URLFetcher
+func NewURLFetcher(config FetchConfig) *URLFetcher {
+	if len(config.AllowedSchemes) == 0 {
+		config.AllowedSchemes = []string{"http", "https"}
+	}
+	f := &URLFetcher{config: config}
+
+	dialer := &net.Dialer{
+		Timeout:   fetchTimeout,
+		KeepAlive: 30 * time.Second,
+		Control:   f.checkConn,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// proxy would be dialed instead of the target, so its address could not be checked
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	f.client = &http.Client{
+		Timeout:   fetchTimeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxFetchRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxFetchRedirects)
+			}
+			return f.CheckURL(req.URL)
+		},
+	}
+	return f
+}
+
+// Get fetches url with GET request
+func (f *URLFetcher) Get(ctx context.Context, rawURL string) (*http.Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, &URLRefusedError{Reason: err.Error()}
+	}
+	if err = f.CheckURL(u); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	return f.client.Do(req)
+}
+
+// CheckURL checks scheme and host of URL, IP literals are checked as well
+func (f *URLFetcher) CheckURL(u *url.URL) error {
+	if !contains(f.config.AllowedSchemes, strings.ToLower(u.Scheme)) {
+		return &URLRefusedError{Reason: fmt.Sprintf("scheme %q is not allowed", u.Scheme)}
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return &URLRefusedError{Reason: "host is empty"}
+	}
+	if len(f.config.AllowedHosts) > 0 && !hostAllowed(f.config.AllowedHosts, host) {
+		return &URLRefusedError{Reason: fmt.Sprintf("host %q is not allowed", host)}
+	}
+	if ip := net.ParseIP(host); ip != nil && !f.config.AllowPrivate && blockedIP(ip) {
+		return &URLRefusedError{Reason: fmt.Sprintf("address %s is not public", ip)}
+	}
+	return nil
+}
+
+// checkConn is net.Dialer control function which checks resolved address before connecting
+func (f *URLFetcher) checkConn(network, address string, _ syscall.RawConn) error {
+	if f.config.AllowPrivate {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return &URLRefusedError{Reason: fmt.Sprintf("address %q is not an IP", host)}
+	}
+	if blockedIP(ip) {
+		return &URLRefusedError{Reason: fmt.Sprintf("address %s is not public", ip)}
+	}
+	return nil
+}
+
+// blockedIP reports whether ip belongs to loopback, private, link-local or other non-public network
+func blockedIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func hostAllowed(allowed []string, host string) bool {
+	for _, pattern := range allowed {
+		if suffix := strings.TrimPrefix(pattern, "*"); suffix != pattern {
+			if strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+				return true
+			}
+			continue
+		}
+		if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(items []string, item string) bool {
+	for _, it := range items {
+		if it == item {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchError maps errors of URLFetcher.Get onto API errors
+func fetchError(err error) error {
+	var refused *URLRefusedError
+	if errors.As(err, &refused) {
+		return newAPIError(KindValidation, refused.Error(), err)
+	}
+	return newAPIError(KindUpstreamFetch, "cannot fetch url", err)
+}
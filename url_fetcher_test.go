@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"golang-developer-test-task/infrastructure/redclient"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v8"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+func TestFetchConfigLoad(t *testing.T) {
+	t.Setenv("FETCH_ALLOWED_SCHEMES", "HTTPS, ")
+	t.Setenv("FETCH_ALLOWED_HOSTS", "data.mos.ru,*.data.gov.ru")
+	t.Setenv("FETCH_ALLOW_PRIVATE", "")
+	var config FetchConfig
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	want := FetchConfig{
+		AllowedSchemes: []string{"https"},
+		AllowedHosts:   []string{"data.mos.ru", "*.data.gov.ru"},
+	}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("got config %v but wanted %v", config, want)
+	}
+
+	t.Setenv("FETCH_ALLOWED_SCHEMES", "file")
+	if err := config.Load(); err == nil {
+		t.Errorf("expected error for file scheme")
+	}
+	t.Setenv("FETCH_ALLOWED_SCHEMES", "")
+	t.Setenv("FETCH_ALLOW_PRIVATE", "sometimes")
+	if err := config.Load(); err == nil {
+		t.Errorf("expected error for FETCH_ALLOW_PRIVATE")
+	}
+}
+
+func TestBlockedIP(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1":        true,
+		"10.1.2.3":         true,
+		"172.16.0.1":       true,
+		"192.168.1.1":      true,
+		"169.254.169.254":  true,
+		"100.64.0.1":       true,
+		"0.0.0.0":          true,
+		"224.0.0.1":        true,
+		"::1":              true,
+		"::":               true,
+		"fe80::1":          true,
+		"fc00::1":          true,
+		"::ffff:127.0.0.1": true,
+		"::ffff:10.0.0.1":  true,
+		"64:ff9b::a00:1":   true,
+		"8.8.8.8":          false,
+		"213.180.204.62":   false,
+		"2a00:1450::1":     false,
+	}
+	for addr, want := range tests {
+		if got := blockedIP(net.ParseIP(addr)); got != want {
+			t.Errorf("%s: got %v but wanted %v", addr, got, want)
+		}
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	fetcher := NewURLFetcher(FetchConfig{AllowedHosts: []string{"data.mos.ru", "*.data.gov.ru", "8.8.8.8"}})
+	tests := map[string]bool{
+		"https://data.mos.ru/data.json":         true,
+		"http://DATA.MOS.RU:8080/data.json":     true,
+		"https://files.data.gov.ru/data.json":   true,
+		"https://8.8.8.8/data.json":             true,
+		"https://data.gov.ru/data.json":         false,
+		"https://evil.ru/data.json":             false,
+		"https://data.mos.ru.evil.ru/data.json": false,
+		"ftp://data.mos.ru/data.json":           false,
+		"file:///etc/passwd":                    false,
+		"gopher://data.mos.ru":                  false,
+		"/data.json":                            false,
+	}
+	for rawURL, want := range tests {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = fetcher.CheckURL(u)
+		var refused *URLRefusedError
+		if err != nil && !errors.As(err, &refused) {
+			t.Errorf("%s: unexpected error %v", rawURL, err)
+		}
+		if got := err == nil; got != want {
+			t.Errorf("%s: got allowed %v but wanted %v", rawURL, got, want)
+		}
+	}
+
+	fetcher = NewURLFetcher(FetchConfig{})
+	for _, rawURL := range []string{"http://127.0.0.1/", "http://[::1]:80/", "http://169.254.169.254/latest/meta-data"} {
+		u, _ := url.Parse(rawURL)
+		if err := fetcher.CheckURL(u); err == nil {
+			t.Errorf("%s: private address is allowed", rawURL)
+		}
+	}
+}
+
+func TestURLFetcherRefusesResolvedPrivateAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	fetcher := NewURLFetcher(FetchConfig{})
+	// host name passes CheckURL, its address is checked when connecting
+	target := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	_, err := fetcher.Get(context.Background(), target)
+	var refused *URLRefusedError
+	if !errors.As(err, &refused) {
+		t.Fatalf("got error %v but wanted URLRefusedError", err)
+	}
+
+	fetcher = NewURLFetcher(FetchConfig{AllowPrivate: true})
+	resp, err := fetcher.Get(context.Background(), target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+}
+
+func TestURLFetcherRedirect(t *testing.T) {
+	var redirectTo string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, redirectTo, http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := NewURLFetcher(FetchConfig{AllowedHosts: []string{"127.0.0.1"}, AllowPrivate: true})
+	for _, target := range []string{
+		strings.Replace(server.URL, "127.0.0.1", "localhost", 1) + "/data.json",
+		"http://169.254.169.254/latest/meta-data",
+		"file:///etc/passwd",
+	} {
+		redirectTo = target
+		_, err := fetcher.Get(context.Background(), server.URL+"/redirect")
+		var refused *URLRefusedError
+		if !errors.As(err, &refused) {
+			t.Errorf("%s: got error %v but wanted URLRefusedError", target, err)
+		}
+	}
+
+	redirectTo = server.URL + "/data.json"
+	resp, err := fetcher.Get(context.Background(), server.URL+"/redirect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.Request.URL.Path != "/data.json" {
+		t.Errorf("redirect is not followed")
+	}
+}
+
+func TestCheckConn(t *testing.T) {
+	fetcher := NewURLFetcher(FetchConfig{})
+	tests := map[string]bool{
+		"127.0.0.1:80":       false,
+		"[::1]:443":          false,
+		"169.254.169.254:80": false,
+		"10.0.0.1:6379":      false,
+		"8.8.8.8:443":        true,
+		"[2a00:1450::1]:443": true,
+	}
+	for address, want := range tests {
+		if got := fetcher.checkConn("tcp", address, nil) == nil; got != want {
+			t.Errorf("%s: got allowed %v but wanted %v", address, got, want)
+		}
+	}
+}
+
+func TestHandleLoadFromURLRefused(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	db, _ := redismock.NewClientMock()
+	client := &redclient.RedisClient{UniversalClient: db, MaxRetries: 10}
+	logger, _ := zap.NewProduction()
+	defer func() {
+		_ = logger.Sync()
+	}()
+	cache := NewSearchCache(CacheConfig{TTL: time.Minute, Policy: CachePolicyLRU})
+	processor := NewDBProcessor(client, logger, &singleflight.Group{}, cache)
+
+	for _, target := range []string{
+		server.URL,
+		strings.Replace(server.URL, "127.0.0.1", "localhost", 1),
+		"http://169.254.169.254/latest/meta-data",
+		"file:///etc/passwd",
+	} {
+		req := httptest.NewRequest("POST", "/api/load_from_url", strings.NewReader(`{"url":"`+target+`"}`))
+		res := httptest.NewRecorder()
+		processor.HandleLoadFromURL(res, req)
+
+		if res.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: got status %d but wanted %d", target, res.Code, http.StatusUnprocessableEntity)
+		}
+		if !strings.Contains(res.Body.String(), "url is refused") {
+			t.Errorf("%s: got body %s", target, res.Body.String())
+		}
+	}
+}